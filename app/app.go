@@ -7,7 +7,6 @@ import (
 	"github.com/SUSE/saptune/system"
 	"github.com/SUSE/saptune/txtparser"
 	"io"
-	"io/ioutil"
 	"os"
 	"path"
 	"reflect"
@@ -21,37 +20,46 @@ const (
 	TuneForSolutionsKey  = "TUNE_FOR_SOLUTIONS"
 	TuneForNotesKey      = "TUNE_FOR_NOTES"
 	NoteApplyOrderKey    = "NOTE_APPLY_ORDER"
+	PersistSysctlKey     = "PERSIST_SYSCTL"
 )
 
 // App defines the application configuration and serialised state information.
 type App struct {
 	SysconfigPrefix  string
 	AllNotes         map[string]note.Note         // all notes
-	AllSolutions     map[string]solution.Solution // all solutions
+	AllSolutions     map[string]solution.Solution // all solutions of the architecture
+	Catalog          *solution.Catalog            // solutions of all architectures, overrides and deprecations
+	Paths            solution.CatalogPaths        // locations of the Note and solution catalogs
 	TuneForSolutions []string                     // list of solution names to tune, must always be sorted in ascending order.
 	TuneForNotes     []string                     // list of additional notes to tune, must always be sorted in ascending order.
 	NoteApplyOrder   []string                     // list of notes in applied order. Do NOT sort.
+	PersistSysctl    bool                         // write the [sysctl] values to a sysctl.d drop-in file
 	State            *State                       // examine and manage serialised notes.
 }
 
 // InitialiseApp load application configuration. Panic on error.
-func InitialiseApp(sysconfigPrefix, stateDirPrefix string, allNotes map[string]note.Note, allSolutions map[string]solution.Solution) (app *App) {
+// The Notes and solutions are loaded by the catalog loader, whose locations
+// can be changed in the sysconfig file
+func InitialiseApp(sysconfigPrefix, stateDirPrefix string, loader *CatalogLoader) (app *App) {
 	app = &App{
 		SysconfigPrefix: sysconfigPrefix,
 		State:           &State{StateDirPrefix: stateDirPrefix},
-		AllNotes:        allNotes,
-		AllSolutions:    allSolutions,
 	}
 	sysconf, err := txtparser.ParseSysconfigFile(path.Join(app.SysconfigPrefix, SysconfigSaptuneFile), true)
 	if err == nil {
 		app.TuneForSolutions = sysconf.GetStringArray(TuneForSolutionsKey, []string{})
 		app.TuneForNotes = sysconf.GetStringArray(TuneForNotesKey, []string{})
 		app.NoteApplyOrder = sysconf.GetStringArray(NoteApplyOrderKey, []string{})
+		app.PersistSysctl = sysconf.GetBool(PersistSysctlKey, false)
+		loader = loader.Relocated(sysconf)
 	} else {
 		app.TuneForSolutions = []string{}
 		app.TuneForNotes = []string{}
 		app.NoteApplyOrder = []string{}
 	}
+	app.Paths = loader.Paths
+	app.AllNotes, app.Catalog = loader.Load()
+	app.AllSolutions = app.Catalog.ArchSolutions(loader.Arch)
 	sort.Strings(app.TuneForSolutions)
 	sort.Strings(app.TuneForNotes)
 	return
@@ -85,15 +93,32 @@ func (app *App) SaveConfig() error {
 	sysconf.SetStrArray(TuneForSolutionsKey, app.TuneForSolutions)
 	sysconf.SetStrArray(TuneForNotesKey, app.TuneForNotes)
 	sysconf.SetStrArray(NoteApplyOrderKey, app.NoteApplyOrder)
-	return ioutil.WriteFile(path.Join(app.SysconfigPrefix, SysconfigSaptuneFile), []byte(sysconf.ToText()), 0644)
+	return system.WriteFile(path.Join(app.SysconfigPrefix, SysconfigSaptuneFile), []byte(sysconf.ToText()), 0644)
 }
 
 // GetSortedSolutionEnabledNotes returns the number of all solution-enabled
-// SAP notes, sorted.
+// SAP notes, sorted. The solutions referenced by the enabled solutions are
+// resolved, enabled solutions, which can not be resolved, are skipped
 func (app *App) GetSortedSolutionEnabledNotes() (allNoteIDs []string) {
+	allNoteIDs, errs := app.solutionEnabledNotes()
+	for _, err := range errs {
+		system.WarningLog("%v", err)
+	}
+	return
+}
+
+// solutionEnabledNotes returns the sorted notes of the resolved enabled
+// solutions and the errors of the solutions, which can not be resolved
+func (app *App) solutionEnabledNotes() (allNoteIDs []string, errs []error) {
 	allNoteIDs = make([]string, 0, 0)
-	for _, sol := range app.TuneForSolutions {
-		for _, noteID := range app.AllSolutions[sol] {
+	errs = make([]error, 0, 0)
+	for _, solName := range app.TuneForSolutions {
+		sol, err := solution.ResolveSolution(app.AllSolutions, solName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, noteID := range sol {
 			if i := sort.SearchStrings(allNoteIDs, noteID); !(i < len(allNoteIDs) && allNoteIDs[i] == noteID) {
 				allNoteIDs = append(allNoteIDs, noteID)
 				sort.Strings(allNoteIDs)
@@ -114,11 +139,12 @@ Run "saptune note list" for a complete list of supported notes.
 and then please double check your input and /etc/sysconfig/saptune`, id)
 }
 
-// GetSolutionByName return the solution corresponding to the name,
-// or an error if it does not exist.
+// GetSolutionByName return the notes of the solution corresponding to
+// the name with all referenced solutions resolved, or an error if it does
+// not exist or can not be resolved.
 func (app *App) GetSolutionByName(name string) (solution.Solution, error) {
-	if n, exists := app.AllSolutions[name]; exists {
-		return n, nil
+	if _, exists := app.AllSolutions[name]; exists {
+		return solution.ResolveSolution(app.AllSolutions, name)
 	}
 	return nil, fmt.Errorf(`solution name "%s" is not recognised by saptune.
 Run "saptune solution list" for a complete list of supported solutions,
@@ -206,15 +232,172 @@ func (app *App) TuneNote(noteID string) error {
 	if conforming && !forceApply {
 		// Do not apply the Note, if the system already complies with
 		// the requirements.
+		app.updateSysctlDropIn()
+		app.saveDefinition(noteID, aNote)
 		return nil
 	}
 	if err := optimised.Apply(); err != nil {
 		return fmt.Errorf("Failed to apply note %s - %v", noteID, err)
 	}
+	app.updateSysctlDropIn()
+	app.saveDefinition(noteID, aNote)
 
 	return nil
 }
 
+// DefinitionChange describes the changes of the Note definition or the
+// override file of an applied note since the note was applied
+type DefinitionChange struct {
+	NoteID     string
+	OldVersion string
+	NewVersion string
+	Params     []string // changed parameters
+}
+
+// ChangedDefinition checks, if the Note definition or the override file of
+// an applied note changed since the note was applied. Returns nil, if the
+// definition is unchanged or no saved definition is available
+func (app *App) ChangedDefinition(noteID string) *DefinitionChange {
+	aNote, err := app.GetNoteByID(noteID)
+	if err != nil {
+		return nil
+	}
+	iniNote, ok := aNote.(note.INISettings)
+	if !ok {
+		return nil
+	}
+	saved, err := app.State.RetrieveDefinition(noteID)
+	if err != nil {
+		return nil
+	}
+	current, err := iniNote.Definition()
+	if err != nil || !current.Changed(saved) {
+		return nil
+	}
+	return &DefinitionChange{
+		NoteID:     noteID,
+		OldVersion: saved.Version,
+		NewVersion: current.Version,
+		Params:     current.DiffParams(saved),
+	}
+}
+
+// ChangedDefinitions returns the changes of the Note definitions of all
+// applied notes in the apply order
+func (app *App) ChangedDefinitions() []DefinitionChange {
+	changes := make([]DefinitionChange, 0, 0)
+	for _, noteID := range app.NoteApplyOrder {
+		if change := app.ChangedDefinition(noteID); change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	return changes
+}
+
+// saveDefinition saves the version, content hash and parameter values of
+// the Note definition of an applied note. A change of the Note definition
+// since the former apply (e.g. during 'daemon apply' after a package
+// update) is logged
+func (app *App) saveDefinition(noteID string, aNote note.Note) {
+	iniNote, ok := aNote.(note.INISettings)
+	if !ok {
+		return
+	}
+	if change := app.ChangedDefinition(noteID); change != nil {
+		system.WarningLog("Note definition of note '%s' changed since the note was applied (version '%s' -> '%s'), the new definition is applied now. Changed parameters: %s", noteID, change.OldVersion, change.NewVersion, strings.Join(change.Params, ", "))
+	}
+	def, err := iniNote.Definition()
+	if err != nil {
+		system.WarningLog("failed to read the Note definition of note '%s' - %v", noteID, err)
+		return
+	}
+	if err := app.State.StoreDefinition(noteID, def); err != nil {
+		system.WarningLog("failed to save the Note definition state of note '%s' - %v", noteID, err)
+	}
+}
+
+// updateSysctlDropIn writes the effective [sysctl] values of all applied
+// notes to the sysctl.d drop-in file managed by saptune, if enabled in
+// /etc/sysconfig/saptune. The effective value of a parameter is the one of
+// the last applied note in the parameter state file.
+// If disabled or no [sysctl] values are applied, the file is removed.
+func (app *App) updateSysctlDropIn() {
+	params := make(map[string]string)
+	if app.PersistSysctl {
+		for _, noteID := range app.NoteApplyOrder {
+			aNote, err := app.GetNoteByID(noteID)
+			if err != nil || reflect.TypeOf(aNote).String() != "note.INISettings" {
+				continue
+			}
+			for _, key := range aNote.(note.INISettings).SysctlKeys() {
+				pEntries := note.GetSavedParameterNotes(key)
+				if len(pEntries.AllNotes) > 1 {
+					// first entry is the start value
+					params[key] = pEntries.AllNotes[len(pEntries.AllNotes)-1].Value
+				}
+			}
+		}
+	}
+	dropIn := path.Join(app.SysconfigPrefix, system.SysctlDropInFile)
+	if err := system.WriteSysctlDropIn(dropIn, params); err != nil {
+		system.WarningLog("failed to write sysctl drop-in file '%s': %v", dropIn, err)
+	}
+}
+
+// TuneBlockDevice applies the [block] section settings of all applied notes
+// to a single block device. Used for block devices, which are added after
+// the notes were applied (e.g. hot-plugged LUNs), triggered by udev.
+// The current values of the block device are added to the saved state of
+// the notes to be able to revert the values
+func (app *App) TuneBlockDevice(bdev string) error {
+	if _, err := os.Stat(path.Join("/sys/block", bdev)); err != nil {
+		return fmt.Errorf("block device '%s' not found - %v", bdev, err)
+	}
+	for _, noteID := range app.NoteApplyOrder {
+		aNote, err := app.GetNoteByID(noteID)
+		if err != nil {
+			_ = system.ErrorLog(err.Error())
+			continue
+		}
+		if reflect.TypeOf(aNote).String() != "note.INISettings" {
+			continue
+		}
+		savedState := note.INISettings{}
+		if err := app.State.Retrieve(noteID, &savedState); err != nil {
+			// note not applied
+			continue
+		}
+		currentState, err := aNote.Initialise()
+		if err != nil {
+			return fmt.Errorf("Failed to examine system for the current status of note %s - %v", noteID, err)
+		}
+		keys := note.BlockDeviceKeys(currentState.(note.INISettings).SysctlParams, bdev)
+		if len(keys) == 0 {
+			continue
+		}
+		if savedState.SysctlParams == nil {
+			savedState.SysctlParams = make(map[string]string)
+		}
+		for _, key := range keys {
+			if _, ok := savedState.SysctlParams[key]; !ok {
+				savedState.SysctlParams[key] = currentState.(note.INISettings).SysctlParams[key]
+			}
+		}
+		if err = app.State.Store(noteID, savedState, true); err != nil {
+			return fmt.Errorf("Failed to save current state of note %s - %v", noteID, err)
+		}
+		optimised, err := currentState.Optimise()
+		if err != nil {
+			return fmt.Errorf("Failed to calculate optimised parameters for note %s - %v", noteID, err)
+		}
+		if err := optimised.(note.INISettings).SetValuesToApply(keys).Apply(); err != nil {
+			return fmt.Errorf("Failed to apply block device settings of note %s to '%s' - %v", noteID, bdev, err)
+		}
+		system.InfoLog("block device settings of note %s applied to '%s'", noteID, bdev)
+	}
+	return nil
+}
+
 // TuneSolution apply tuning for a solution.
 // If the solution is not yet enabled, the name will be added into the list
 // of tuned solution names.
@@ -262,6 +445,20 @@ func (app *App) TuneAll() error {
 	return nil
 }
 
+// ReapplyNote reverts an applied note without removing it from the
+// configuration and applies it again to get changes of the Note definition
+// or the override file to take effect. The position of the note in the
+// apply order is kept. A note, which is not applied, is left untouched
+func (app *App) ReapplyNote(noteID string) error {
+	if app.PositionInNoteApplyOrder(noteID) < 0 {
+		return nil
+	}
+	if err := app.RevertNote(noteID, false); err != nil {
+		return fmt.Errorf("Failed to revert note %s - %v", noteID, err)
+	}
+	return app.TuneNote(noteID)
+}
+
 // RevertNote revert parameters tuned by the note and clear its stored states.
 func (app *App) RevertNote(noteID string, permanent bool) error {
 	noteTemplate, err := app.GetNoteByID(noteID)
@@ -294,6 +491,9 @@ func (app *App) RevertNote(noteID string, permanent bool) error {
 	if err = app.State.Retrieve(noteID, &noteIface); err == nil {
 		var noteRecovered note.Note = noteIface.(note.Note)
 		if reflect.TypeOf(noteRecovered).String() == "*note.INISettings" {
+			// the saved state does not contain the Note files of the
+			// catalog, which are needed to find the base Notes
+			noteRecovered.(*note.INISettings).NoteFiles = noteTemplate.(note.INISettings).NoteFiles
 			noteRecovered = noteRecovered.(*note.INISettings).SetValuesToApply([]string{"revert"})
 		}
 
@@ -301,10 +501,13 @@ func (app *App) RevertNote(noteID string, permanent bool) error {
 			return err
 		} else if err := app.State.Remove(noteID); err != nil {
 			return err
+		} else if err := app.State.RemoveDefinition(noteID); err != nil {
+			return err
 		}
 	} else if !os.IsNotExist(err) {
 		return err
 	}
+	app.updateSysctlDropIn()
 	return nil
 }
 
@@ -328,17 +531,14 @@ func (app *App) RevertSolution(solName string) error {
 	for _, noteID := range app.TuneForNotes {
 		notesDoNotRevert[noteID] = struct{}{}
 	}
-	// Do not revert notes that are referred to by other enabled solutions
-	for _, otherSolName := range app.TuneForSolutions {
-		if otherSolName != solName {
-			otherSolNotes, err := app.GetSolutionByName(otherSolName)
-			if err != nil {
-				return err
-			}
-			for _, noteID := range otherSolNotes {
-				notesDoNotRevert[noteID] = struct{}{}
-			}
-		}
+	// Do not revert notes that are referred to by other enabled solutions,
+	// directly or by referenced solutions
+	otherSolNotes, errs := app.solutionEnabledNotes()
+	if len(errs) != 0 {
+		return errs[0]
+	}
+	for _, noteID := range otherSolNotes {
+		notesDoNotRevert[noteID] = struct{}{}
 	}
 	// Now revert the (sol notes - manually enabled - other sol notes)
 	noteErrs := make([]error, 0, 0)
@@ -358,6 +558,78 @@ func (app *App) RevertSolution(solName string) error {
 	return fmt.Errorf("Failed to revert one or more SAP notes that belong to the solution: %v", noteErrs)
 }
 
+// MigrateSolution replaces the enabled solution 'oldSol' by the solution
+// 'newSol' in the configuration. Only the notes, which differ between both
+// solutions, are reverted or applied. Notes still needed as additional notes
+// or by other enabled solutions are not reverted.
+func (app *App) MigrateSolution(oldSol, newSol string) (revertedNotes, appliedNotes []string, err error) {
+	revertedNotes = make([]string, 0, 0)
+	appliedNotes = make([]string, 0, 0)
+	oldNotes, err := app.GetSolutionByName(oldSol)
+	if err != nil {
+		return
+	}
+	newNotes, err := app.GetSolutionByName(newSol)
+	if err != nil {
+		return
+	}
+	i := sort.SearchStrings(app.TuneForSolutions, oldSol)
+	if !(i < len(app.TuneForSolutions) && app.TuneForSolutions[i] == oldSol) {
+		err = fmt.Errorf("solution '%s' is not enabled", oldSol)
+		return
+	}
+	// swap the solutions in the configuration
+	app.TuneForSolutions = append(app.TuneForSolutions[0:i], app.TuneForSolutions[i+1:]...)
+	if i := sort.SearchStrings(app.TuneForSolutions, newSol); !(i < len(app.TuneForSolutions) && app.TuneForSolutions[i] == newSol) {
+		app.TuneForSolutions = append(app.TuneForSolutions, newSol)
+		sort.Strings(app.TuneForSolutions)
+	}
+	if err = app.SaveConfig(); err != nil {
+		return
+	}
+
+	// notes still needed after the migration
+	notesDoNotRevert := make(map[string]struct{})
+	for _, noteID := range app.TuneForNotes {
+		notesDoNotRevert[noteID] = struct{}{}
+	}
+	solNotes, errs := app.solutionEnabledNotes()
+	if len(errs) != 0 {
+		err = errs[0]
+		return
+	}
+	for _, noteID := range solNotes {
+		notesDoNotRevert[noteID] = struct{}{}
+	}
+	for _, noteID := range oldNotes {
+		if _, found := notesDoNotRevert[noteID]; found {
+			continue
+		}
+		if err = app.RevertNote(noteID, true); err != nil {
+			return
+		}
+		revertedNotes = append(revertedNotes, noteID)
+	}
+	for _, noteID := range newNotes {
+		// Remove solution's notes from additional notes list.
+		if i := sort.SearchStrings(app.TuneForNotes, noteID); i < len(app.TuneForNotes) && app.TuneForNotes[i] == noteID {
+			app.TuneForNotes = append(app.TuneForNotes[0:i], app.TuneForNotes[i+1:]...)
+			if err = app.SaveConfig(); err != nil {
+				return
+			}
+		}
+		if app.PositionInNoteApplyOrder(noteID) >= 0 {
+			// note already applied
+			continue
+		}
+		if err = app.TuneNote(noteID); err != nil {
+			return
+		}
+		appliedNotes = append(appliedNotes, noteID)
+	}
+	return
+}
+
 // RevertAll revert all tuned parameters (both solutions and additional notes),
 // and clear stored states.
 func (app *App) RevertAll(permanent bool) error {
@@ -374,6 +646,11 @@ func (app *App) RevertAll(permanent bool) error {
 	} else {
 		allErrs = append(allErrs, err)
 	}
+	// all values reverted, so remove the sysctl drop-in file
+	dropIn := path.Join(app.SysconfigPrefix, system.SysctlDropInFile)
+	if err := system.RemoveSysctlDropIn(dropIn); err != nil {
+		allErrs = append(allErrs, err)
+	}
 	if permanent {
 		app.TuneForNotes = make([]string, 0, 0)
 		app.TuneForSolutions = make([]string, 0, 0)
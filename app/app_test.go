@@ -6,10 +6,12 @@ import (
 	"github.com/SUSE/saptune/sap/note"
 	"github.com/SUSE/saptune/sap/param"
 	"github.com/SUSE/saptune/sap/solution"
+	"github.com/SUSE/saptune/system"
 	"io/ioutil"
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -96,7 +98,7 @@ func VerifyConfig(t *testing.T, app *App, hasNotes []string, hasSolutions []stri
 	if !reflect.DeepEqual(app.TuneForSolutions, hasSolutions) {
 		panic(fmt.Sprintf("Solutions diff %v %v", hasSolutions, app.TuneForSolutions))
 	}
-	appReloaded := InitialiseApp(app.SysconfigPrefix, app.State.StateDirPrefix, AllTestNotes, AllTestSolutions)
+	appReloaded := InitialiseApp(app.SysconfigPrefix, app.State.StateDirPrefix, NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	if !reflect.DeepEqual(app.TuneForNotes, appReloaded.TuneForNotes) {
 		panic(fmt.Sprintf("Notes diff %v %v", appReloaded.TuneForNotes, app.TuneForNotes))
 	}
@@ -122,14 +124,14 @@ func VerifyFileContent(t *testing.T, filePath, content string) {
 
 func TestReadConfig(t *testing.T) {
 	// Read the default config should not yield anything
-	tuneApp := InitialiseApp(OSPackageInGOPATH, "", AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(OSPackageInGOPATH, "", NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	if len(tuneApp.TuneForSolutions) != 0 || len(tuneApp.TuneForNotes) != 0 {
 		fmt.Println(len(tuneApp.TuneForSolutions))
 		fmt.Println(len(tuneApp.TuneForNotes))
 		t.Fatal(tuneApp)
 	}
 	// Read from non existing file
-	tuneApp = InitialiseApp("/tmp/saptune", "", AllTestNotes, AllTestSolutions)
+	tuneApp = InitialiseApp("/tmp/saptune", "", NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	if len(tuneApp.TuneForSolutions) != 0 || len(tuneApp.TuneForNotes) != 0 {
 		fmt.Println(len(tuneApp.TuneForSolutions))
 		fmt.Println(len(tuneApp.TuneForNotes))
@@ -137,7 +139,7 @@ func TestReadConfig(t *testing.T) {
 	}
 
 	// Read from testdata config 'testdata/etc/sysconfig/saptune'
-	tuneApp = InitialiseApp(TstFilesInGOPATH, "", AllTestNotes, AllTestSolutions)
+	tuneApp = InitialiseApp(TstFilesInGOPATH, "", NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	matchTxt := `
 current order of applied notes is: 2205917 2684254 1680803
 
@@ -156,7 +158,7 @@ current order of applied notes is: 2205917 2684254 1680803
 }
 
 func TestGetSortedSolutionNotes(t *testing.T) {
-	tuneApp := InitialiseApp(OSPackageInGOPATH, "", AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(OSPackageInGOPATH, "", NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	tuneApp.TuneForSolutions = []string{"sol1"}
 	if sols := tuneApp.GetSortedSolutionEnabledNotes(); len(sols) != 1 {
 		t.Fatal(sols)
@@ -178,7 +180,7 @@ func TestGetSortedSolutionNotes(t *testing.T) {
 func TestOptimiseNoteOnly(t *testing.T) {
 	os.RemoveAll(SampleNoteDataDir)
 	defer os.RemoveAll(SampleNoteDataDir)
-	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	VerifyConfig(t, tuneApp, []string{}, []string{})
 	// Optimise note1, then revert it
 	if err := tuneApp.TuneNote("1001"); err != nil {
@@ -239,10 +241,223 @@ func TestOptimiseNoteOnly(t *testing.T) {
 	VerifyConfig(t, tuneApp, []string{}, []string{})
 }
 
+func TestTuneBlockDevice(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
+	if err := tuneApp.TuneNote("1001"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tuneApp.TuneBlockDevice("not_avail"); err == nil {
+		t.Fatal("block device 'not_avail' found")
+	}
+	dirCont, err := ioutil.ReadDir("/sys/block")
+	if err != nil || len(dirCont) == 0 {
+		t.Skip("no block devices available. Skip test.")
+	}
+	// notes without a [block] section are skipped
+	if err := tuneApp.TuneBlockDevice(dirCont[0].Name()); err != nil {
+		t.Fatal(err)
+	}
+	VerifyFileContent(t, SampleParamFile, "optimised1")
+	if err := tuneApp.RevertNote("1001", true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateSysctlDropIn(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	noteFile := path.Join(SampleNoteDataDir, "sysctlNote")
+	os.MkdirAll(SampleNoteDataDir, 0755)
+	statInterval, _ := ioutil.ReadFile("/proc/sys/vm/stat_interval")
+	if err := ioutil.WriteFile(noteFile, []byte("[sysctl]\nvm.stat_interval = "+string(statInterval)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sysctlNotes := map[string]note.Note{"sysctlNote": note.INISettings{ConfFilePath: noteFile, ID: "sysctlNote"}}
+	dropIn := path.Join(SampleNoteDataDir, "conf", system.SysctlDropInFile)
+
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(sysctlNotes, AllTestSolutions))
+	if tuneApp.PersistSysctl {
+		t.Fatal("sysctl persistence enabled by default")
+	}
+	if err := tuneApp.TuneNote("sysctlNote"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dropIn); !os.IsNotExist(err) {
+		t.Fatalf("drop-in file '%s' written, but sysctl persistence not enabled\n", dropIn)
+	}
+	if err := tuneApp.RevertNote("sysctlNote", true); err != nil {
+		t.Fatal(err)
+	}
+
+	tuneApp.PersistSysctl = true
+	if err := tuneApp.TuneNote("sysctlNote"); err != nil {
+		t.Fatal(err)
+	}
+	VerifyFileContent(t, dropIn, "# sysctl values of the Notes applied by saptune\n# generated by saptune - do not edit, will be removed during revert\nvm.stat_interval = "+strings.TrimSpace(string(statInterval))+"\n")
+	if err := tuneApp.RevertNote("sysctlNote", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dropIn); !os.IsNotExist(err) {
+		t.Fatalf("drop-in file '%s' still available after revert\n", dropIn)
+	}
+	if err := tuneApp.TuneNote("sysctlNote"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tuneApp.RevertAll(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dropIn); !os.IsNotExist(err) {
+		t.Fatalf("drop-in file '%s' still available after revert all\n", dropIn)
+	}
+}
+
+func TestChangedDefinition(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	noteFile := path.Join(SampleNoteDataDir, "defNote")
+	os.MkdirAll(SampleNoteDataDir, 0755)
+	statInterval, _ := ioutil.ReadFile("/proc/sys/vm/stat_interval")
+	if err := ioutil.WriteFile(noteFile, []byte("[version]\nVERSION=1\n[sysctl]\nvm.stat_interval = "+string(statInterval)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defNotes := map[string]note.Note{"defNote": note.INISettings{ConfFilePath: noteFile, ID: "defNote"}}
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(defNotes, AllTestSolutions))
+	if err := tuneApp.TuneNote("defNote"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tuneApp.State.RetrieveDefinition("defNote"); err != nil {
+		t.Fatal(err)
+	}
+	if change := tuneApp.ChangedDefinition("defNote"); change != nil {
+		t.Fatalf("unchanged definition reported as changed: %+v\n", change)
+	}
+
+	// change the Note definition after the note was applied
+	if err := ioutil.WriteFile(noteFile, []byte("[version]\nVERSION=2\n[sysctl]\nvm.stat_interval = 7\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes := tuneApp.ChangedDefinitions()
+	expected := []DefinitionChange{{NoteID: "defNote", OldVersion: "1", NewVersion: "2", Params: []string{"vm.stat_interval: '" + strings.TrimSpace(string(statInterval)) + "' -> '7'"}}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("got '%+v', expected '%+v'\n", changes, expected)
+	}
+
+	if err := tuneApp.RevertNote("defNote", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tuneApp.State.RetrieveDefinition("defNote"); !os.IsNotExist(err) {
+		t.Fatalf("saved definition still available after revert: %v\n", err)
+	}
+	if changes := tuneApp.ChangedDefinitions(); len(changes) != 0 {
+		t.Fatalf("changes reported for reverted note: %+v\n", changes)
+	}
+}
+
+func TestReapplyNote(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	noteFile := path.Join(SampleNoteDataDir, "reapplyNote")
+	os.MkdirAll(SampleNoteDataDir, 0755)
+	statInterval, _ := ioutil.ReadFile("/proc/sys/vm/stat_interval")
+	if err := ioutil.WriteFile(noteFile, []byte("[version]\nVERSION=1\n[sysctl]\nvm.stat_interval = "+string(statInterval)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reapplyNotes := map[string]note.Note{"reapplyNote": note.INISettings{ConfFilePath: noteFile, ID: "reapplyNote"}}
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(reapplyNotes, AllTestSolutions))
+	// a note, which is not applied, is not applied by ReapplyNote
+	if err := tuneApp.ReapplyNote("reapplyNote"); err != nil {
+		t.Fatal(err)
+	}
+	if tuneApp.PositionInNoteApplyOrder("reapplyNote") >= 0 {
+		t.Fatal("note not applied before is applied by ReapplyNote")
+	}
+	if err := tuneApp.TuneNote("reapplyNote"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(noteFile, []byte("[version]\nVERSION=2\n[sysctl]\nvm.stat_interval = "+string(statInterval)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if change := tuneApp.ChangedDefinition("reapplyNote"); change == nil || change.NewVersion != "2" || len(change.Params) != 0 {
+		t.Fatalf("wrong definition change: %+v\n", change)
+	}
+	if err := tuneApp.ReapplyNote("reapplyNote"); err != nil {
+		t.Fatal(err)
+	}
+	if change := tuneApp.ChangedDefinition("reapplyNote"); change != nil {
+		t.Fatalf("definition change reported after apply again: %+v\n", change)
+	}
+	if !reflect.DeepEqual(tuneApp.NoteApplyOrder, []string{"reapplyNote"}) {
+		t.Fatalf("wrong apply order: %+v\n", tuneApp.NoteApplyOrder)
+	}
+	if err := tuneApp.RevertAll(true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	noteFile := path.Join(SampleNoteDataDir, "dryRunNote")
+	os.MkdirAll(SampleNoteDataDir, 0755)
+	// a value different from the current one, as equal values are not written
+	statInterval, _ := ioutil.ReadFile("/proc/sys/vm/stat_interval")
+	newInterval := "7"
+	if strings.TrimSpace(string(statInterval)) == newInterval {
+		newInterval = "8"
+	}
+	if err := ioutil.WriteFile(noteFile, []byte("[sysctl]\nvm.stat_interval = "+newInterval+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dryRunNotes := map[string]note.Note{"dryRunNote": note.INISettings{ConfFilePath: noteFile, ID: "dryRunNote"}}
+	dropIn := path.Join(SampleNoteDataDir, "conf", system.SysctlDropInFile)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(dryRunNotes, AllTestSolutions))
+	tuneApp.PersistSysctl = true
+
+	system.SetDryRun(true)
+	defer system.SetDryRun(false)
+	planned := func(action, target string) bool {
+		for _, step := range system.WritePlan() {
+			if step.Action == action && step.Target == target {
+				return true
+			}
+		}
+		return false
+	}
+	if err := tuneApp.TuneNote("dryRunNote"); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{tuneApp.State.GetPathToNote("dryRunNote"), dropIn} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Fatalf("file '%s' written in dry run mode\n", file)
+		}
+		if !planned(system.PlanWrite, file) {
+			t.Fatalf("write of '%s' missing in plan '%+v'\n", file, system.WritePlan())
+		}
+	}
+	if !planned(system.PlanWrite, "/proc/sys/vm/stat_interval") {
+		t.Fatalf("sysctl write missing in plan '%+v'\n", system.WritePlan())
+	}
+
+	// revert sees the state planned by apply
+	if err := tuneApp.RevertNote("dryRunNote", true); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{tuneApp.State.GetPathToNote("dryRunNote"), dropIn} {
+		if !planned(system.PlanRemove, file) {
+			t.Fatalf("remove of '%s' missing in plan '%+v'\n", file, system.WritePlan())
+		}
+	}
+	if len(tuneApp.NoteApplyOrder) != 0 {
+		t.Fatalf("note still in apply order: %+v\n", tuneApp.NoteApplyOrder)
+	}
+}
+
 func TestOptimiseSolutionOnly(t *testing.T) {
 	os.RemoveAll(SampleNoteDataDir)
 	defer os.RemoveAll(SampleNoteDataDir)
-	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	VerifyConfig(t, tuneApp, []string{}, []string{})
 	// Optimise sol1, then revert it
 	if _, err := tuneApp.TuneSolution("sol1"); err != nil {
@@ -351,7 +566,7 @@ func TestOptimiseSolutionOnly(t *testing.T) {
 func TestOverlappingSolutions(t *testing.T) {
 	os.RemoveAll(SampleNoteDataDir)
 	defer os.RemoveAll(SampleNoteDataDir)
-	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	VerifyConfig(t, tuneApp, []string{}, []string{})
 
 	// Optimise sol2, sol1, sol12, and then revert sol12
@@ -385,7 +600,7 @@ func TestOverlappingSolutions(t *testing.T) {
 func TestCombiningSolutionAndNotes(t *testing.T) {
 	os.RemoveAll(SampleNoteDataDir)
 	defer os.RemoveAll(SampleNoteDataDir)
-	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	VerifyConfig(t, tuneApp, []string{}, []string{})
 	// Optimise sol1, note2, revert note2, add note2, and then add sol12
 	if _, err := tuneApp.TuneSolution("sol1"); err != nil {
@@ -430,10 +645,112 @@ func TestCombiningSolutionAndNotes(t *testing.T) {
 	VerifyFileContent(t, SampleParamFile, "optimised1")
 }
 
+func TestMigrateSolution(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
+	if _, err := tuneApp.TuneSolution("sol1"); err != nil {
+		t.Fatal(err)
+	}
+	VerifyFileContent(t, SampleParamFile, "optimised1")
+
+	// no common notes, revert note1 and apply note2
+	reverted, applied, err := tuneApp.MigrateSolution("sol1", "sol2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reverted, []string{"1001"}) || !reflect.DeepEqual(applied, []string{"1002"}) {
+		t.Fatalf("reverted: '%+v', applied: '%+v'\n", reverted, applied)
+	}
+	VerifyConfig(t, tuneApp, []string{}, []string{"sol2"})
+	VerifyFileContent(t, SampleParamFile, "optimised2")
+
+	// note2 is part of both solutions and stays untouched
+	reverted, applied, err = tuneApp.MigrateSolution("sol2", "sol12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 0 || !reflect.DeepEqual(applied, []string{"1001"}) {
+		t.Fatalf("reverted: '%+v', applied: '%+v'\n", reverted, applied)
+	}
+	VerifyConfig(t, tuneApp, []string{}, []string{"sol12"})
+	VerifyFileContent(t, SampleParamFile, "optimised1")
+
+	if _, _, err := tuneApp.MigrateSolution("sol1", "sol2"); err == nil {
+		t.Fatal("migration of a not enabled solution not detected")
+	}
+	if _, _, err := tuneApp.MigrateSolution("sol12", "this one does not exist"); err == nil {
+		t.Fatal("migration to a not existing solution not detected")
+	}
+	VerifyConfig(t, tuneApp, []string{}, []string{"sol12"})
+
+	reverted, applied, err = tuneApp.MigrateSolution("sol12", "sol2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reverted, []string{"1001"}) || len(applied) != 0 {
+		t.Fatalf("reverted: '%+v', applied: '%+v'\n", reverted, applied)
+	}
+	VerifyConfig(t, tuneApp, []string{}, []string{"sol2"})
+	VerifyFileContent(t, SampleParamFile, "optimised2")
+}
+
+func TestCompositeSolutions(t *testing.T) {
+	os.RemoveAll(SampleNoteDataDir)
+	defer os.RemoveAll(SampleNoteDataDir)
+	compSolutions := map[string]solution.Solution{
+		"sol1":  solution.Solution{"1001"},
+		"sol2":  solution.Solution{"1002"},
+		"solc":  solution.Solution{"@sol1", "@sol2", "1001"},
+		"cyc1":  solution.Solution{"@cyc2"},
+		"cyc2":  solution.Solution{"1001", "@cyc1"},
+		"wrong": solution.Solution{"@notavail"},
+	}
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, compSolutions))
+	if notes, err := tuneApp.GetSolutionByName("solc"); err != nil || !reflect.DeepEqual(notes, solution.Solution{"1001", "1002"}) {
+		t.Fatal(notes, err)
+	}
+	if _, err := tuneApp.GetSolutionByName("cyc1"); err == nil {
+		t.Fatal("solution reference cycle not detected")
+	}
+	if _, err := tuneApp.TuneSolution("wrong"); err == nil {
+		t.Fatal("reference of an unknown solution not detected")
+	}
+
+	if _, err := tuneApp.TuneSolution("solc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tuneApp.TuneSolution("sol2"); err != nil {
+		t.Fatal(err)
+	}
+	if notes := tuneApp.GetSortedSolutionEnabledNotes(); !reflect.DeepEqual(notes, []string{"1001", "1002"}) {
+		t.Fatal(notes)
+	}
+	// note 1002 is still needed by sol2
+	if err := tuneApp.RevertSolution("solc"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tuneApp.TuneForSolutions, []string{"sol2"}) || !reflect.DeepEqual(tuneApp.NoteApplyOrder, []string{"1002"}) {
+		t.Fatal(tuneApp.TuneForSolutions, tuneApp.NoteApplyOrder)
+	}
+	if notes := tuneApp.GetSortedSolutionEnabledNotes(); !reflect.DeepEqual(notes, []string{"1002"}) {
+		t.Fatal(notes)
+	}
+
+	// an enabled solution, which can not be resolved, is skipped
+	tuneApp.TuneForSolutions = []string{"cyc1", "sol2"}
+	if notes := tuneApp.GetSortedSolutionEnabledNotes(); !reflect.DeepEqual(notes, []string{"1002"}) {
+		t.Fatal(notes)
+	}
+	if err := tuneApp.RevertSolution("sol2"); err == nil {
+		t.Fatal("solution reference cycle not detected")
+	}
+}
+
 func TestVerifyNoteAndSolutions(t *testing.T) {
 	os.RemoveAll(SampleNoteDataDir)
 	defer os.RemoveAll(SampleNoteDataDir)
-	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), AllTestNotes, AllTestSolutions)
+	tuneApp := InitialiseApp(path.Join(SampleNoteDataDir, "conf"), path.Join(SampleNoteDataDir, "data"), NewStaticCatalogLoader(AllTestNotes, AllTestSolutions))
 	VerifyConfig(t, tuneApp, []string{}, []string{})
 
 	// Tune for sol1 and "1002", so that system will conform to "1002" but not sol1.
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"github.com/SUSE/saptune/sap/note"
+	"github.com/SUSE/saptune/sap/solution"
+	"github.com/SUSE/saptune/txtparser"
+	"runtime"
+)
+
+// sysconfig keys to relocate the Note and solution catalogs
+const (
+	NoteDirKey        = "NOTE_DIR"
+	ExtraDirKey       = "EXTRA_DIR"
+	OverrideDirKey    = "OVERRIDE_DIR"
+	SolutionFileKey   = "SOLUTION_FILE"
+	SolutionDirKey    = "SOLUTION_DIR"
+	DeprecatedFileKey = "DEPRECATED_SOLUTION_FILE"
+)
+
+// CatalogLoader loads the Notes and the solutions available to saptune from
+// the configured locations. Preset Notes or solutions are used instead of
+// reading them from the file system, e.g. for tests
+type CatalogLoader struct {
+	Paths              solution.CatalogPaths // locations of the Note and solution catalogs
+	Arch               string                // architecture of the solutions to tune
+	Notes              map[string]note.Note  // preset Notes
+	Solutions          *solution.Catalog     // preset solutions
+	LimitsPlaceholders map[string][]string   // values of the placeholders of the limits definitions
+}
+
+// NewCatalogLoader returns a loader for the standard locations of the
+// catalogs and the running architecture
+func NewCatalogLoader() *CatalogLoader {
+	return &CatalogLoader{Paths: solution.DefaultCatalogPaths(), Arch: runtime.GOARCH}
+}
+
+// NewStaticCatalogLoader returns a loader for the given Notes and the given
+// solutions of the running architecture
+func NewStaticCatalogLoader(notes map[string]note.Note, sols map[string]solution.Solution) *CatalogLoader {
+	loader := NewCatalogLoader()
+	loader.Notes = notes
+	loader.Solutions = solution.NewCatalog(loader.Arch, sols)
+	return loader
+}
+
+// Relocated returns a copy of the loader with the locations of the catalogs
+// changed by the values of the sysconfig file. Empty or missing values keep
+// the locations of the loader. The values of the placeholders of the limits
+// definitions are taken from the sysconfig file too
+func (loader CatalogLoader) Relocated(sysconf *txtparser.Sysconfig) *CatalogLoader {
+	loader.LimitsPlaceholders = note.LimitsPlaceholderValues(sysconf)
+	loader.Paths.NoteDir = sysconf.GetString(NoteDirKey, loader.Paths.NoteDir)
+	loader.Paths.ExtraDir = sysconf.GetString(ExtraDirKey, loader.Paths.ExtraDir)
+	loader.Paths.OverrideDir = sysconf.GetString(OverrideDirKey, loader.Paths.OverrideDir)
+	loader.Paths.SolutionFile = sysconf.GetString(SolutionFileKey, loader.Paths.SolutionFile)
+	loader.Paths.SolutionDir = sysconf.GetString(SolutionDirKey, loader.Paths.SolutionDir)
+	loader.Paths.DeprecatedFile = sysconf.GetString(DeprecatedFileKey, loader.Paths.DeprecatedFile)
+	return &loader
+}
+
+// Load returns the Notes and the solution catalog, either the preset ones
+// or the ones read from the configured locations. The Note definitions get
+// the values of the placeholders of the limits definitions of the loader
+// and the Note definition files of all loaded Notes to find their base Notes
+func (loader *CatalogLoader) Load() (map[string]note.Note, *solution.Catalog) {
+	notes := loader.Notes
+	if notes == nil {
+		notes = note.GetTuningOptions(loader.Paths.NoteDir, loader.Paths.ExtraDir, loader.Paths.OverrideDir)
+	}
+	notes = loader.completeNotes(notes)
+	sols := loader.Solutions
+	if sols == nil {
+		sols = solution.LoadCatalog(loader.Paths)
+	}
+	return notes, sols
+}
+
+// completeNotes returns a copy of the Notes, where the Note definitions
+// contain the values the loader provides for them
+func (loader *CatalogLoader) completeNotes(notes map[string]note.Note) map[string]note.Note {
+	noteFiles := note.TuningOptions(notes).NoteFiles()
+	ret := make(map[string]note.Note, len(notes))
+	for id, aNote := range notes {
+		if iniNote, ok := aNote.(note.INISettings); ok {
+			iniNote.LimitsPlaceholders = loader.LimitsPlaceholders
+			iniNote.NoteFiles = noteFiles
+			aNote = iniNote
+		}
+		ret[id] = aNote
+	}
+	return ret
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"github.com/SUSE/saptune/sap/note"
+	"github.com/SUSE/saptune/sap/solution"
+	"github.com/SUSE/saptune/txtparser"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+var OSNotesInGOPATH = path.Join(OSPackageInGOPATH, "usr/share/saptune/notes")
+
+func TestStaticCatalogLoader(t *testing.T) {
+	loader := NewStaticCatalogLoader(AllTestNotes, AllTestSolutions)
+	if loader.Arch != runtime.GOARCH || loader.Paths != solution.DefaultCatalogPaths() {
+		t.Fatal(loader)
+	}
+	notes, cat := loader.Load()
+	if !reflect.DeepEqual(notes, AllTestNotes) || !reflect.DeepEqual(cat.ArchSolutions(runtime.GOARCH), AllTestSolutions) {
+		t.Fatal(notes, cat)
+	}
+}
+
+func TestStaticCatalogLoaderNoteFiles(t *testing.T) {
+	inheritDir := path.Join(TstFilesInGOPATH, "inherit")
+	notes := map[string]note.Note{
+		"4711": note.INISettings{ConfFilePath: path.Join(inheritDir, "4711"), ID: "4711"},
+		"4712": note.INISettings{ConfFilePath: path.Join(inheritDir, "4712"), ID: "4712"},
+	}
+	loaded, _ := NewStaticCatalogLoader(notes, AllTestSolutions).Load()
+	iniNote := loaded["4712"].(note.INISettings)
+	ini, err := note.ParseNoteFile(iniNote.ConfFilePath, iniNote.NoteFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ini.Bases) != 1 || ini.Bases[0] != path.Join(inheritDir, "4711") {
+		t.Fatal(ini.Bases)
+	}
+	// the preset Notes are not changed
+	if notes["4712"].(note.INISettings).NoteFiles != nil {
+		t.Fatal(notes)
+	}
+}
+
+func TestRelocated(t *testing.T) {
+	sysconf, err := txtparser.ParseSysconfig("NOTE_DIR=\"/tmp/notes\"\nOVERRIDE_DIR=\"/tmp/override\"\nSOLUTION_FILE=\"\"\nLIMITS_DBMSuser=\"sybase\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := NewCatalogLoader()
+	relocated := loader.Relocated(sysconf)
+	if relocated.Paths.NoteDir != "/tmp/notes" || relocated.Paths.OverrideDir != "/tmp/override" {
+		t.Fatal(relocated.Paths)
+	}
+	// empty or missing values keep the location
+	if relocated.Paths.SolutionFile != solution.SolutionSheet || relocated.Paths.ExtraDir != solution.ExtraSolutionSheets {
+		t.Fatal(relocated.Paths)
+	}
+	if !reflect.DeepEqual(relocated.LimitsPlaceholders, map[string][]string{"DBMSuser": {"sybase"}}) {
+		t.Fatal(relocated.LimitsPlaceholders)
+	}
+	// the origin loader is not changed
+	if loader.Paths != solution.DefaultCatalogPaths() || loader.LimitsPlaceholders != nil {
+		t.Fatal(loader)
+	}
+}
+
+func TestLoadCatalogFromPaths(t *testing.T) {
+	loader := NewCatalogLoader()
+	loader.Paths = solution.CatalogPaths{
+		NoteDir:        OSNotesInGOPATH,
+		ExtraDir:       path.Join(TstFilesInGOPATH, "extra"),
+		OverrideDir:    "/saptune_dir_not_avail",
+		SolutionFile:   path.Join(TstFilesInGOPATH, "saptune-test-solutions"),
+		SolutionDir:    "/saptune_dir_not_avail",
+		DeprecatedFile: path.Join(TstFilesInGOPATH, "saptune-test-deprecated-sols"),
+	}
+	notes, cat := loader.Load()
+	if _, ok := notes["1410736"]; !ok {
+		t.Fatal(notes)
+	}
+	if _, ok := notes["simpleNote"]; !ok {
+		t.Fatal(notes)
+	}
+	// the loaded Note definitions get the values of the placeholders
+	loader.LimitsPlaceholders = map[string][]string{"DBMSuser": {"sybase"}}
+	notes, _ = loader.Load()
+	if iniNote := notes["1410736"].(note.INISettings); !reflect.DeepEqual(iniNote.LimitsPlaceholders, loader.LimitsPlaceholders) {
+		t.Fatal(iniNote)
+	}
+	if _, ok := cat.ArchSolutions(runtime.GOARCH)["NETW"]; !ok {
+		t.Fatal(cat.Solutions)
+	}
+	if _, ok := cat.Deprecation(runtime.GOARCH, "MAXDB"); !ok {
+		t.Fatal(cat.Deprecated)
+	}
+}
+
+func TestInitialiseAppRelocated(t *testing.T) {
+	confDir := path.Join(SampleNoteDataDir, "reloc")
+	sysconfDir := path.Join(confDir, path.Dir(SysconfigSaptuneFile))
+	if err := os.MkdirAll(sysconfDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(confDir)
+	WriteFileOrPanic(path.Join(confDir, SysconfigSaptuneFile), "TUNE_FOR_SOLUTIONS=\"NETW\"\nNOTE_DIR=\""+OSNotesInGOPATH+"\"\nSOLUTION_FILE=\""+path.Join(TstFilesInGOPATH, "saptune-test-solutions")+"\"\n")
+
+	tuneApp := InitialiseApp(confDir, "", NewCatalogLoader())
+	if tuneApp.Paths.NoteDir != OSNotesInGOPATH || tuneApp.Paths.OverrideDir != solution.OverrideSolutionSheets {
+		t.Fatal(tuneApp.Paths)
+	}
+	if _, ok := tuneApp.AllNotes["1410736"]; !ok {
+		t.Fatal(tuneApp.AllNotes)
+	}
+	if _, err := tuneApp.GetSolutionByName("NETW"); err != nil {
+		t.Fatal(err)
+	}
+	if notes := tuneApp.GetSortedSolutionEnabledNotes(); len(notes) != 5 {
+		t.Fatal(notes)
+	}
+}
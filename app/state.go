@@ -3,7 +3,7 @@ package app
 import (
 	"encoding/json"
 	"github.com/SUSE/saptune/sap/note"
-	"io/ioutil"
+	"github.com/SUSE/saptune/system"
 	"os"
 	"path"
 )
@@ -11,6 +11,10 @@ import (
 // SaptuneStateDir defines saptunes saved state directory
 const SaptuneStateDir = "/var/lib/saptune/saved_state"
 
+// SaptuneDefinitionStateDir defines the directory of the saved version and
+// content hash of the Note definitions of the applied notes
+const SaptuneDefinitionStateDir = "/var/lib/saptune/saved_definitions"
+
 // State stores and manages serialised note states.
 type State struct {
 	StateDirPrefix string
@@ -28,30 +32,24 @@ func (state *State) Store(noteID string, obj note.Note, overwriteExisting bool)
 	if err != nil {
 		return err
 	}
-	if err = os.MkdirAll(path.Join(state.StateDirPrefix, SaptuneStateDir), 0755); err != nil {
+	if err = system.MkdirAll(path.Join(state.StateDirPrefix, SaptuneStateDir), 0755); err != nil {
 		return err
 	}
-	if _, err := os.Stat(state.GetPathToNote(noteID)); os.IsNotExist(err) || overwriteExisting {
-		return ioutil.WriteFile(state.GetPathToNote(noteID), content, 0644)
+	if !system.FileExists(state.GetPathToNote(noteID)) || overwriteExisting {
+		return system.WriteFile(state.GetPathToNote(noteID), content, 0644)
 	}
 	return nil
 }
 
 // List all stored note states. Return note numbers.
 func (state *State) List() (ret []string, err error) {
-	if err = os.MkdirAll(path.Join(state.StateDirPrefix, SaptuneStateDir), 0755); err != nil {
+	if err = system.MkdirAll(path.Join(state.StateDirPrefix, SaptuneStateDir), 0755); err != nil {
 		return
 	}
 	// List SaptuneStateDir and collect number from file names
-	dirContent, err := ioutil.ReadDir(path.Join(state.StateDirPrefix, SaptuneStateDir))
+	ret, err = system.ReadDirNames(path.Join(state.StateDirPrefix, SaptuneStateDir))
 	if os.IsNotExist(err) {
 		return []string{}, nil
-	} else if err != nil {
-		return
-	}
-	ret = make([]string, 0, len(dirContent))
-	for _, info := range dirContent {
-		ret = append(ret, info.Name())
 	}
 	return
 }
@@ -59,7 +57,7 @@ func (state *State) List() (ret []string, err error) {
 // Retrieve deserialises a SAP note into the destination pointer.
 // The destination must be a pointer.
 func (state *State) Retrieve(noteID string, dest interface{}) error {
-	content, err := ioutil.ReadFile(state.GetPathToNote(noteID))
+	content, err := system.ReadFile(state.GetPathToNote(noteID))
 	if err != nil {
 		return err
 	}
@@ -68,12 +66,47 @@ func (state *State) Retrieve(noteID string, dest interface{}) error {
 
 // Remove a serialised state file.
 func (state *State) Remove(noteID string) error {
-	_, err := os.Stat(state.GetPathToNote(noteID))
-	if os.IsNotExist(err) {
+	if !system.FileExists(state.GetPathToNote(noteID)) {
 		return nil
-	} else if err == nil {
-		return os.Remove(state.GetPathToNote(noteID))
-	} else {
+	}
+	return system.RemoveFile(state.GetPathToNote(noteID))
+}
+
+// GetPathToDefinition returns path to the serialised note definition
+// state file.
+func (state *State) GetPathToDefinition(noteID string) string {
+	return path.Join(state.StateDirPrefix, SaptuneDefinitionStateDir, noteID)
+}
+
+// StoreDefinition saves the version, content hash and parameter values of
+// the Note definition of an applied note. Overwrite existing file.
+func (state *State) StoreDefinition(noteID string, def note.NoteDefinition) error {
+	content, err := json.Marshal(def)
+	if err != nil {
 		return err
 	}
+	if err = system.MkdirAll(path.Join(state.StateDirPrefix, SaptuneDefinitionStateDir), 0755); err != nil {
+		return err
+	}
+	return system.WriteFile(state.GetPathToDefinition(noteID), content, 0644)
+}
+
+// RetrieveDefinition returns the saved Note definition of an applied note.
+func (state *State) RetrieveDefinition(noteID string) (note.NoteDefinition, error) {
+	def := note.NoteDefinition{}
+	content, err := system.ReadFile(state.GetPathToDefinition(noteID))
+	if err != nil {
+		return def, err
+	}
+	err = json.Unmarshal(content, &def)
+	return def, err
+}
+
+// RemoveDefinition removes the saved Note definition of a note.
+func (state *State) RemoveDefinition(noteID string) error {
+	err := system.RemoveFile(state.GetPathToDefinition(noteID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
 }
@@ -114,3 +114,32 @@ func TestState(t *testing.T) {
 		t.Fatal(err, readNote1)
 	}
 }
+
+func TestStoreDefinition(t *testing.T) {
+	state := State{StateDirPrefix: "/tmp/saptune-test/"}
+	defer os.RemoveAll("/tmp/saptune-test/")
+	def := note.NoteDefinition{Version: "3", Hash: "abc", Params: map[string]string{"vm.swappiness": "10"}}
+	if err := state.StoreDefinition("1", def); err != nil {
+		t.Fatal(err)
+	}
+	if state.GetPathToDefinition("1") != path.Join("/tmp/saptune-test/", SaptuneDefinitionStateDir, "1") {
+		t.Fatal(state.GetPathToDefinition("1"))
+	}
+	saved, err := state.RetrieveDefinition("1")
+	if err != nil || saved.Version != "3" || saved.Hash != "abc" || saved.Params["vm.swappiness"] != "10" {
+		t.Fatal(saved, err)
+	}
+	// the saved definitions are not listed as saved note states
+	if list, err := state.List(); err != nil || len(list) != 0 {
+		t.Fatal(list, err)
+	}
+	if err := state.RemoveDefinition("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.RetrieveDefinition("1"); !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if err := state.RemoveDefinition("1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -27,9 +28,6 @@ const (
 	TunedService          = "tuned.service"
 	TunedProfileName      = "saptune"
 	logFile               = "/var/log/tuned/tuned.log"
-	NoteTuningSheets      = "/usr/share/saptune/notes/"
-	OverrideTuningSheets  = "/etc/saptune/override/"
-	ExtraTuningSheets     = "/etc/saptune/extra/" // ExtraTuningSheets is a directory located on file system for external parties to place their tuning option files.
 	exitTunedStopped      = 1
 	exitTunedWrongProfile = 2
 	exitNotTuned          = 3
@@ -43,6 +41,11 @@ const (
 	footnote3             = "[3] value is only checked, but NOT set"
 	footnote4             = "[4] cpu idle state settings differ"
 	footnote5             = "[5] expected value does not contain a supported scheduler"
+	footnote6             = "[6] more than one version of the package installed, compared version is the one of the running kernel or the highest one"
+	footnote7             = "[7] effective limit defined in a file not written by saptune:"
+	footnote8             = "[8] expected value computed from the expression:"
+	footnote9             = "[9] current value checked against the value specification:"
+	footnote10            = "[10] memory sizes in human-readable form:"
 )
 
 // PrintHelpAndExit Print the usage and exit
@@ -53,12 +56,22 @@ Daemon control:
 Tune system according to SAP and SUSE notes:
   saptune note [ list | verify ]
   saptune note [ apply | simulate | verify | customise | create | revert | show | delete ] NoteID
+  saptune note [ apply | revert ] --dry-run NoteID
+  saptune note show --explain NoteID
   saptune note rename NoteID newNoteID
+  saptune note set [--apply] NoteID section.key=value
+  saptune note [ unset | untouch ] [--apply] NoteID section.key
 Tune system for all notes applicable to your SAP solution:
   saptune solution [ list | verify ]
   saptune solution [ apply | simulate | verify | revert ] SolutionName
+  saptune solution [ apply | revert ] --dry-run SolutionName
+  saptune solution [ create | customise | show | delete ] SolutionName
+  saptune solution migrate [--dry-run] [SolutionName]
+  saptune solution detect [--apply]
+Apply the block device tuning of the applied notes to a single block device:
+  saptune block apply BlockDevice
 Revert all parameters tuned by the SAP notes or solutions:
-  saptune revert all
+  saptune revert all [--dry-run]
 Print current saptune version:
   saptune version
 Print this message:
@@ -136,6 +149,14 @@ func main() {
 	// activate logging
 	system.LogInit(logFile, debugSwitch, verboseSwitch)
 
+	// '--dry-run' only records the changes of the system
+	if cutDryRunOption() {
+		if !dryRunSupported(cliArg(1), cliArg(2)) {
+			errorExit("The option '--dry-run' is only supported by 'note apply', 'note revert', 'solution apply', 'solution revert', 'solution migrate' and 'revert all'.")
+		}
+		system.SetDryRun(true)
+	}
+
 	switch saptuneVersion {
 	case "1":
 		cmd := exec.Command(saptuneV1, os.Args[1:]...)
@@ -154,17 +175,15 @@ func main() {
 		errorExit("Wrong saptune version in file '/etc/sysconfig/saptune': %s", saptuneVersion)
 	}
 
-	if system.IsPagecacheAvailable() {
-		solutionSelector = solutionSelector + "_PC"
-	}
-	archSolutions, exist := solution.AllSolutions[solutionSelector]
-	if !exist {
-		errorExit("The system architecture (%s) is not supported.", solutionSelector)
-		return
-	}
 	// Initialise application configuration and tuning procedures
-	tuningOptions = note.GetTuningOptions(NoteTuningSheets, ExtraTuningSheets)
-	tuneApp = app.InitialiseApp("", "", tuningOptions, archSolutions)
+	loader := app.NewCatalogLoader()
+	loader.Arch = solutionSelector
+	tuneApp = app.InitialiseApp("", "", loader)
+	tuningOptions = tuneApp.AllNotes
+	if _, exist := tuneApp.Catalog.Solutions[solutionSelector]; !exist {
+		// Notes can be used without solutions
+		system.DebugLog("No solutions defined for the system architecture (%s), only Notes can be applied.", solutionSelector)
+	}
 
 	checkUpdateLeftOvers()
 	selectAction()
@@ -181,6 +200,8 @@ func selectAction() {
 		NoteAction(cliArg(2), cliArg(3), cliArg(4))
 	case "solution":
 		SolutionAction(cliArg(2), cliArg(3))
+	case "block":
+		BlockAction(os.Stdout, cliArg(2), cliArg(3), tuneApp)
 	case "revert":
 		RevertAction(os.Stdout, cliArg(2), tuneApp)
 	default:
@@ -188,6 +209,84 @@ func selectAction() {
 	}
 }
 
+// cutDryRunOption removes the option '--dry-run' from the command line
+// arguments and returns true, if the option was found
+func cutDryRunOption() bool {
+	found := false
+	args := make([]string, 0, len(os.Args))
+	for _, arg := range os.Args {
+		if arg == "--dry-run" {
+			found = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	return found
+}
+
+// dryRunSupported checks, if the option '--dry-run' is supported by the
+// action
+func dryRunSupported(cmd, action string) bool {
+	switch cmd {
+	case "note":
+		return action == "apply" || action == "revert"
+	case "solution":
+		return action == "apply" || action == "revert" || action == "migrate"
+	case "revert":
+		return action == "all"
+	}
+	return false
+}
+
+// isSaptuneStateFile checks, if the file is a configuration or state file
+// of saptune itself
+func isSaptuneStateFile(fileName string) bool {
+	return strings.HasPrefix(fileName, "/var/lib/saptune/") || fileName == "/etc/sysconfig/saptune"
+}
+
+// PrintWritePlan prints the changes of the system recorded in dry run
+// mode. The changes of the system are printed before the changes of the
+// configuration and state files of saptune
+func PrintWritePlan(writer io.Writer, plan []system.PlanStep) {
+	if len(plan) == 0 {
+		fmt.Fprintf(writer, "\nDry run - nothing would be changed on the system.\n")
+		return
+	}
+	sysSteps := []system.PlanStep{}
+	stateSteps := []system.PlanStep{}
+	for _, step := range plan {
+		if step.Action != system.PlanRun && isSaptuneStateFile(step.Target) {
+			stateSteps = append(stateSteps, step)
+		} else {
+			sysSteps = append(sysSteps, step)
+		}
+	}
+	fmt.Fprintf(writer, "\nDry run - nothing was changed. The following changes would be done:\n")
+	if len(sysSteps) != 0 {
+		fmt.Fprintf(writer, "\nSystem:\n")
+		for _, step := range sysSteps {
+			switch {
+			case step.Action == system.PlanWrite && (strings.HasPrefix(step.Target, "/proc/") || strings.HasPrefix(step.Target, "/sys/")):
+				fmt.Fprintf(writer, "    %-7s %s = '%s'\n", step.Action, step.Target, strings.TrimSpace(step.Value))
+			case step.Action == system.PlanWrite:
+				fmt.Fprintf(writer, "    %-7s %s\n", step.Action, step.Target)
+				for _, line := range strings.Split(strings.TrimRight(step.Value, "\n"), "\n") {
+					fmt.Fprintf(writer, "            | %s\n", line)
+				}
+			default:
+				fmt.Fprintf(writer, "    %-7s %s\n", step.Action, step.Target)
+			}
+		}
+	}
+	if len(stateSteps) != 0 {
+		fmt.Fprintf(writer, "\nsaptune configuration and state:\n")
+		for _, step := range stateSteps {
+			fmt.Fprintf(writer, "    %-7s %s\n", step.Action, step.Target)
+		}
+	}
+}
+
 // checkUpdateLeftOvers checks for left over files from the migration of
 // saptune version 1 to saptune version 2
 func checkUpdateLeftOvers() {
@@ -214,9 +313,27 @@ func RevertAction(writer io.Writer, actionName string, tuneApp *app.App) {
 		errorExit("Failed to revert notes: %v", err)
 		//panic(err)
 	}
+	if system.IsDryRun() {
+		PrintWritePlan(writer, system.WritePlan())
+		return
+	}
 	fmt.Fprintf(writer, "Parameters tuned by the notes and solutions have been successfully reverted.\n")
 }
 
+// BlockAction handles block device actions like apply.
+// 'saptune block apply <device>' is called by udev for newly added block
+// devices to get the same tuning as the already available block devices
+func BlockAction(writer io.Writer, actionName, bdev string, tuneApp *app.App) {
+	if actionName != "apply" || bdev == "" {
+		PrintHelpAndExit(1)
+	}
+	bdev = path.Base(bdev)
+	if err := tuneApp.TuneBlockDevice(bdev); err != nil {
+		errorExit("Failed to apply block device settings to '%s': %v", bdev, err)
+	}
+	fmt.Fprintf(writer, "Block device settings of the applied notes have been applied to '%s'.\n", bdev)
+}
+
 // DaemonAction handles daemon actions like start, stop, status asm.
 func DaemonAction(actionName string) {
 	switch actionName {
@@ -224,6 +341,7 @@ func DaemonAction(actionName string) {
 		DaemonActionStart()
 	case "apply":
 		// This action name is only used by tuned script, hence it is not advertised to end user.
+		warnDeprecatedSolutions(tuneApp.TuneForSolutions)
 		if err := tuneApp.TuneAll(); err != nil {
 			panic(err)
 		}
@@ -315,7 +433,7 @@ func PrintNoteFields(writer io.Writer, header string, noteComparisons map[string
 	compliant := "yes"
 	printHead := ""
 	noteField := ""
-	footnote := make([]string, 5, 5)
+	footnote := make([]string, 10, 10)
 	reminder := make(map[string]string)
 	override := ""
 	comment := ""
@@ -365,8 +483,26 @@ func PrintNoteFields(writer io.Writer, header string, noteComparisons map[string
 			}
 		}
 
+		// check expressions map for computed values
+		expression := ""
+		if noteComparisons[noteID][fmt.Sprintf("%s[%s]", "Expressions", comparison.ReflectMapKey)].ActualValue != nil {
+			expression = noteComparisons[noteID][fmt.Sprintf("%s[%s]", "Expressions", comparison.ReflectMapKey)].ActualValue.(string)
+		}
+
+		// check value specifications map for ranges, sets, ...
+		valueSpec := ""
+		if noteComparisons[noteID][fmt.Sprintf("%s[%s]", "ValueSpecs", comparison.ReflectMapKey)].ActualValue != nil {
+			valueSpec = noteComparisons[noteID][fmt.Sprintf("%s[%s]", "ValueSpecs", comparison.ReflectMapKey)].ActualValue.(string)
+		}
+
+		// check memory sizes map for human-readable values
+		memSize := ""
+		if sizes := noteComparisons[noteID][fmt.Sprintf("%s[%s]", "MemSizes", comparison.ReflectMapKey)]; sizes.ActualValue != nil && sizes.ExpectedValue != nil {
+			memSize = fmt.Sprintf("expected %s, actual %s", sizes.ExpectedValue.(string), sizes.ActualValue.(string))
+		}
+
 		// prepare footnote
-		compliant, comment, footnote = prepareFootnote(comparison, compliant, comment, inform, footnote)
+		compliant, comment, footnote = prepareFootnote(comparison, compliant, comment, inform, expression, valueSpec, memSize, footnote)
 
 		// print table header
 		if printHead != "" {
@@ -395,8 +531,10 @@ func sortNoteComparisonsOutput(noteCompare map[string]map[string]note.FieldCompa
 	// sort output
 	for noteID, comparisons := range noteCompare {
 		for _, comparison := range comparisons {
-			if comparison.ReflectFieldName == "Inform" {
-				// skip inform map to avoid double entries in verify table
+			if comparison.ReflectFieldName == "Inform" || comparison.ReflectFieldName == "Expressions" || comparison.ReflectFieldName == "ValueSpecs" || comparison.ReflectFieldName == "MemSizes" {
+				// skip inform, expressions, value specifications
+				// and memory sizes map to avoid double entries in
+				// verify table
 				continue
 			}
 			if len(comparison.ReflectMapKey) != 0 && comparison.ReflectFieldName != "OverrideParams" {
@@ -502,7 +640,7 @@ func printTableHeader(writer io.Writer, format string, col0, col1, col2, col3, c
 
 // prepareFootnote prepares the content of the last column and the
 // corresponding footnotes
-func prepareFootnote(comparison note.FieldComparison, compliant, comment, inform string, footnote []string) (string, string, []string) {
+func prepareFootnote(comparison note.FieldComparison, compliant, comment, inform, expression, valueSpec, memSize string, footnote []string) (string, string, []string) {
 	switch comparison.ActualValue {
 	case "all:none":
 		compliant = compliant + " [1]"
@@ -532,6 +670,45 @@ func prepareFootnote(comparison note.FieldComparison, compliant, comment, inform
 		comment = comment + " [5]"
 		footnote[4] = footnote5
 	}
+	if strings.HasPrefix(comparison.ReflectMapKey, "rpm:") && inform != "" {
+		compliant = compliant + " [6]"
+		comment = comment + " [6]"
+		footnote[5] = footnote6
+	}
+	if strings.HasPrefix(comparison.ReflectMapKey, "LIMIT_") && inform != "" && !strings.HasPrefix(path.Base(inform), "saptune-") {
+		compliant = compliant + " [7]"
+		comment = comment + " [7]"
+		if footnote[6] == "" {
+			footnote[6] = footnote7
+		}
+		if !strings.Contains(footnote[6], " "+inform) {
+			footnote[6] = footnote[6] + " " + inform
+		}
+	}
+	if expression != "" {
+		compliant = compliant + " [8]"
+		comment = comment + " [8]"
+		if footnote[7] == "" {
+			footnote[7] = footnote8
+		}
+		footnote[7] = footnote[7] + fmt.Sprintf("\n     %s = %s", comparison.ReflectMapKey, expression)
+	}
+	if valueSpec != "" {
+		compliant = compliant + " [9]"
+		comment = comment + " [9]"
+		if footnote[8] == "" {
+			footnote[8] = footnote9
+		}
+		footnote[8] = footnote[8] + fmt.Sprintf("\n     %s %s", comparison.ReflectMapKey, valueSpec)
+	}
+	if memSize != "" {
+		compliant = compliant + " [10]"
+		comment = comment + " [10]"
+		if footnote[9] == "" {
+			footnote[9] = footnote10
+		}
+		footnote[9] = footnote[9] + fmt.Sprintf("\n     %s: %s", comparison.ReflectMapKey, memSize)
+	}
 	return compliant, comment, footnote
 }
 
@@ -583,11 +760,13 @@ func VerifyAllParameters() {
 	if len(tuneApp.NoteApplyOrder) == 0 {
 		fmt.Println("No notes or solutions enabled, nothing to verify.")
 	} else {
+		warnDeprecatedSolutions(tuneApp.TuneForSolutions)
 		unsatisfiedNotes, comparisons, err := tuneApp.VerifyAll()
 		if err != nil {
 			errorExit("Failed to inspect the current system: %v", err)
 		}
 		PrintNoteFields(os.Stdout, "NONE", comparisons, true)
+		printDefinitionChanges(os.Stdout, tuneApp.ChangedDefinitions())
 		tuneApp.PrintNoteApplyOrder(os.Stdout)
 		if len(unsatisfiedNotes) == 0 {
 			fmt.Println("The running system is currently well-tuned according to all of the enabled notes.")
@@ -613,13 +792,26 @@ func NoteAction(actionName, noteID, newNoteID string) {
 	case "create":
 		NoteActionCreate(noteID)
 	case "show":
-		NoteActionShow(noteID)
+		// 'saptune note show --explain NoteID' or
+		// 'saptune note show NoteID --explain'
+		explain := false
+		if noteID == "--explain" {
+			noteID = newNoteID
+			explain = true
+		} else if newNoteID == "--explain" {
+			explain = true
+		}
+		NoteActionShow(noteID, explain)
 	case "delete":
 		NoteActionDelete(noteID)
 	case "rename":
 		NoteActionRename(noteID, newNoteID)
 	case "revert":
 		NoteActionRevert(os.Stdout, noteID, tuneApp)
+	case note.OverrideSet, note.OverrideUnset, note.OverrideUntouch:
+		// 'saptune note set [--apply] NoteID section.key=value' or
+		// 'saptune note unset|untouch [--apply] NoteID section.key'
+		NoteActionOverride(os.Stdout, actionName, []string{noteID, newNoteID, cliArg(5)}, tuneApp)
 	default:
 		PrintHelpAndExit(1)
 	}
@@ -644,6 +836,10 @@ func NoteActionApply(writer io.Writer, noteID string, tuneApp *app.App) {
 	if err := tuneApp.TuneNote(noteID); err != nil {
 		errorExit("Failed to tune for note %s: %v", noteID, err)
 	}
+	if system.IsDryRun() {
+		PrintWritePlan(writer, system.WritePlan())
+		return
+	}
 	fmt.Fprintf(writer, "The note has been applied successfully.\n")
 	if !system.SystemctlIsRunning(TunedService) || system.GetTunedProfile() != TunedProfileName {
 		fmt.Fprintf(writer, "\nRemember: if you wish to automatically activate the solution's tuning options after a reboot,"+
@@ -662,7 +858,7 @@ func NoteActionList(writer io.Writer, tuneApp *app.App, tOptions note.TuningOpti
 		if len(noteID) >= 8 {
 			format = "\t%s\t%s\n"
 		}
-		if _, err := os.Stat(fmt.Sprintf("%s%s", OverrideTuningSheets, noteID)); err == nil {
+		if _, err := os.Stat(path.Join(tuneApp.Paths.OverrideDir, noteID)); err == nil {
 			format = " O" + format
 		}
 		if i := sort.SearchStrings(solutionNoteIDs, noteID); i < len(solutionNoteIDs) && solutionNoteIDs[i] == noteID {
@@ -677,6 +873,7 @@ func NoteActionList(writer io.Writer, tuneApp *app.App, tOptions note.TuningOpti
 		}
 		fmt.Fprintf(writer, format, noteID, noteObj.Name())
 	}
+	printDefinitionChanges(writer, tuneApp.ChangedDefinitions())
 	tuneApp.PrintNoteApplyOrder(writer)
 	if !system.SystemctlIsRunning(TunedService) || system.GetTunedProfile() != TunedProfileName {
 		fmt.Fprintf(writer, "Remember: if you wish to automatically activate the solution's tuning options after a reboot,"+
@@ -699,6 +896,9 @@ func NoteActionVerify(writer io.Writer, noteID string, tuneApp *app.App) {
 		noteComp := make(map[string]map[string]note.FieldComparison)
 		noteComp[noteID] = comparisons
 		PrintNoteFields(writer, "HEAD", noteComp, true)
+		if change := tuneApp.ChangedDefinition(noteID); change != nil {
+			printDefinitionChanges(writer, []app.DefinitionChange{*change})
+		}
 		tuneApp.PrintNoteApplyOrder(writer)
 		if !conforming {
 			errorExit("The parameters listed above have deviated from the specified note.\n")
@@ -765,6 +965,83 @@ func NoteActionCustomise(noteID string) {
 	// if syscall.Exec returns 'nil' the execution of the program ends immediately
 }
 
+// NoteActionOverride sets, unsets or untouches a single parameter of a Note
+// in the override file without launching an editor. With the option
+// '--apply' an already applied Note is applied again to get the change to
+// take effect
+func NoteActionOverride(writer io.Writer, action string, args []string, tuneApp *app.App) {
+	apply := false
+	params := []string{}
+	for _, arg := range args {
+		switch arg {
+		case "":
+		case "--apply":
+			apply = true
+		default:
+			params = append(params, arg)
+		}
+	}
+	if len(params) != 2 {
+		PrintHelpAndExit(1)
+	}
+	noteID := params[0]
+	section, key, value, err := splitOverrideParam(action, params[1])
+	if err != nil {
+		errorExit("%v", err)
+	}
+	aNote, err := tuneApp.GetNoteByID(noteID)
+	if err != nil {
+		errorExit("%v", err)
+	}
+	iniNote, ok := aNote.(note.INISettings)
+	if !ok {
+		errorExit("Note %s has no Note definition file, its parameters can not be changed.", noteID)
+	}
+	changed, err := iniNote.ChangeOverride(action, section, key, value)
+	if err != nil {
+		errorExit("Failed to %s parameter '%s' of Note %s: %v", action, params[1], noteID, err)
+	}
+	if !changed {
+		fmt.Fprintf(writer, "The override file of Note %s already contains the requested setting. Nothing to do.\n", noteID)
+		return
+	}
+	fmt.Fprintf(writer, "The override file '%s' has been changed successfully.\n", path.Join(tuneApp.Paths.OverrideDir, noteID))
+	if tuneApp.PositionInNoteApplyOrder(noteID) < 0 {
+		// noteID not yet applied
+		return
+	}
+	if !apply {
+		fmt.Fprintf(writer, "Note %s is already applied. To get your changes to take effect, please 'revert' the Note and apply again or use the option '--apply'.\n", noteID)
+		return
+	}
+	if err := tuneApp.ReapplyNote(noteID); err != nil {
+		errorExit("Failed to apply note %s again: %v", noteID, err)
+	}
+	fmt.Fprintf(writer, "The note has been applied again with the changed parameter.\n")
+}
+
+// splitOverrideParam splits the parameter argument 'section.key=value' of
+// 'saptune note set' or 'section.key' of 'saptune note unset|untouch' into
+// section, key and value. The section name is separated by the first dot,
+// as parameter names like 'vm.swappiness' contain dots too
+func splitOverrideParam(action, param string) (string, string, string, error) {
+	value := ""
+	fields := strings.SplitN(param, "=", 2)
+	if action == note.OverrideSet {
+		if len(fields) != 2 {
+			return "", "", "", fmt.Errorf("wrong parameter '%s', expected '<section>.<key>=<value>'", param)
+		}
+		value = strings.TrimSpace(fields[1])
+	} else if len(fields) != 1 {
+		return "", "", "", fmt.Errorf("wrong parameter '%s', expected '<section>.<key>' without value", param)
+	}
+	sk := strings.SplitN(strings.TrimSpace(fields[0]), ".", 2)
+	if len(sk) != 2 || sk[0] == "" || sk[1] == "" {
+		return "", "", "", fmt.Errorf("wrong parameter '%s', expected '<section>.<key>'", param)
+	}
+	return sk[0], sk[1], value, nil
+}
+
 // NoteActionCreate helps the customer to create an own Note definition
 func NoteActionCreate(noteID string) {
 	if noteID == "" {
@@ -773,13 +1050,13 @@ func NoteActionCreate(noteID string) {
 	if _, err := tuneApp.GetNoteByID(noteID); err == nil {
 		errorExit("Note '%s' already exists. Please use 'saptune note customise %s' instead to create an override file or choose another NoteID.", noteID, noteID)
 	}
-	fileName := fmt.Sprintf("%s%s", NoteTuningSheets, noteID)
+	fileName := path.Join(tuneApp.Paths.NoteDir, noteID)
 	if _, err := os.Stat(fileName); err == nil {
-		errorExit("Note '%s' already exists in %s. Please use 'saptune note customise %s' instead to create an override file or choose another NoteID.", noteID, NoteTuningSheets, noteID)
+		errorExit("Note '%s' already exists in %s. Please use 'saptune note customise %s' instead to create an override file or choose another NoteID.", noteID, tuneApp.Paths.NoteDir, noteID)
 	}
-	extraFileName := fmt.Sprintf("%s%s.conf", ExtraTuningSheets, noteID)
+	extraFileName := path.Join(tuneApp.Paths.ExtraDir, noteID+".conf")
 	if _, err := os.Stat(extraFileName); err == nil {
-		errorExit("Note '%s' already exists in %s. Please use 'saptune note customise %s' instead to create an override file or choose another NoteID.", noteID, ExtraTuningSheets, noteID)
+		errorExit("Note '%s' already exists in %s. Please use 'saptune note customise %s' instead to create an override file or choose another NoteID.", noteID, tuneApp.Paths.ExtraDir, noteID)
 	}
 	templateFile := "/usr/share/saptune/NoteTemplate.conf"
 	//if _, err := os.Stat(extraFileName); os.IsNotExist(err) {
@@ -798,11 +1075,12 @@ func NoteActionCreate(noteID string) {
 }
 
 // NoteActionShow shows the content of the Note definition file
-func NoteActionShow(noteID string) {
+func NoteActionShow(noteID string, explain bool) {
 	if noteID == "" {
 		PrintHelpAndExit(1)
 	}
-	if _, err := tuneApp.GetNoteByID(noteID); err != nil {
+	aNote, err := tuneApp.GetNoteByID(noteID)
+	if err != nil {
 		errorExit("%v", err)
 	}
 	fileName, _ := getFileName(noteID)
@@ -811,6 +1089,152 @@ func NoteActionShow(noteID string) {
 		errorExit("Failed to read file '%s' - %v", fileName, err)
 	}
 	fmt.Printf("\nContent of Note %s:\n%s\n", noteID, string(cont))
+	noteFiles := note.TuningOptions(tuneApp.AllNotes).NoteFiles()
+	printResolvedNote(os.Stdout, noteID, fileName, noteFiles)
+	if iniNote, ok := aNote.(note.INISettings); ok && iniNote.HasExpressions() {
+		printComputedValues(os.Stdout, noteID, tuneApp)
+	}
+	if explain {
+		printConditions(os.Stdout, fmt.Sprintf("Note %s", noteID), fileName, noteFiles)
+		if ovFileName, exists := getovFile(noteID); exists {
+			printConditions(os.Stdout, fmt.Sprintf("override file of Note %s", noteID), ovFileName, noteFiles)
+		}
+	}
+}
+
+// printResolvedNote prints the resolved definition of a Note, which is
+// based on other Notes, and where each value came from. The files of the
+// base Notes are looked up in noteFiles
+func printResolvedNote(writer io.Writer, noteID, fileName string, noteFiles map[string]string) {
+	ini, err := note.ParseNoteFile(fileName, noteFiles)
+	if err != nil {
+		errorExit("Failed to resolve Note %s: %v", noteID, err)
+	}
+	if len(ini.Bases) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "Resolved definition of Note %s (based on %s):\n", noteID, strings.Join(ini.Bases, ", "))
+	sections := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, entry := range ini.AllValues {
+		if !seen[entry.Section] {
+			sections = append(sections, entry.Section)
+			seen[entry.Section] = true
+		}
+	}
+	for _, section := range sections {
+		fmt.Fprintf(writer, "[%s]\n", section)
+		for _, entry := range ini.AllValues {
+			if entry.Section == section {
+				fmt.Fprintf(writer, "\t%s\t(from %s)\n", formatINIEntry(entry), entry.Origin)
+			}
+		}
+	}
+	if len(ini.Unset) != 0 {
+		fmt.Fprintf(writer, "Unset parameters:\n")
+		for _, entry := range ini.Unset {
+			fmt.Fprintf(writer, "\t[%s] %s\t(by %s)\n", entry.Section, strings.TrimSpace(formatINIEntry(entry)), entry.Origin)
+		}
+	}
+	fmt.Fprintf(writer, "\n")
+}
+
+// formatINIEntry formats an entry of a Note definition file in the syntax
+// of the Note definition file
+func formatINIEntry(entry txtparser.INIEntry) string {
+	value := strings.Replace(entry.Value, "\t", " ", -1)
+	switch entry.Section {
+	case note.INISectionLimits:
+		if value == "NA" {
+			value = ""
+		}
+		return fmt.Sprintf("LIMITS %s %s", entry.Operator, value)
+	case note.INISectionRpm:
+		return fmt.Sprintf("%s %s %s", strings.TrimPrefix(entry.Key, "rpm:"), entry.Operator, value)
+	case note.INISectionGrub:
+		if entry.Key == "grub:"+value {
+			return value
+		}
+		return fmt.Sprintf("%s=%s", strings.TrimPrefix(entry.Key, "grub:"), value)
+	case note.INISectionReminder:
+		return strings.TrimSpace(strings.Replace(value, "\n", "\n\t", -1))
+	}
+	return fmt.Sprintf("%s %s %s", entry.Key, entry.Operator, value)
+}
+
+// printDefinitionChanges prints the applied notes, whose Note definition
+// or override file changed since the note was applied, together with the
+// changed parameters
+func printDefinitionChanges(writer io.Writer, changes []app.DefinitionChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "\nThe Note definition of the following applied notes changed since the notes were applied:\n")
+	for _, change := range changes {
+		fmt.Fprintf(writer, "\t%s (version %s -> %s)\n", change.NoteID, change.OldVersion, change.NewVersion)
+		if len(change.Params) == 0 {
+			fmt.Fprintf(writer, "\t\tno parameter changed\n")
+		}
+		for _, param := range change.Params {
+			fmt.Fprintf(writer, "\t\t%s\n", param)
+		}
+	}
+	fmt.Fprintf(writer, "The system still uses the values of the former Note definition. To use the new definition please reapply the notes by 'saptune note revert <NoteID>' followed by 'saptune note apply <NoteID>' (for notes enabled by a solution use 'saptune solution revert <SolutionName>' and 'saptune solution apply <SolutionName>').\n\n")
+}
+
+// printConditions prints the conditions of the sections and entries of a
+// Note definition or override file and if they match on this system
+func printConditions(writer io.Writer, name, fileName string, noteFiles map[string]string) {
+	ini, err := note.ParseNoteFile(fileName, noteFiles)
+	if err != nil {
+		errorExit("Failed to read file '%s' - %v", fileName, err)
+	}
+	if len(ini.Conditions) == 0 {
+		fmt.Fprintf(writer, "The %s does not contain conditions.\n\n", name)
+		return
+	}
+	fmt.Fprintf(writer, "Conditions of the %s on this system (%s):\n", name, ini.HostFacts.String())
+	for _, cond := range ini.Conditions {
+		result := "not used"
+		if cond.Match {
+			result = "used"
+		}
+		if cond.Entry == "" {
+			fmt.Fprintf(writer, "\tsection [%s:%s] - %s\n", cond.Section, cond.Condition, result)
+		} else {
+			fmt.Fprintf(writer, "\tentry '{%s} %s' of section [%s] - %s\n", cond.Condition, cond.Entry, cond.Section, result)
+		}
+		for _, explain := range cond.Explain {
+			fmt.Fprintf(writer, "\t\t%s\n", explain)
+		}
+	}
+	fmt.Fprintf(writer, "\n")
+}
+
+// printComputedValues prints the expressions of the computed parameter
+// values of a Note together with the values computed for this system
+func printComputedValues(writer io.Writer, noteID string, tuneApp *app.App) {
+	_, comparisons, _, err := tuneApp.VerifyNote(noteID)
+	if err != nil {
+		errorExit("Failed to compute the values of note %s: %v", noteID, err)
+	}
+	keys := make([]string, 0)
+	for _, comparison := range comparisons {
+		if comparison.ReflectFieldName == "Expressions" {
+			keys = append(keys, comparison.ReflectMapKey)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(writer, "Computed values of Note %s on this system:\n", noteID)
+	for _, key := range keys {
+		expression := comparisons[fmt.Sprintf("%s[%s]", "Expressions", key)].ActualValue.(string)
+		value := comparisons[fmt.Sprintf("%s[%s]", "SysctlParams", key)].ExpectedValueJS
+		fmt.Fprintf(writer, "\t%s = %s\n\t\t-> %s\n", key, expression, strings.Replace(value, "\t", " ", -1))
+	}
+	fmt.Fprintf(writer, "\n")
 }
 
 // NoteActionDelete deletes a custom Note definition file and
@@ -907,6 +1331,10 @@ func NoteActionRevert(writer io.Writer, noteID string, tuneApp *app.App) {
 	if err := tuneApp.RevertNote(noteID, true); err != nil {
 		errorExit("Failed to revert note %s: %v", noteID, err)
 	}
+	if system.IsDryRun() {
+		PrintWritePlan(writer, system.WritePlan())
+		return
+	}
 	fmt.Fprintf(writer, "Parameters tuned by the note have been successfully reverted.\n")
 	fmt.Fprintf(writer, "Please note: the reverted note may still show up in list of enabled notes, if an enabled solution refers to it.\n")
 }
@@ -924,6 +1352,25 @@ func SolutionAction(actionName, solName string) {
 		SolutionActionSimulate(solName)
 	case "revert":
 		SolutionActionRevert(solName)
+	case "create":
+		SolutionActionCreate(solName)
+	case "customise":
+		SolutionActionCustomise(solName)
+	case "show":
+		SolutionActionShow(os.Stdout, solName)
+	case "delete":
+		SolutionActionDelete(solName)
+	case "migrate":
+		SolutionActionMigrate(os.Stdout, solName)
+	case "detect":
+		switch solName {
+		case "":
+			SolutionActionDetect(os.Stdout, "/", false)
+		case "--apply":
+			SolutionActionDetect(os.Stdout, "/", true)
+		default:
+			PrintHelpAndExit(1)
+		}
 	default:
 		PrintHelpAndExit(1)
 	}
@@ -941,10 +1388,15 @@ func SolutionActionApply(solName string) {
 		system.InfoLog("There is already one solution applied. Applying another solution is NOT supported.")
 		os.Exit(0)
 	}
+	warnDeprecatedSolutions([]string{solName})
 	removedAdditionalNotes, err := tuneApp.TuneSolution(solName)
 	if err != nil {
 		errorExit("Failed to tune for solution %s: %v", solName, err)
 	}
+	if system.IsDryRun() {
+		PrintWritePlan(os.Stdout, system.WritePlan())
+		return
+	}
 	fmt.Println("All tuning options for the SAP solution have been applied successfully.")
 	if len(removedAdditionalNotes) > 0 {
 		fmt.Println("The following previously-enabled notes are now tuned by the SAP solution:")
@@ -962,21 +1414,21 @@ func SolutionActionApply(solName string) {
 // SolutionActionList lists all available solution definitions
 func SolutionActionList() {
 	fmt.Println("\nAll solutions (* denotes enabled solution, O denotes override file exists for solution, D denotes deprecated solutions):")
-	for _, solName := range solution.GetSortedSolutionNames(solutionSelector) {
+	for _, solName := range tuneApp.Catalog.SortedSolutionNames(solutionSelector) {
 		format := "\t%-18s -"
 		if i := sort.SearchStrings(tuneApp.TuneForSolutions, solName); i < len(tuneApp.TuneForSolutions) && tuneApp.TuneForSolutions[i] == solName {
 			format = " " + setGreenText + "*" + format
 		}
-		if len(solution.OverrideSolutions[solutionSelector][solName]) != 0 {
+		if tuneApp.Catalog.IsOverridden(solutionSelector, solName) {
 			//override solution
 			format = " O" + format
 		}
 
 		solNotes := ""
-		for _, noteString := range solution.AllSolutions[solutionSelector][solName] {
+		for _, noteString := range tuneApp.AllSolutions[solName] {
 			solNotes = solNotes + " " + noteString
 		}
-		if _, ok := solution.DeprecSolutions[solutionSelector][solName]; ok {
+		if _, ok := tuneApp.Catalog.Deprecation(solutionSelector, solName); ok {
 			format = " D" + format
 		}
 		format = format + solNotes + resetTextColor + "\n"
@@ -996,6 +1448,7 @@ func SolutionActionVerify(solName string) {
 		VerifyAllParameters()
 	} else {
 		// Check system parameters against the specified solution, no matter the solution has been tuned for or not.
+		warnDeprecatedSolutions([]string{solName})
 		unsatisfiedNotes, comparisons, err := tuneApp.VerifySolution(solName)
 		if err != nil {
 			errorExit("Failed to test the current system against the specified SAP solution: %v", err)
@@ -1033,26 +1486,366 @@ func SolutionActionRevert(solName string) {
 	if err := tuneApp.RevertSolution(solName); err != nil {
 		errorExit("Failed to revert tuning for solution %s: %v", solName, err)
 	}
+	if system.IsDryRun() {
+		PrintWritePlan(os.Stdout, system.WritePlan())
+		return
+	}
 	fmt.Println("Parameters tuned by the notes referred by the SAP solution have been successfully reverted.")
 }
 
+// SolutionActionCreate helps the customer to create an own solution
+// definition
+func SolutionActionCreate(solName string) {
+	if solName == "" {
+		PrintHelpAndExit(1)
+	}
+	if !solution.IsValidSolutionName(solName) {
+		errorExit("'%s' is not a valid solution name. Please use only letters, digits and the characters '_', '.', '+' and '-'.", solName)
+	}
+	for _, archSols := range tuneApp.Catalog.Solutions {
+		if _, exists := archSols[solName]; exists {
+			errorExit("Solution '%s' already exists. Please use 'saptune solution customise %s' instead to create an override file or choose another solution name.", solName, solName)
+		}
+	}
+	for _, dir := range []string{tuneApp.Paths.SolutionDir, tuneApp.Paths.ExtraDir} {
+		fileName := path.Join(dir, solName+solution.SolutionFileSuffix)
+		if _, err := os.Stat(fileName); err == nil {
+			errorExit("Solution '%s' already exists in %s. Please use 'saptune solution customise %s' instead to create an override file or choose another solution name.", solName, dir, solName)
+		}
+	}
+	extraFileName := path.Join(tuneApp.Paths.ExtraDir, solName+solution.SolutionFileSuffix)
+	//copy template file
+	if err := system.CopyFile(solution.SolutionTemplate, extraFileName); err != nil {
+		errorExit("Problems while copying '%s' to '%s' - %v", solution.SolutionTemplate, extraFileName, err)
+	}
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "/usr/bin/vim" // launch vim by default
+	}
+	if err := syscall.Exec(editor, []string{editor, extraFileName}, os.Environ()); err != nil {
+		errorExit("Failed to start launch editor %s: %v", editor, err)
+	}
+}
+
+// SolutionActionCustomise creates an override file for a solution and
+// launches an editor to change it
+func SolutionActionCustomise(solName string) {
+	if solName == "" {
+		PrintHelpAndExit(1)
+	}
+	// the references of other solutions are not resolved, so a wrong
+	// reference can be corrected in the override file
+	notes, exists := tuneApp.AllSolutions[solName]
+	if !exists {
+		_, err := tuneApp.GetSolutionByName(solName)
+		errorExit("%v", err)
+	}
+	ovFileName, overrideSol := getSolovFile(solName)
+	if !overrideSol {
+		if def, ok := tuneApp.Catalog.Definitions[solName]; ok {
+			//copy file
+			if err := system.CopyFile(def.FileName, ovFileName); err != nil {
+				errorExit("Problems while copying '%s' to '%s' - %v", def.FileName, ovFileName, err)
+			}
+		} else {
+			// solution of the solution definition file
+			if err := ioutil.WriteFile(ovFileName, []byte(solution.SolutionDefinitionToText(solName, notes)), 0644); err != nil {
+				errorExit("Problems while writing '%s' - %v", ovFileName, err)
+			}
+		}
+	} else {
+		system.InfoLog("Solution override file already exists, using file '%s' as base for editing", ovFileName)
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "/usr/bin/vim" // launch vim by default
+	}
+	if isAppliedSolution(solName) {
+		system.InfoLog("Your just edited Solution is already applied. To get your changes to take effect, please 'revert' the Solution and apply again.\n")
+	} else {
+		system.InfoLog("Do not forget to apply the just edited Solution to get your changes to take effect\n")
+	}
+	if err := syscall.Exec(editor, []string{editor, ovFileName}, os.Environ()); err != nil {
+		errorExit("Failed to start launch editor %s: %v", editor, err)
+	}
+	// if syscall.Exec returns 'nil' the execution of the program ends immediately
+}
+
+// SolutionActionShow shows the definition of a solution
+func SolutionActionShow(writer io.Writer, solName string) {
+	if solName == "" {
+		PrintHelpAndExit(1)
+	}
+	notes, err := tuneApp.GetSolutionByName(solName)
+	if err != nil {
+		errorExit("%v", err)
+	}
+	ovFileName, overrideSol := getSolovFile(solName)
+	if !overrideSol {
+		ovFileName = ""
+	}
+	deprec, _ := tuneApp.Catalog.Deprecation(solutionSelector, solName)
+	printSolution(writer, tuneApp, solName, notes, tuneApp.Catalog.Definitions[solName], deprec, ovFileName)
+}
+
+// printSolution prints the metadata and the Notes of a solution and the
+// content of its solution definition file and override file
+func printSolution(writer io.Writer, tuneApp *app.App, solName string, notes solution.Solution, def solution.SolutionDefinition, deprec solution.Deprecation, ovFileName string) {
+	format := "\t%-15s%s\n"
+	fmt.Fprintf(writer, "\nSolution %s:\n", solName)
+	if def.Description != "" {
+		fmt.Fprintf(writer, format, "Description:", def.Description)
+	}
+	if def.Version != "" {
+		version := def.Version
+		if def.Date != "" {
+			version = fmt.Sprintf("%s from %s", def.Version, def.Date)
+		}
+		fmt.Fprintf(writer, format, "Version:", version)
+	}
+	if def.FileName != "" {
+		archs := "all"
+		if len(def.Architectures) != 0 {
+			archs = strings.Join(def.Architectures, " ")
+		}
+		fmt.Fprintf(writer, format, "Architectures:", archs)
+	}
+	fmt.Fprintf(writer, format, "Notes:", strings.Join(notes, " "))
+	if refs := solution.SolutionRefs(tuneApp.AllSolutions[solName]); len(refs) != 0 {
+		fmt.Fprintf(writer, format, "Solutions:", strings.Join(refs, " "))
+	}
+	if deprec.Reason != "" {
+		fmt.Fprintf(writer, format, "Deprecated:", deprec.Reason)
+	}
+	if deprec.Successor != "" {
+		fmt.Fprintf(writer, format, "Replacement:", deprec.Successor)
+	}
+	fileName := def.FileName
+	switch {
+	case fileName == "":
+		fmt.Fprintf(writer, format, "Definition:", tuneApp.Paths.SolutionFile)
+	case def.Custom:
+		fmt.Fprintf(writer, format, "Definition:", fileName+" (customer/vendor specific)")
+	default:
+		fmt.Fprintf(writer, format, "Definition:", fileName)
+	}
+	if ovFileName != "" {
+		fmt.Fprintf(writer, format, "Override:", ovFileName)
+	}
+	for _, file := range []string{fileName, ovFileName} {
+		if file == "" {
+			continue
+		}
+		cont, err := ioutil.ReadFile(file)
+		if err != nil {
+			errorExit("Failed to read file '%s' - %v", file, err)
+		}
+		fmt.Fprintf(writer, "\nContent of %s:\n%s\n", file, string(cont))
+	}
+}
+
+// SolutionActionDelete deletes a custom solution definition file and
+// the corresponding override file
+func SolutionActionDelete(solName string) {
+	if solName == "" {
+		PrintHelpAndExit(1)
+	}
+	if _, err := tuneApp.GetSolutionByName(solName); err != nil {
+		errorExit("%v", err)
+	}
+	def := tuneApp.Catalog.Definitions[solName]
+	ovFileName, overrideSol := getSolovFile(solName)
+
+	// check, if solution is active - applied
+	if isAppliedSolution(solName) {
+		system.InfoLog("The solution definition file you want to delete is currently in use, which means it is already applied.")
+		system.InfoLog("So please 'revert' the solution first and then try deleting again.\n")
+		os.Exit(0)
+	}
+
+	txtConfirm := ""
+	switch {
+	case !def.Custom && !overrideSol:
+		errorExit("ATTENTION: The solution you want to delete is a saptune internal (shipped) solution and can NOT be deleted. Exiting ...")
+	case !def.Custom && overrideSol:
+		// system solution, override file exists
+		txtConfirm = fmt.Sprintf("Solution to delete is a saptune internal (shipped) solution, so it can NOT be deleted. But an override file for the solution exists.\nDo you want to remove the override file for solution %s?", solName)
+	case def.Custom && overrideSol:
+		// custom solution with override file
+		txtConfirm = fmt.Sprintf("Solution to delete is a customer/vendor specific solution.\nDo you really want to delete this solution (%s) and the corresponding override file?", solName)
+	default:
+		// custom solution
+		txtConfirm = fmt.Sprintf("Solution to delete is a customer/vendor specific solution.\nDo you really want to delete this solution (%s)?", solName)
+	}
+	if readYesNo(txtConfirm, os.Stdin) {
+		deleteNote(def.FileName, ovFileName, overrideSol, def.Custom)
+	}
+}
+
+// SolutionActionMigrate replaces an enabled deprecated solution by its
+// successor. Only the notes, which differ between both solutions, are
+// reverted or applied. Without a solution name all enabled deprecated
+// solutions with a successor are migrated
+func SolutionActionMigrate(writer io.Writer, solName string) {
+	solNames := []string{solName}
+	if solName == "" {
+		solNames = []string{}
+		for _, sol := range tuneApp.TuneForSolutions {
+			if deprec, ok := tuneApp.Catalog.Deprecation(solutionSelector, sol); ok {
+				if deprec.Successor == "" {
+					system.WarningLog("Solution '%s' is deprecated, but has no successor. Please choose a replacement manually.", sol)
+					continue
+				}
+				solNames = append(solNames, sol)
+			}
+		}
+		if len(solNames) == 0 {
+			fmt.Fprintln(writer, "No deprecated solution with a successor enabled, nothing to migrate.")
+			return
+		}
+	}
+	for _, oldSol := range solNames {
+		deprec, ok := tuneApp.Catalog.Deprecation(solutionSelector, oldSol)
+		if !ok {
+			errorExit("Solution '%s' is not deprecated, nothing to migrate.", oldSol)
+		}
+		if deprec.Successor == "" {
+			errorExit("Solution '%s' is deprecated, but has no successor. Please choose a replacement manually.", oldSol)
+		}
+		revertedNotes, appliedNotes, err := tuneApp.MigrateSolution(oldSol, deprec.Successor)
+		if err != nil {
+			errorExit("Failed to migrate solution '%s' to solution '%s': %v", oldSol, deprec.Successor, err)
+		}
+		if !system.IsDryRun() {
+			printMigration(writer, oldSol, deprec.Successor, revertedNotes, appliedNotes)
+		}
+	}
+	if system.IsDryRun() {
+		PrintWritePlan(writer, system.WritePlan())
+	}
+}
+
+// printMigration prints the result of the migration of a deprecated solution
+func printMigration(writer io.Writer, oldSol, newSol string, revertedNotes, appliedNotes []string) {
+	fmt.Fprintf(writer, "Solution '%s' has been replaced by solution '%s'.\n", oldSol, newSol)
+	if len(revertedNotes) == 0 && len(appliedNotes) == 0 {
+		fmt.Fprintln(writer, "Both solutions contain the same notes, no parameter has been changed.")
+	}
+	if len(revertedNotes) > 0 {
+		fmt.Fprintln(writer, "The following notes have been reverted:")
+		printNoteIDs(writer, revertedNotes)
+	}
+	if len(appliedNotes) > 0 {
+		fmt.Fprintln(writer, "The following notes have been applied:")
+		printNoteIDs(writer, appliedNotes)
+	}
+}
+
+// printNoteIDs prints the note IDs and the names of the notes
+func printNoteIDs(writer io.Writer, noteIDs []string) {
+	for _, noteID := range noteIDs {
+		noteName := ""
+		if aNote, ok := tuningOptions[noteID]; ok {
+			noteName = aNote.Name()
+		}
+		fmt.Fprintf(writer, "\t%s\t%s\n", noteID, noteName)
+	}
+}
+
+// SolutionActionDetect inspects the file system below rootDir for installed
+// SAP components and recommends the matching solution. With 'apply' the
+// recommended solution is applied
+func SolutionActionDetect(writer io.Writer, rootDir string, apply bool) {
+	evidence := solution.DetectSAPComponents(rootDir)
+	solName := solution.RecommendSolution(evidence, tuneApp.AllSolutions, tuneApp.Catalog.Deprecated[solutionSelector])
+	printDetection(writer, evidence, solName)
+	if !apply {
+		return
+	}
+	if solName == "" {
+		errorExit("No solution can be recommended for the system, nothing to apply.")
+	}
+	fmt.Fprintln(writer, "")
+	SolutionActionApply(solName)
+}
+
+// printDetection prints the detected SAP components and the recommended
+// solution
+func printDetection(writer io.Writer, evidence []solution.Evidence, solName string) {
+	if len(evidence) == 0 {
+		fmt.Fprintln(writer, "No installed SAP components found.")
+	} else {
+		format := "\t%-30s %-4s %-9s %s\n"
+		fmt.Fprintln(writer, "Detected SAP components:")
+		fmt.Fprintf(writer, format, "Component", "SID", "Instance", "Found in")
+		for _, ev := range evidence {
+			fmt.Fprintf(writer, format, ev.Component, ev.SID, ev.Instance, ev.Path)
+		}
+	}
+	if solName == "" {
+		fmt.Fprintln(writer, "\nNo solution can be recommended for the system. Please choose a solution manually, see 'saptune solution list'.")
+		return
+	}
+	fmt.Fprintf(writer, "\nRecommended solution: %s\n", solName)
+	fmt.Fprintf(writer, "Please check the recommendation, S/4HANA systems can not be distinguished from NetWeaver systems. Use 'saptune solution apply %s' or 'saptune solution detect --apply' to apply the recommended solution.\n", solName)
+}
+
+// warnDeprecatedSolutions logs a warning for each deprecated solution
+// of the list
+func warnDeprecatedSolutions(solNames []string) {
+	for _, solName := range solNames {
+		deprec, ok := tuneApp.Catalog.Deprecation(solutionSelector, solName)
+		if !ok {
+			continue
+		}
+		if deprec.Successor != "" {
+			system.WarningLog("Solution '%s' is deprecated (%s). Please use 'saptune solution migrate %s' to replace it by solution '%s'.", solName, deprec.Reason, solName, deprec.Successor)
+		} else {
+			system.WarningLog("Solution '%s' is deprecated (%s).", solName, deprec.Reason)
+		}
+	}
+}
+
+// isAppliedSolution checks, if the solution is applied
+func isAppliedSolution(solName string) bool {
+	for _, sol := range tuneApp.TuneForSolutions {
+		if sol == solName {
+			return true
+		}
+	}
+	return false
+}
+
+// getSolovFile returns the corresponding override filename of a given
+// solution and if the override file already exists
+func getSolovFile(solName string) (string, bool) {
+	ovFileName := path.Join(tuneApp.Paths.OverrideDir, solName+solution.SolutionFileSuffix)
+	if _, err := os.Stat(ovFileName); os.IsNotExist(err) {
+		return ovFileName, false
+	} else if err != nil {
+		errorExit("Failed to read file '%s' - %v", ovFileName, err)
+	}
+	return ovFileName, true
+}
+
 // getFileName returns the corresponding filename of a given noteID
 // additional it returns a boolean value which is pointing out that the Note
 // the Note is a custom Note (extraNote = true) or an internal one
 func getFileName(noteID string) (string, bool) {
 	extraNote := false
-	fileName := fmt.Sprintf("%s%s", NoteTuningSheets, noteID)
+	fileName := path.Join(tuneApp.Paths.NoteDir, noteID)
 	if _, err := os.Stat(fileName); os.IsNotExist(err) {
 		// Note is NOT an internal Note, but may be a custom Note
 		extraNote = true
-		_, files := system.ListDir(ExtraTuningSheets, "")
+		_, files := system.ListDir(tuneApp.Paths.ExtraDir, "")
 		for _, f := range files {
-			if strings.HasPrefix(f, noteID) {
-				fileName = fmt.Sprintf("%s%s", ExtraTuningSheets, f)
+			if strings.HasPrefix(f, noteID) && !strings.HasSuffix(f, solution.SolutionFileSuffix) {
+				fileName = path.Join(tuneApp.Paths.ExtraDir, f)
 			}
 		}
 		if _, err := os.Stat(fileName); os.IsNotExist(err) {
-			errorExit("Note %s not found in %s or %s.", noteID, NoteTuningSheets, ExtraTuningSheets)
+			errorExit("Note %s not found in %s or %s.", noteID, tuneApp.Paths.NoteDir, tuneApp.Paths.ExtraDir)
 		} else if err != nil {
 			errorExit("Failed to read file '%s' - %v", fileName, err)
 		}
@@ -1067,7 +1860,7 @@ func getFileName(noteID string) (string, bool) {
 // override file already exists (overrideNote = true) or not
 func getovFile(noteID string) (string, bool) {
 	overrideNote := true
-	ovFileName := fmt.Sprintf("%s%s", OverrideTuningSheets, noteID)
+	ovFileName := path.Join(tuneApp.Paths.OverrideDir, noteID)
 	if _, err := os.Stat(ovFileName); os.IsNotExist(err) {
 		overrideNote = false
 	} else if err != nil {
@@ -1099,13 +1892,13 @@ func readYesNo(s string, in io.Reader) bool {
 // renameNote will rename a Note to an new name
 func renameNote(newNoteID, fileName, ovFileName string, overrideNote, extraNote bool) {
 	if overrideNote {
-		newovFileName := fmt.Sprintf("%s%s", OverrideTuningSheets, newNoteID)
+		newovFileName := path.Join(tuneApp.Paths.OverrideDir, newNoteID)
 		if err := os.Rename(ovFileName, newovFileName); err != nil {
 			errorExit("Failed to rename file '%s' to '%s' - %v", ovFileName, newovFileName, err)
 		}
 	}
 	if extraNote {
-		newFileName := fmt.Sprintf("%s%s.conf", ExtraTuningSheets, newNoteID)
+		newFileName := path.Join(tuneApp.Paths.ExtraDir, newNoteID+".conf")
 		if err := os.Rename(fileName, newFileName); err != nil {
 			errorExit("Failed to rename file '%s' to '%s' - %v", fileName, newFileName, err)
 		}
@@ -6,9 +6,13 @@ import (
 	"github.com/SUSE/saptune/app"
 	"github.com/SUSE/saptune/sap/note"
 	"github.com/SUSE/saptune/sap/solution"
+	"github.com/SUSE/saptune/system"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"reflect"
+	"runtime"
 	"syscall"
 	"testing"
 )
@@ -22,8 +26,8 @@ var AllTestSolutions = map[string]solution.Solution{
 	"sol12": solution.Solution{"1001", "1002"},
 }
 
-var tuningOpts = note.GetTuningOptions("", TstFilesInGOPATH)
-var tApp = app.InitialiseApp(OSPackageInGOPATH, "", tuningOpts, AllTestSolutions)
+var tuningOpts = note.GetTuningOptions("", TstFilesInGOPATH, "")
+var tApp = app.InitialiseApp(OSPackageInGOPATH, "", app.NewStaticCatalogLoader(tuningOpts, AllTestSolutions))
 var checkOut = func(t *testing.T, got, want string) {
 	t.Helper()
 	if got != want {
@@ -91,14 +95,27 @@ Parameters tuned by the notes and solutions have been successfully reverted.
 	checkOut(t, txt, revertMatchText)
 }
 
+func TestBlockAction(t *testing.T) {
+	dirCont, err := ioutil.ReadDir("/sys/block")
+	if err != nil || len(dirCont) == 0 {
+		t.Skip("no block devices available. Skip test.")
+	}
+	bdev := dirCont[0].Name()
+	var blockMatchText = fmt.Sprintf("Block device settings of the applied notes have been applied to '%s'.\n", bdev)
+	buffer := bytes.Buffer{}
+	BlockAction(&buffer, "apply", path.Join("/dev", bdev), tApp)
+	txt := buffer.String()
+	checkOut(t, txt, blockMatchText)
+}
+
 func TestNoteActionList(t *testing.T) {
 	var listMatchText = `
 All notes (+ denotes manually enabled notes, * denotes notes enabled by solutions, - denotes notes enabled by solutions but reverted manually later, O denotes override file exists for note):
 	extraNote	Configuration drop in for extra tests
-			Version 0 from 04.06.2019 
+			Version 0 from 04.06.2019
 	oldFile		Name_syntax
 	simpleNote	Configuration drop in for simple tests
-			Version 1 from 09.07.2019 
+			Version 1 from 09.07.2019
 Remember: if you wish to automatically activate the solution's tuning options after a reboot,you must instruct saptune to configure "tuned" daemon by running:
     saptune daemon start
 `
@@ -246,6 +263,258 @@ func TestPrintNoteFields(t *testing.T) {
 		//txt := PrintNoteFields("NONE", noteComp, false)
 		checkCorrectMessage(t, txt, printMatchText4)
 	})
+
+	var printMatchText5 = `   SAPNote, Version | Parameter           | Expected             | Override  | Actual               | Compliant
+--------------------+---------------------+----------------------+-----------+----------------------+-----------
+   941735,          | ShmFileSystemSizeMB | 1714                 |           | 488                  | no  [10]
+   941735,          | kernel.shmmax       | 18446744073709551615 |           | 18446744073709551615 | yes [10]
+
+ [10] memory sizes in human-readable form:
+     ShmFileSystemSizeMB: expected 1.67 GiB, actual 488 MiB
+     kernel.shmmax: expected 16 EiB, actual 16 EiB
+
+`
+	fcomp6 := note.FieldComparison{ReflectFieldName: "MemSizes", ReflectMapKey: "ShmFileSystemSizeMB", ActualValue: "488 MiB", ExpectedValue: "1.67 GiB", ActualValueJS: "488 MiB", ExpectedValueJS: "1.67 GiB", MatchExpectation: false}
+	fcomp7 := note.FieldComparison{ReflectFieldName: "MemSizes", ReflectMapKey: "kernel.shmmax", ActualValue: "16 EiB", ExpectedValue: "16 EiB", ActualValueJS: "16 EiB", ExpectedValueJS: "16 EiB", MatchExpectation: true}
+	map941735["MemSizes[ShmFileSystemSizeMB]"] = fcomp6
+	map941735["MemSizes[kernel.shmmax]"] = fcomp7
+	t.Run("verify with memory sizes", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+		PrintNoteFields(&buffer, "NONE", noteComp, true)
+		txt := buffer.String()
+		checkCorrectMessage(t, txt, printMatchText5)
+	})
+}
+
+func TestPrintConditions(t *testing.T) {
+	noArch := "ppc64le"
+	if runtime.GOARCH == noArch {
+		noArch = "amd64"
+	}
+	condFile := "/tmp/saptune_cond_test"
+	condNote := fmt.Sprintf("[sysctl]\n{arch=%s} vm.swappiness = 10\n\n[sysctl:arch=%s]\nvm.swappiness = 20\n", runtime.GOARCH, noArch)
+	if err := ioutil.WriteFile(condFile, []byte(condNote), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(condFile)
+	var condMatchText = fmt.Sprintf(`Conditions of the Note 4711 on this system (%s):
+	entry '{arch=%s} vm.swappiness = 10' of section [sysctl] - used
+		arch=%s: matched (system: %s)
+	section [sysctl:arch=%s] - not used
+		arch=%s: not matched (system: %s)
+
+`, system.GetHostFacts().String(), runtime.GOARCH, runtime.GOARCH, runtime.GOARCH, noArch, noArch, runtime.GOARCH)
+	buffer := bytes.Buffer{}
+	printConditions(&buffer, "Note 4711", condFile, nil)
+	checkOut(t, buffer.String(), condMatchText)
+
+	if err := ioutil.WriteFile(condFile, []byte("[sysctl]\nvm.swappiness = 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	buffer = bytes.Buffer{}
+	printConditions(&buffer, "Note 4711", condFile, nil)
+	checkOut(t, buffer.String(), "The Note 4711 does not contain conditions.\n\n")
+}
+
+func TestPrintResolvedNote(t *testing.T) {
+	inheritDir := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/inherit")
+	noteFiles := note.GetTuningOptions(inheritDir, "", "").NoteFiles()
+	var resolvedMatchText = fmt.Sprintf(`Resolved definition of Note 4712 (based on %[1]s/4711):
+[sysctl]
+	vm.swappiness = 10	(from %[1]s/4712)
+	kernel.numa_balancing = 0	(from %[1]s/4711)
+	kernel.shmmni = 32768	(from %[1]s/4712)
+[limits]
+	LIMITS = @sapsys soft nofile 65536	(from %[1]s/4711)
+	LIMITS = @dba hard nofile 1048576	(from %[1]s/4712)
+[reminder]
+	# reminder of the base note	(from %[1]s/4711)
+Unset parameters:
+	[sysctl] vm.dirty_bytes =	(by %[1]s/4712)
+	[limits] LIMITS = @sapsys hard nofile	(by %[1]s/4712)
+
+`, inheritDir)
+	buffer := bytes.Buffer{}
+	printResolvedNote(&buffer, "4712", path.Join(inheritDir, "4712"), noteFiles)
+	checkOut(t, buffer.String(), resolvedMatchText)
+
+	buffer = bytes.Buffer{}
+	printResolvedNote(&buffer, "4711", path.Join(inheritDir, "4711"), noteFiles)
+	checkOut(t, buffer.String(), "")
+}
+
+func TestSplitOverrideParam(t *testing.T) {
+	tests := []struct {
+		action, param, section, key, value string
+		fail                               bool
+	}{
+		{"set", "sysctl.vm.swappiness=10", "sysctl", "vm.swappiness", "10", false},
+		{"set", "limits.LIMITS = @sapsys soft nofile 65536", "limits", "LIMITS", "@sapsys soft nofile 65536", false},
+		{"set", "grub.numa_balancing=disable=1", "grub", "numa_balancing", "disable=1", false},
+		{"untouch", "vm.THP", "vm", "THP", "", false},
+		{"unset", "sysctl.kernel.shmmni", "sysctl", "kernel.shmmni", "", false},
+		{"set", "sysctl.vm.swappiness", "", "", "", true},
+		{"unset", "sysctl.vm.swappiness=10", "", "", "", true},
+		{"set", "swappiness=10", "", "", "", true},
+		{"untouch", ".THP", "", "", "", true},
+	}
+	for _, tst := range tests {
+		section, key, value, err := splitOverrideParam(tst.action, tst.param)
+		if (err != nil) != tst.fail {
+			t.Errorf("'%s %s': unexpected error state '%v'\n", tst.action, tst.param, err)
+		}
+		if section != tst.section || key != tst.key || value != tst.value {
+			t.Errorf("'%s %s': got '%s', '%s', '%s'\n", tst.action, tst.param, section, key, value)
+		}
+	}
+}
+
+func TestDryRunOption(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"saptune", "note", "apply", "--dry-run", "simpleNote"}
+	if !cutDryRunOption() {
+		t.Fatal("option '--dry-run' not found")
+	}
+	if !reflect.DeepEqual(os.Args, []string{"saptune", "note", "apply", "simpleNote"}) {
+		t.Fatalf("wrong arguments: %+v\n", os.Args)
+	}
+	if cutDryRunOption() {
+		t.Fatal("option '--dry-run' found twice")
+	}
+	for _, tst := range []struct {
+		cmd, action string
+		supported   bool
+	}{
+		{"note", "apply", true}, {"note", "revert", true}, {"solution", "apply", true},
+		{"solution", "revert", true}, {"revert", "all", true}, {"note", "verify", false},
+		{"solution", "migrate", true}, {"note", "migrate", false}, {"daemon", "start", false}, {"note", "set", false},
+	} {
+		if dryRunSupported(tst.cmd, tst.action) != tst.supported {
+			t.Errorf("'%s %s': expected '%v'\n", tst.cmd, tst.action, tst.supported)
+		}
+	}
+}
+
+func TestPrintWritePlan(t *testing.T) {
+	var planMatchText = `
+Dry run - nothing was changed. The following changes would be done:
+
+System:
+    write   /proc/sys/vm/swappiness = '10'
+    mkdir   /etc/systemd/logind.conf.d
+    write   /etc/systemd/logind.conf.d/saptune-UserTasksMax.conf
+            | [Login]
+            | UserTasksMax=infinity
+    run     systemctl restart systemd-logind.service
+    remove  /etc/security/limits.d/saptune-@sapsys-nofile-soft.conf
+
+saptune configuration and state:
+    write   /var/lib/saptune/saved_state/simpleNote
+    write   /etc/sysconfig/saptune
+`
+	plan := []system.PlanStep{
+		{Action: system.PlanWrite, Target: "/proc/sys/vm/swappiness", Value: "10"},
+		{Action: system.PlanMkdir, Target: "/etc/systemd/logind.conf.d"},
+		{Action: system.PlanWrite, Target: "/var/lib/saptune/saved_state/simpleNote", Value: "{}"},
+		{Action: system.PlanWrite, Target: "/etc/systemd/logind.conf.d/saptune-UserTasksMax.conf", Value: "[Login]\nUserTasksMax=infinity\n"},
+		{Action: system.PlanRun, Target: "systemctl restart systemd-logind.service"},
+		{Action: system.PlanRemove, Target: "/etc/security/limits.d/saptune-@sapsys-nofile-soft.conf"},
+		{Action: system.PlanWrite, Target: "/etc/sysconfig/saptune", Value: "NOTE_APPLY_ORDER=\"simpleNote\"\n"},
+	}
+	buffer := bytes.Buffer{}
+	PrintWritePlan(&buffer, plan)
+	checkOut(t, buffer.String(), planMatchText)
+
+	buffer = bytes.Buffer{}
+	PrintWritePlan(&buffer, []system.PlanStep{})
+	checkOut(t, buffer.String(), "\nDry run - nothing would be changed on the system.\n")
+}
+
+func TestPrintSolution(t *testing.T) {
+	solFile := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/sol/sols/OLDSOL.sol")
+	ovFile := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/sol/override/HANA.sol")
+	def, err := solution.ParseSolutionFile(solFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solCont, _ := ioutil.ReadFile(solFile)
+	var showMatchText = `
+Solution OLDSOL:
+	Description:   Deprecated test solution
+	Version:       1
+	Architectures: all
+	Notes:         941735
+	Deprecated:    replaced by TSTSOL
+	Replacement:   TSTSOL
+	Definition:    ` + solFile + `
+
+Content of ` + solFile + `:
+` + string(solCont) + `
+`
+	buffer := bytes.Buffer{}
+	printSolution(&buffer, tApp, "OLDSOL", def.Notes, def, solution.Deprecation{Successor: def.Replacement, Reason: def.Deprecated}, "")
+	checkOut(t, buffer.String(), showMatchText)
+
+	// solution of the solution definition file with override file
+	ovCont, _ := ioutil.ReadFile(ovFile)
+	showMatchText = `
+Solution HANA:
+	Notes:         941735 2382421
+	Definition:    /usr/share/saptune/solutions
+	Override:      ` + ovFile + `
+
+Content of ` + ovFile + `:
+` + string(ovCont) + `
+`
+	buffer = bytes.Buffer{}
+	printSolution(&buffer, tApp, "HANA", solution.Solution{"941735", "2382421"}, solution.SolutionDefinition{}, solution.Deprecation{}, ovFile)
+	checkOut(t, buffer.String(), showMatchText)
+}
+
+func TestPrintMigration(t *testing.T) {
+	var migrateMatchText = `Solution 'sol1' has been replaced by solution 'sol2'.
+The following notes have been reverted:
+	1001	
+The following notes have been applied:
+	1002	
+`
+	buffer := bytes.Buffer{}
+	printMigration(&buffer, "sol1", "sol2", []string{"1001"}, []string{"1002"})
+	checkOut(t, buffer.String(), migrateMatchText)
+
+	migrateMatchText = `Solution 'sol1' has been replaced by solution 'sol12'.
+Both solutions contain the same notes, no parameter has been changed.
+`
+	buffer = bytes.Buffer{}
+	printMigration(&buffer, "sol1", "sol12", []string{}, []string{})
+	checkOut(t, buffer.String(), migrateMatchText)
+}
+
+func TestPrintDetection(t *testing.T) {
+	evidence := []solution.Evidence{
+		{Component: solution.CompHANA, SID: "HA0", Instance: "HDB00", Path: "/usr/sap/HA0/HDB00"},
+		{Component: solution.CompHANA, SID: "HA0", Instance: "", Path: "/hana/shared/HA0"},
+	}
+	var detectMatchText = `Detected SAP components:
+	Component                      SID  Instance  Found in
+	HANA database                  HA0  HDB00     /usr/sap/HA0/HDB00
+	HANA database                  HA0            /hana/shared/HA0
+
+Recommended solution: HANA
+Please check the recommendation, S/4HANA systems can not be distinguished from NetWeaver systems. Use 'saptune solution apply HANA' or 'saptune solution detect --apply' to apply the recommended solution.
+`
+	buffer := bytes.Buffer{}
+	printDetection(&buffer, evidence, "HANA")
+	checkOut(t, buffer.String(), detectMatchText)
+
+	detectMatchText = `No installed SAP components found.
+
+No solution can be recommended for the system. Please choose a solution manually, see 'saptune solution list'.
+`
+	buffer = bytes.Buffer{}
+	printDetection(&buffer, []solution.Evidence{}, "")
+	checkOut(t, buffer.String(), detectMatchText)
 }
 
 func TestCheckUpdateLeftOvers(t *testing.T) {
@@ -0,0 +1,20 @@
+# This is a template for a saptune solution definition file.
+#
+# You can add here your own solution definition. See saptune(8) for details.
+# The name of the solution is the name of the file without the suffix '.sol'.
+# The section [solution] and the key NOTES are mandatory, they *must* exist.
+#
+# change all _TEXT_TO_CHANGE_ with your values
+
+[solution]
+VERSION=0
+DATE=_TEXT_TO_CHANGE_
+DESCRIPTION="_TEXT_TO_CHANGE_"
+# supported architectures like 'amd64', 'ppc64le', 'arm64' or 's390x', empty for all architectures
+ARCHITECTURES=
+# list of NoteIDs, separated by space or ','
+NOTES=_TEXT_TO_CHANGE_
+# reason of the deprecation, empty if the solution is not deprecated
+DEPRECATED=
+# name of the solution replacing this deprecated solution
+REPLACEMENT=
@@ -42,14 +42,20 @@ func (paging LinuxPagingImprovements) Optimise() (Note, error) {
 		return nil, err
 	}
 	inputEnable := conf.GetBool("ENABLE_PAGECACHE_LIMIT", false)
-	inputOverride := conf.GetInt("OVERRIDE_PAGECACHE_LIMIT_MB", 0)
+	// the page cache limit can be given with unit suffix or as
+	// percentage of the main memory
+	inputOverride, sizeErr := system.ParseMemSize(conf.GetString("OVERRIDE_PAGECACHE_LIMIT_MB", "0"), "M")
+	if sizeErr != nil {
+		system.WarningLog("wrong value for OVERRIDE_PAGECACHE_LIMIT_MB in '%s' - %v", newPaging.PagingConfig, sizeErr)
+		inputOverride = 0
+	}
 
 	// As discussed with SAP and Alliance team, use the HANA formula for
 	// Netweaver too.
 	// So for HANA and Netweaver: new limit is 2% system memory
 	newPaging.VMPagecacheLimitMB = system.GetMainMemSizeMB() * 2 / 100
 	if inputOverride != 0 {
-		newPaging.VMPagecacheLimitMB = uint64(inputOverride)
+		newPaging.VMPagecacheLimitMB = inputOverride
 	}
 	if !inputEnable {
 		newPaging.VMPagecacheLimitMB = 0
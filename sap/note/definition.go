@@ -0,0 +1,123 @@
+package note
+
+// Version and content hash of Note definitions to detect changes of the
+// Note definition of an applied Note
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/SUSE/saptune/txtparser"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// NoteDefinition contains the version and the content hash of the Note
+// definition file, the Note definition files it is based on and the
+// override file of a Note together with the parameter values defined by
+// these files
+type NoteDefinition struct {
+	Version string
+	Hash    string
+	Params  map[string]string
+}
+
+// Definition returns the version, the content hash and the parameter
+// values of the Note definition including the override file
+func (vend INISettings) Definition() (NoteDefinition, error) {
+	def := NoteDefinition{Params: make(map[string]string)}
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
+	if err != nil {
+		return def, err
+	}
+	def.Version = vend.Metadata().Version
+
+	// the Note definition file and all Note definition files it is
+	// based on, directly or by a base Note
+	files := []string{}
+	seen := make(map[string]bool)
+	for _, file := range append([]string{vend.ConfFilePath}, ini.Bases...) {
+		if !seen[file] {
+			files = append(files, file)
+			seen[file] = true
+		}
+	}
+	for _, param := range ini.AllValues {
+		if param.Origin != "" && !seen[param.Origin] {
+			files = append(files, param.Origin)
+			seen[param.Origin] = true
+		}
+	}
+	overrideFile := vend.overrideFile()
+	ow, err := txtparser.ParseINIFile(overrideFile, false)
+	if err == nil {
+		files = append(files, overrideFile)
+	}
+	hash := sha256.New()
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return def, err
+		}
+		hash.Write(content)
+		// separate the file contents
+		hash.Write([]byte{0})
+	}
+	def.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	for _, param := range ini.AllValues {
+		value := param.Value
+		op := param.Operator
+		if ow != nil {
+			if oparam, ok := ow.KeyValue[param.Section][param.Key]; ok {
+				value = oparam.Value
+				op = oparam.Operator
+				if value == "" && param.Section != INISectionPagecache {
+					value = "untouched"
+				}
+			}
+		}
+		value = strings.Join(strings.Fields(value), " ")
+		if op != "" && op != txtparser.OperatorEqual && param.Section != INISectionRpm {
+			value = fmt.Sprintf("%s %s", op, value)
+		}
+		def.Params[param.Key] = value
+	}
+	return def, nil
+}
+
+// Changed checks, if the Note definition differs from a former Note
+// definition
+func (def NoteDefinition) Changed(former NoteDefinition) bool {
+	return def.Hash != former.Hash
+}
+
+// DiffParams returns the parameters, which differ from a former Note
+// definition, sorted by parameter name like "vm.dirty_bytes: '629145600' -> '0'"
+func (def NoteDefinition) DiffParams(former NoteDefinition) []string {
+	keys := make([]string, 0, len(def.Params))
+	for key := range def.Params {
+		keys = append(keys, key)
+	}
+	for key := range former.Params {
+		if _, ok := def.Params[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	diffs := []string{}
+	for _, key := range keys {
+		oldVal, oldOK := former.Params[key]
+		newVal, newOK := def.Params[key]
+		switch {
+		case !oldOK:
+			diffs = append(diffs, fmt.Sprintf("%s: added '%s'", key, newVal))
+		case !newOK:
+			diffs = append(diffs, fmt.Sprintf("%s: removed '%s'", key, oldVal))
+		case oldVal != newVal:
+			diffs = append(diffs, fmt.Sprintf("%s: '%s' -> '%s'", key, oldVal, newVal))
+		}
+	}
+	return diffs
+}
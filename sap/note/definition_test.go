@@ -0,0 +1,72 @@
+package note
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestNoteDefinition(t *testing.T) {
+	defFile := path.Join(os.TempDir(), "saptune_definition_test")
+	defer os.Remove(defFile)
+	content := `[version]
+VIP-NOTE=defTest
+VERSION=1
+DATE=01.10.2019
+NAME="definition test"
+
+[sysctl]
+vm.dirty_bytes = 629145600
+kernel.shmmni >= 4096
+vm.swappiness = 10
+`
+	if err := ioutil.WriteFile(defFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vend := INISettings{ConfFilePath: defFile, ID: "saptune_definition_test"}
+	former, err := vend.Definition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expParams := map[string]string{"vm.dirty_bytes": "629145600", "kernel.shmmni": ">= 4096", "vm.swappiness": "10"}
+	if former.Version != "1" || len(former.Hash) != 64 || !reflect.DeepEqual(former.Params, expParams) {
+		t.Fatalf("%+v\n", former)
+	}
+	same, _ := vend.Definition()
+	if same.Changed(former) || len(same.DiffParams(former)) != 0 {
+		t.Fatalf("%+v\n", same)
+	}
+
+	content = `[version]
+VIP-NOTE=defTest
+VERSION=2
+DATE=02.10.2019
+NAME="definition test"
+
+[sysctl]
+vm.dirty_bytes = 0
+kernel.shmmni >= 4096
+vm.max_map_count = 2147483647
+`
+	if err := ioutil.WriteFile(defFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	current, err := vend.Definition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !current.Changed(former) || current.Version != "2" {
+		t.Fatalf("%+v\n", current)
+	}
+	expDiff := []string{"vm.dirty_bytes: '629145600' -> '0'", "vm.max_map_count: added '2147483647'", "vm.swappiness: removed '10'"}
+	if diff := current.DiffParams(former); !reflect.DeepEqual(diff, expDiff) {
+		t.Fatalf("got '%+v', expected '%+v'\n", diff, expDiff)
+	}
+
+	vend = INISettings{ConfFilePath: "/not_avail_file", ID: "not_avail"}
+	if _, err := vend.Definition(); err == nil {
+		t.Fatal("expected error for missing Note definition file")
+	}
+}
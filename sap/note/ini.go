@@ -1,6 +1,7 @@
 package note
 
 import (
+	"fmt"
 	"github.com/SUSE/saptune/sap"
 	"github.com/SUSE/saptune/sap/param"
 	"github.com/SUSE/saptune/system"
@@ -15,7 +16,7 @@ import (
 const OverrideTuningSheets = "/etc/saptune/override/"
 
 var pc = LinuxPagingImprovements{}
-var blck = param.BlockDeviceQueue{param.BlockDeviceSchedulers{SchedulerChoice: make(map[string]string)}, param.BlockDeviceNrRequests{NrRequests: make(map[string]int)}}
+var blck = param.BlockDeviceQueue{param.BlockDeviceSchedulers{SchedulerChoice: make(map[string]string)}, param.BlockDeviceNrRequests{NrRequests: make(map[string]int)}, param.BlockDeviceTunables{Tunables: make(map[string]map[string]string)}}
 var isLimitSoft = regexp.MustCompile(`LIMIT_.*_soft_memlock`)
 var isLimitHard = regexp.MustCompile(`LIMIT_.*_hard_memlock`)
 var flstates = ""
@@ -81,6 +82,24 @@ type INISettings struct {
 	ValuesToApply   map[string]string // values to apply
 	OverrideParams  map[string]string // parameter values from the override file
 	Inform          map[string]string // special information for parameter values
+	Expressions     map[string]string // expressions of computed parameter values
+	ValueSpecs      map[string]string // value specifications of parameters
+	MemSizes        map[string]string // human-readable values of memory sizes
+	OverrideDir     string            // directory of the override file, OverrideTuningSheets if empty
+	// values of the placeholders of the limits definitions from the
+	// saptune sysconfig file, not part of the saved state
+	LimitsPlaceholders map[string][]string `json:"-"`
+	// Note definition files of the Note catalog by Note ID to find the
+	// base Notes, not part of the saved state
+	NoteFiles map[string]string `json:"-"`
+}
+
+// overrideFile returns the path of the override file of the Note
+func (vend INISettings) overrideFile() string {
+	if vend.OverrideDir == "" {
+		return path.Join(OverrideTuningSheets, vend.ID)
+	}
+	return path.Join(vend.OverrideDir, vend.ID)
 }
 
 // Name returns the name of the related SAP Note or en empty string
@@ -91,31 +110,52 @@ func (vend INISettings) Name() string {
 	return vend.DescriptiveName
 }
 
+// Metadata returns the metadata of the Note from the [version] section
+// of the Note definition file
+func (vend INISettings) Metadata() txtparser.NoteMetadata {
+	meta, _ := txtparser.GetNoteMetadata(vend.ConfFilePath)
+	return meta
+}
+
 // Initialise retrieves the current parameter values from the system
 func (vend INISettings) Initialise() (Note, error) {
 	// Parse the configuration file
-	ini, err := txtparser.ParseINIFile(vend.ConfFilePath, false)
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
 	if err != nil {
 		return vend, err
 	}
 
 	// looking for override file
 	override := false
-	ow, err := txtparser.ParseINIFile(path.Join(OverrideTuningSheets, vend.ID), false)
+	ow, err := txtparser.ParseINIFile(vend.overrideFile(), false)
 	if err == nil {
 		override = true
 	}
+	// fill in the placeholders of the limits definitions
+	if override {
+		resolveINILimitsPlaceholders(ow, nil, vend.LimitsPlaceholders)
+		resolveINILimitsPlaceholders(ini, ow, vend.LimitsPlaceholders)
+	} else {
+		resolveINILimitsPlaceholders(ini, nil, vend.LimitsPlaceholders)
+	}
 	// Read current parameter values
 	vend.SysctlParams = make(map[string]string)
 	vend.OverrideParams = make(map[string]string)
 	vend.Inform = make(map[string]string)
+	vend.Expressions = make(map[string]string)
+	vend.ValueSpecs = make(map[string]string)
+	vend.MemSizes = make(map[string]string)
 	pc = LinuxPagingImprovements{}
-	blck = param.BlockDeviceQueue{param.BlockDeviceSchedulers{SchedulerChoice: make(map[string]string)}, param.BlockDeviceNrRequests{NrRequests: make(map[string]int)}}
+	blck = param.BlockDeviceQueue{param.BlockDeviceSchedulers{SchedulerChoice: make(map[string]string)}, param.BlockDeviceNrRequests{NrRequests: make(map[string]int)}, param.BlockDeviceTunables{Tunables: make(map[string]map[string]string)}}
 
 	for _, param := range ini.AllValues {
 		if override && len(ow.KeyValue[param.Section]) != 0 {
 			param.Key, param.Value, param.Operator = vend.handleInitOverride(param.Key, param.Value, param.Section, param.Operator, ow)
 		}
+		// remember the expression of a computed value for 'show'
+		// and 'simulate'
+		vend.addExpression(param.Section, param.Key, param.Value)
+		vend.addValueSpec(param.Section, param.Key, param.Operator, param.Value)
 
 		switch param.Section {
 		case INISectionSysctl:
@@ -125,7 +165,7 @@ func (vend INISettings) Initialise() (Note, error) {
 		case INISectionBlock:
 			vend.SysctlParams[param.Key], vend.Inform[param.Key], _ = GetBlkVal(param.Key, &blck)
 		case INISectionLimits:
-			vend.SysctlParams[param.Key], _ = GetLimitsVal(param.Value)
+			vend.SysctlParams[param.Key], vend.Inform[param.Key] = GetLimitsVal(param.Value)
 		case INISectionService:
 			vend.SysctlParams[param.Key] = GetServiceVal(param.Key)
 		case INISectionLogin:
@@ -135,7 +175,7 @@ func (vend INISettings) Initialise() (Note, error) {
 		case INISectionCPU:
 			vend.SysctlParams[param.Key], flstates, vend.Inform[param.Key] = GetCPUVal(param.Key)
 		case INISectionRpm:
-			vend.SysctlParams[param.Key] = GetRpmVal(param.Key)
+			vend.SysctlParams[param.Key], vend.Inform[param.Key] = GetRpmVal(param.Key)
 			continue
 		case INISectionGrub:
 			vend.SysctlParams[param.Key] = GetGrubVal(param.Key)
@@ -145,9 +185,12 @@ func (vend INISettings) Initialise() (Note, error) {
 			continue
 		case INISectionPagecache:
 			// page cache is special, has it's own config file
-			// so adjust path to pagecache config file, if needed
+			// so adjust path to pagecache config file (override
+			// file or inherited base Note definition), if needed
 			if override {
-				pc.PagingConfig = path.Join(OverrideTuningSheets, vend.ID)
+				pc.PagingConfig = vend.overrideFile()
+			} else if param.Origin != "" {
+				pc.PagingConfig = param.Origin
 			} else {
 				pc.PagingConfig = vend.ConfFilePath
 			}
@@ -156,6 +199,7 @@ func (vend INISettings) Initialise() (Note, error) {
 			system.WarningLog("3rdPartyTuningOption %s: skip unknown section %s", vend.ConfFilePath, param.Section)
 			continue
 		}
+		vend.addMemSize(param.Section, param.Key)
 		// create parameter saved state file, if NOT in 'verify'
 		vend.createParamSavedStates(param.Key, flstates)
 	}
@@ -167,20 +211,14 @@ func (vend INISettings) Optimise() (Note, error) {
 	blckOK := make(map[string][]string)
 	scheds := ""
 	// Parse the configuration file
-	ini, err := txtparser.ParseINIFile(vend.ConfFilePath, false)
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
 	if err != nil {
 		return vend, err
 	}
 
-	for _, param := range ini.AllValues {
+	// use the limits definitions resolved during Initialise
+	for _, param := range vend.expandLimitsPlaceholders(ini.AllValues) {
 		// Compare current values against INI's definition
-		if len(vend.OverrideParams) != 0 && vend.ID == "1805750" {
-			// as note 1805750 does not set a limits domain, but
-			// the customer should be able to set the correct
-			// domain using an override file we need to rewrite
-			// param.Key and param.Value to get a correct behaviour
-			param.Key, param.Value = vend.handleID1805750(param.Key, param.Value)
-		}
 		if len(vend.OverrideParams[param.Key]) != 0 {
 			// use value from override file instead of the value
 			// from the sap note (ConfFile)
@@ -192,10 +230,34 @@ func (vend INISettings) Optimise() (Note, error) {
 			}
 			param.Value = vend.OverrideParams[param.Key]
 		}
+		if txtparser.IsExpression(param.Value) && param.Section != INISectionReminder {
+			// compute the value from the expression
+			val, err := evalExpression(param.Section, param.Value, vend.SysctlParams[param.Key])
+			if err != nil {
+				_ = system.ErrorLog("Note %s, parameter '%s' skipped - %v", vend.ID, param.Key, err)
+				continue
+			}
+			param.Value = val
+		}
+		if unit, ok := memSizeUnit(param.Section, param.Key); ok && vend.ValueSpecs[param.Key] == "" {
+			// convert a memory size with unit suffix or
+			// percentage into the unit expected by the system
+			val, err := normaliseMemSize(param.Section, param.Value, unit)
+			if err != nil {
+				_ = system.ErrorLog("Note %s, parameter '%s' skipped - %v", vend.ID, param.Key, err)
+				continue
+			}
+			param.Value = val
+		}
 		switch param.Section {
 		case INISectionSysctl:
 			//optimisedValue, err := CalculateOptimumValue(param.Operator, vend.SysctlParams[param.Key], param.Value)
 			//vend.SysctlParams[param.Key] = optimisedValue
+			if spec, ok := vend.ValueSpecs[param.Key]; ok {
+				// operator and value from the override file
+				// or the Note definition file
+				param.Operator, param.Value = splitValueSpec(spec)
+			}
 			vend.SysctlParams[param.Key] = OptSysctlVal(param.Operator, param.Key, vend.SysctlParams[param.Key], param.Value)
 		case INISectionVM:
 			vend.SysctlParams[param.Key] = OptVMVal(param.Key, param.Value)
@@ -207,7 +269,7 @@ func (vend INISettings) Optimise() (Note, error) {
 		case INISectionLimits:
 			vend.SysctlParams[param.Key] = OptLimitsVal(vend.SysctlParams[param.Key], param.Value)
 		case INISectionService:
-			vend.SysctlParams[param.Key] = OptServiceVal(param.Key, param.Value)
+			vend.SysctlParams[param.Key] = OptServiceVal(param.Key, vend.SysctlParams[param.Key], param.Value)
 		case INISectionLogin:
 			vend.SysctlParams[param.Key] = OptLoginVal(param.Value)
 		case INISectionMEM:
@@ -233,6 +295,7 @@ func (vend INISettings) Optimise() (Note, error) {
 			system.WarningLog("3rdPartyTuningOption %s: skip unknown section %s", vend.ConfFilePath, param.Section)
 			continue
 		}
+		vend.addMemSize(param.Section, param.Key)
 		// add values to parameter saved state file, if NOT in 'verify'
 		vend.addParamSavedStates(param.Key)
 	}
@@ -267,21 +330,14 @@ func (vend INISettings) Apply() error {
 		revertValues = true
 	}
 	// Parse the configuration file
-	ini, err := txtparser.ParseINIFile(vend.ConfFilePath, false)
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
 	if err != nil {
 		return err
 	}
 
+	blckApplied := false
 	//for key, value := range vend.SysctlParams {
-	for _, param := range ini.AllValues {
-		if len(vend.OverrideParams) != 0 && vend.ID == "1805750" {
-			// as note 1805750 does not set a limits domain, but
-			// the customer should be able to set the correct
-			// domain using an override file we need to rewrite
-			// param.Key and param.Value to get a correct behaviour
-			param.Key, param.Value = vend.handleID1805750(param.Key, param.Value)
-		}
-
+	for _, param := range vend.expandLimitsPlaceholders(ini.AllValues) {
 		switch param.Section {
 		case INISectionRpm, INISectionGrub, INISectionReminder:
 			// These parameters are only checked, but not applied.
@@ -313,6 +369,7 @@ func (vend INISettings) Apply() error {
 			errs = append(errs, SetVMVal(param.Key, vend.SysctlParams[param.Key]))
 		case INISectionBlock:
 			errs = append(errs, SetBlkVal(param.Key, vend.SysctlParams[param.Key], &blck, revertValues))
+			blckApplied = true
 		case INISectionLimits:
 			errs = append(errs, SetLimitsVal(param.Key, pvendID, vend.SysctlParams[param.Key], revertValues))
 		case INISectionService:
@@ -338,10 +395,132 @@ func (vend INISettings) Apply() error {
 			continue
 		}
 	}
+	if blckApplied {
+		// newly added block devices need the same tuning
+		updateBlockUdevRules(revertValues)
+	}
 	err = sap.PrintErrors(errs)
 	return err
 }
 
+// HasExpressions checks, if a parameter value of the resolved Note
+// definition contains an expression. As during the initialisation a
+// value from the override file replaces the value of the Note definition
+func (vend INISettings) HasExpressions() bool {
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
+	if err != nil {
+		return false
+	}
+	ow, err := txtparser.ParseINIFile(vend.overrideFile(), false)
+	if err != nil {
+		ow = nil
+	}
+	for _, param := range ini.AllValues {
+		if param.Section == INISectionReminder {
+			continue
+		}
+		value := param.Value
+		if ow != nil && ow.KeyValue[param.Section][param.Key].Value != "" {
+			value = ow.KeyValue[param.Section][param.Key].Value
+		}
+		if txtparser.IsExpression(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// addExpression stores the expression of a computed parameter value.
+// A value from an override file replaces the value of the Note definition
+// The parser separates the fields of sysctl values by tabs, so the
+// whitespace is normalised for displaying
+func (vend INISettings) addExpression(section, key, value string) {
+	if ovalue := vend.OverrideParams[key]; ovalue != "" && ovalue != "untouched" {
+		value = ovalue
+	}
+	if section != INISectionReminder && txtparser.IsExpression(value) {
+		vend.Expressions[key] = strings.Join(strings.Fields(value), " ")
+	}
+}
+
+// addValueSpec stores the value specification of a [sysctl] parameter
+// like 'vm.swappiness = 10..60' as '<operator> <value>'.
+// A value from an override file replaces the value of the Note definition
+func (vend INISettings) addValueSpec(section, key string, op txtparser.Operator, value string) {
+	if ovalue := vend.OverrideParams[key]; ovalue != "" && ovalue != "untouched" {
+		value = ovalue
+	}
+	if section == INISectionSysctl && !txtparser.IsExpression(value) && IsValueSpec(op, value) {
+		vend.ValueSpecs[key] = fmt.Sprintf("%s %s", op, strings.Join(strings.Fields(value), " "))
+	}
+}
+
+// splitValueSpec splits a stored value specification into operator and
+// value
+func splitValueSpec(spec string) (txtparser.Operator, string) {
+	fields := strings.SplitN(spec, " ", 2)
+	if len(fields) != 2 {
+		return txtparser.OperatorEqual, spec
+	}
+	return txtparser.Operator(fields[0]), fields[1]
+}
+
+// evalExpression computes a parameter value from an expression over system
+// facts like '${MEM_TOTAL_BYTES} / ${PAGE_SIZE}'. For the [limits] section
+// only the limit value (the 4th field) is computed.
+// '${CURRENT}' references the current value of the parameter
+func evalExpression(section, value, curval string) (string, error) {
+	prefix := ""
+	expr := value
+	if section == INISectionLimits {
+		lim := strings.Fields(value)
+		if len(lim) < 4 {
+			return value, fmt.Errorf("wrong limits entry '%s'", value)
+		}
+		prefix = strings.Join(lim[:3], " ") + " "
+		expr = strings.Join(lim[3:], " ")
+		curlim := strings.Fields(curval)
+		curval = ""
+		if len(curlim) == 4 {
+			curval = curlim[3]
+		}
+	}
+	exp, err := txtparser.ParseExpression(expr)
+	if err != nil {
+		return value, err
+	}
+	val, err := exp.Eval(func(name string) (int64, error) {
+		if name == "CURRENT" {
+			cur, err := strconv.ParseInt(strings.TrimSpace(curval), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("current value '%s' is not a single integer", curval)
+			}
+			return cur, nil
+		}
+		return system.GetSystemFact(name)
+	})
+	if err != nil {
+		return value, err
+	}
+	return prefix + strconv.FormatInt(val, 10), nil
+}
+
+// SysctlKeys returns the parameter keys of the [sysctl] section of the Note
+// definition file
+func (vend INISettings) SysctlKeys() []string {
+	keys := make([]string, 0)
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
+	if err != nil {
+		return keys
+	}
+	for _, param := range ini.AllValues {
+		if param.Section == INISectionSysctl {
+			keys = append(keys, param.Key)
+		}
+	}
+	return keys
+}
+
 // SetValuesToApply fills the data structure for applying the changes
 func (vend INISettings) SetValuesToApply(values []string) Note {
 	vend.ValuesToApply = make(map[string]string)
@@ -420,21 +599,6 @@ func (vend INISettings) addParamSavedStates(key string) {
 	}
 }
 
-// handleID1805750 handles the special case of SAP Note 1805750
-func (vend INISettings) handleID1805750(key, val string) (string, string) {
-	// as note 1805750 does not set a limits domain, but
-	// the customer should be able to set the correct
-	// domain using an override file we need to rewrite
-	// param.Key and param.Value to get a correct behaviour
-	for owkey, owval := range vend.OverrideParams {
-		if (isLimitSoft.MatchString(key) && isLimitSoft.MatchString(owkey)) || (isLimitHard.MatchString(key) && isLimitHard.MatchString(owkey)) {
-			key = owkey
-			val = owval
-		}
-	}
-	return key, val
-}
-
 // handleInitOverride handles the override parameter settings
 func (vend INISettings) handleInitOverride(key, val, section string, op txtparser.Operator, over *txtparser.INIFile) (string, string, txtparser.Operator) {
 	chkKey := key
@@ -444,20 +608,6 @@ func (vend INISettings) handleInitOverride(key, val, section string, op txtparse
 			chkKey = cKey
 		}
 	}
-	if vend.ID == "1805750" {
-		// as note 1805750 does not set a limits
-		// domain, but the customer should be able to
-		// set the correct domain using an override
-		// file we need to rewrite param.Key and
-		// param.Value to get a correct behaviour
-		for owkey, owparam := range over.KeyValue[section] {
-			if (isLimitSoft.MatchString(key) && isLimitSoft.MatchString(owkey)) || (isLimitHard.MatchString(key) && isLimitHard.MatchString(owkey)) {
-				chkKey = owkey
-				key = owkey
-				val = owparam.Value
-			}
-		}
-	}
 	if over.KeyValue[section][chkKey].Value == "" && section != INISectionPagecache && (over.KeyValue[section][chkKey].Key != "" || (section == INISectionLimits && over.KeyValue[section][chkKey].Key == "")) {
 		// disable parameter setting in override file
 		vend.OverrideParams[chkKey] = "untouched"
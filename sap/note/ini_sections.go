@@ -5,11 +5,11 @@ import (
 	"github.com/SUSE/saptune/sap/param"
 	"github.com/SUSE/saptune/system"
 	"github.com/SUSE/saptune/txtparser"
-	"io/ioutil"
 	"math"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -43,11 +43,20 @@ const (
 
 // OptSysctlVal optimises a sysctl parameter value
 // use exactly the value from the config file. No calculation any more
+// Only for value specifications like ranges, sets or field operators
+// (e.g. '>=4096 * >=16M') a matching value is computed
 func OptSysctlVal(operator txtparser.Operator, key, actval, cfgval string) string {
 	if actval == "" {
 		// sysctl parameter not available in system
 		return ""
 	}
+	if IsValueSpec(operator, cfgval) {
+		optval, err := OptValueSpec(operator, actval, cfgval)
+		if err != nil {
+			_ = system.ErrorLog("value specification '%s %s' of parameter '%s': %v", operator, cfgval, key, err)
+		}
+		return optval
+	}
 	allFieldsC := strings.Fields(actval)
 	allFieldsE := strings.Fields(cfgval)
 	allFieldsS := ""
@@ -91,6 +100,72 @@ func OptSysctlVal(operator txtparser.Operator, key, actval, cfgval string) strin
 
 var isSched = regexp.MustCompile(`^IO_SCHEDULER_\w+$`)
 var isNrreq = regexp.MustCompile(`^NRREQ_\w+$`)
+var isBlkTunable = regexp.MustCompile(`^(READ_AHEAD_KB|MAX_SECTORS_KB|RQ_AFFINITY|NOMERGES|ADD_RANDOM|IOSCHED\.\w+)_([^_]+)$`)
+
+// blkTunableOfKey returns the block device and the name of the queue tunable
+// of a [block] section key like 'READ_AHEAD_KB_sda' or
+// 'IOSCHED.READ_EXPIRE_sda' (tunable 'iosched/read_expire')
+func blkTunableOfKey(key string) (string, string) {
+	fields := isBlkTunable.FindStringSubmatch(key)
+	if len(fields) != 3 {
+		return "", ""
+	}
+	tunable := strings.ToLower(fields[1])
+	if strings.HasPrefix(tunable, "iosched.") {
+		tunable = path.Join("iosched", strings.TrimPrefix(tunable, "iosched."))
+	}
+	return fields[2], tunable
+}
+
+// blkDevOfKey returns the block device of a [block] section key like
+// 'IO_SCHEDULER_sda' or an empty string, if the key is not a block device key
+func blkDevOfKey(key string) string {
+	switch {
+	case isSched.MatchString(key):
+		return strings.TrimPrefix(key, "IO_SCHEDULER_")
+	case isNrreq.MatchString(key):
+		return strings.TrimPrefix(key, "NRREQ_")
+	case isBlkTunable.MatchString(key):
+		bdev, _ := blkTunableOfKey(key)
+		return bdev
+	}
+	return ""
+}
+
+// BlockDeviceKeys returns the [block] section keys of the block device
+// 'bdev' from the list of parameter keys
+func BlockDeviceKeys(params map[string]string, bdev string) []string {
+	keys := make([]string, 0)
+	for key := range params {
+		if blkDevOfKey(key) == bdev {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateBlockUdevRules writes the udev rules file for newly added block
+// devices after applying [block] section settings and removes the file,
+// if the block device settings of the last Note were reverted
+func updateBlockUdevRules(revert bool) {
+	if !revert {
+		if err := system.WriteBlockUdevRules(system.BlockUdevRulesFile); err != nil {
+			system.WarningLog("failed to write udev rules file '%s': %v", system.BlockUdevRulesFile, err)
+		}
+		return
+	}
+	params, _ := ListParams()
+	for _, param := range params {
+		if blkDevOfKey(param) != "" {
+			// block device settings of other Notes still applied
+			return
+		}
+	}
+	if err := system.RemoveBlockUdevRules(system.BlockUdevRulesFile); err != nil {
+		system.WarningLog("failed to remove udev rules file '%s': %v", system.BlockUdevRulesFile, err)
+	}
+}
 
 // GetBlkVal initialise the block device structure with the current
 // system settings
@@ -117,6 +192,12 @@ func GetBlkVal(key string, cur *param.BlockDeviceQueue) (string, string, error)
 		newReq = newNrR.(param.BlockDeviceNrRequests).NrRequests
 		retVal = strconv.Itoa(newReq[strings.TrimPrefix(key, "NRREQ_")])
 		cur.BlockDeviceNrRequests = newNrR.(param.BlockDeviceNrRequests)
+	case isBlkTunable.MatchString(key):
+		// read only the tunable of the key instead of inspecting all
+		// tunables of all block devices
+		bdev, tunable := blkTunableOfKey(key)
+		retVal = param.GetBlockTunable(bdev, tunable)
+		cur.BlockDeviceTunables.SetTunable(bdev, tunable, retVal)
 	}
 	return retVal, info, nil
 }
@@ -157,9 +238,23 @@ func OptBlkVal(key, cfgval string, cur *param.BlockDeviceQueue, bOK map[string][
 		if sval == "0" {
 			sval = "1024"
 		}
-		ival, _ := strconv.Atoi(sval)
-		opt, _ := cur.BlockDeviceNrRequests.Optimise(ival)
+		// set the value only for the block device of the key
+		// as different block devices may get different values
+		opt, err := cur.BlockDeviceNrRequests.Optimise(strings.TrimPrefix(key, "NRREQ_") + " " + sval)
+		if err != nil {
+			system.WarningLog("wrong value '%s' for '%s'", sval, key)
+		}
 		cur.BlockDeviceNrRequests = opt.(param.BlockDeviceNrRequests)
+	case isBlkTunable.MatchString(key):
+		bdev, tunable := blkTunableOfKey(key)
+		if _, err := strconv.Atoi(sval); err != nil {
+			system.WarningLog("wrong value '%s' for '%s', only numbers supported", sval, key)
+		}
+		opt, err := cur.BlockDeviceTunables.Optimise(bdev + " " + tunable + " " + sval)
+		if err != nil {
+			system.WarningLog("wrong value '%s' for '%s'", sval, key)
+		}
+		cur.BlockDeviceTunables = opt.(param.BlockDeviceTunables)
 	}
 	return sval, info
 }
@@ -186,6 +281,15 @@ func SetBlkVal(key, value string, cur *param.BlockDeviceQueue, revert bool) erro
 		if err != nil {
 			return err
 		}
+	case isBlkTunable.MatchString(key):
+		if revert {
+			bdev, tunable := blkTunableOfKey(key)
+			cur.BlockDeviceTunables.SetTunable(bdev, tunable, value)
+		}
+		err = cur.BlockDeviceTunables.Apply()
+		if err != nil {
+			return err
+		}
 	}
 	return err
 }
@@ -194,30 +298,46 @@ func SetBlkVal(key, value string, cur *param.BlockDeviceQueue, revert bool) erro
 
 // GetLimitsVal initialise the security limit structure with the current
 // system settings
-func GetLimitsVal(value string) (string, error) {
+// the effective limit is evaluated from /etc/security/limits.conf and all
+// drop-in files in /etc/security/limits.d in the same way pam_limits does.
+// Additional the file defining the effective limit is returned
+func GetLimitsVal(value string) (string, string) {
 	// Find out current limits
 	limit := value
+	file := ""
 	if limit != "" && limit != "NA" {
 		lim := strings.Fields(limit)
 		// dom=[0], type=[1], item=[2], value=[3]
 		// no check, that the syntax/order of the entry in the config file is
 		// a valid limits entry
 
-		// /etc/security/limits.d/saptune-<domain>-<item>-<type>.conf
-		dropInFile := fmt.Sprintf("/etc/security/limits.d/saptune-%s-%s-%s.conf", lim[0], lim[2], lim[1])
-		secLimits, err := system.ParseSecLimitsFile(dropInFile)
-		if err != nil {
-			//ANGI TODO - check, if other files in /etc/security/limits.d contain a value for the touple "<domain>-<item>-<type>"
-			return "", err
-		}
-		lim[3], _ = secLimits.Get(lim[0], lim[1], lim[2])
+		lim[3], file = system.EffectiveSecLimit(system.GetSecLimitsFiles(system.LimitsConfFile, system.LimitsDropInDir), lim[0], lim[1], lim[2])
 		if lim[3] == "" {
 			lim[3] = "NA"
 		}
 		// current limit found
 		limit = strings.Join(lim, " ")
+		checkLimitsShadow(lim, file)
+	}
+	return limit, file
+}
+
+// limitsDropInFile returns the name of the saptune limits drop-in file
+// /etc/security/limits.d/saptune-<domain>-<item>-<type>.conf
+func limitsDropInFile(lim []string) string {
+	return fmt.Sprintf("%s/saptune-%s-%s-%s.conf", system.LimitsDropInDir, lim[0], lim[2], lim[1])
+}
+
+// checkLimitsShadow warns, if the effective limit is not defined by the
+// saptune drop-in file of the limit, but by another file
+func checkLimitsShadow(lim []string, file string) {
+	dropInFile := limitsDropInFile(lim)
+	if file == "" || file == dropInFile {
+		return
+	}
+	if _, err := os.Stat(dropInFile); err == nil {
+		system.WarningLog("limit '%s %s %s' set by saptune in '%s' is shadowed by a setting in file '%s'", lim[0], lim[1], lim[2], dropInFile, file)
 	}
-	return limit, nil
 }
 
 // OptLimitsVal optimises the security limit structure with the settings
@@ -235,12 +355,11 @@ func SetLimitsVal(key, noteID, value string, revert bool) error {
 		lim := strings.Fields(limit)
 		// dom=[0], type=[1], item=[2], value=[3]
 
-		// /etc/security/limits.d/saptune-<domain>-<item>-<type>.conf
-		dropInFile := fmt.Sprintf("/etc/security/limits.d/saptune-%s-%s-%s.conf", lim[0], lim[2], lim[1])
+		dropInFile := limitsDropInFile(lim)
 
 		if revert && IsLastNoteOfParameter(key) {
 			// revert - remove limits drop-in file
-			os.Remove(dropInFile)
+			system.RemoveFile(dropInFile)
 			return nil
 		}
 
@@ -257,6 +376,10 @@ func SetLimitsVal(key, noteID, value string, revert bool) error {
 
 			//err = secLimits.Apply()
 			err = secLimits.ApplyDropIn(lim, noteID)
+			if err == nil {
+				_, file := system.EffectiveSecLimit(system.GetSecLimitsFiles(system.LimitsConfFile, system.LimitsDropInDir), lim[0], lim[1], lim[2])
+				checkLimitsShadow(lim, file)
+			}
 		}
 	}
 	return err
@@ -333,10 +456,17 @@ func GetCPUVal(key string) (string, string, string) {
 		// cpupower -c all frequency-info -p
 		//or better
 		// cat /sys/devices/system/cpu/cpu0/cpufreq/scaling_governor
-		newGov := system.GetGovernor()
-		for k, v := range newGov {
-			val = val + fmt.Sprintf("%s:%s ", k, v)
-		}
+		val = system.CPUMapToValues(system.GetGovernor())
+	case "energy_performance_preference":
+		// cat /sys/devices/system/cpu/cpu0/cpufreq/energy_performance_preference
+		val = system.CPUMapToValues(system.GetEPP())
+	case "scaling_min_freq":
+		val = system.CPUMapToValues(system.GetScalingFreq("min"))
+	case "scaling_max_freq":
+		val = system.CPUMapToValues(system.GetScalingFreq("max"))
+	case "no_turbo", "min_perf_pct":
+		// cat /sys/devices/system/cpu/intel_pstate/no_turbo
+		val = system.GetIntelPstate(key)
 	}
 	val = strings.TrimSpace(val)
 	if val == "all:none" {
@@ -347,16 +477,95 @@ func GetCPUVal(key string) (string, string, string) {
 
 // OptCPUVal optimises the cpu performance structure with the settings
 // from the configuration file
+// the value from the configuration file can be a single value, which is
+// used for all CPUs, or a list of '<cpu set>:<value>' entries separated by
+// spaces like '0-15:performance isolated:powersave node1:performance'.
+// A cpu set can be 'all', a cpu name like 'cpu2', a cpu list like '0-3,8',
+// 'isolated' or 'node<N>'. CPUs not covered by the list keep their current
+// value
 func OptCPUVal(key, actval, cfgval string) string {
-	//ANGI TODO - check cfgval is not a single value like 'performance' but
-	// cpu0:performance cpu2:powersave
+	return optCPUVal(key, actval, cfgval, system.GetCPUList(), system.ResolveCPUSet)
+}
+
+// optCPUVal optimises the cpu performance structure for the given list
+// of CPUs. 'resolve' returns the CPUs of a cpu set
+func optCPUVal(key, actval, cfgval string, cpus []string, resolve func(string) ([]string, error)) string {
 	sval := strings.ToLower(cfgval)
 	rval := ""
-	val := "0"
 	switch key {
 	case "force_latency":
 		rval = sval
-	case "energy_perf_bias":
+	case "no_turbo", "min_perf_pct":
+		rval = optIntelPstateVal(key, sval)
+	case "energy_perf_bias", "governor", "energy_performance_preference", "scaling_min_freq", "scaling_max_freq":
+		if !strings.Contains(sval, ":") {
+			val := cpuSettingVal(key, sval)
+			for _, entry := range strings.Fields(actval) {
+				fields := strings.Split(entry, ":")
+				rval = rval + fmt.Sprintf("%s:%s ", fields[0], val)
+			}
+			break
+		}
+		// per cpu settings
+		cpuVals := cpuValuesToMap(actval, cpus)
+		for _, entry := range strings.Fields(sval) {
+			fields := strings.SplitN(entry, ":", 2)
+			if len(fields) != 2 || fields[0] == "" {
+				system.WarningLog("wrong cpu setting '%s' for '%s', skipping", entry, key)
+				continue
+			}
+			set, err := resolve(fields[0])
+			if err != nil {
+				system.WarningLog("wrong cpu set '%s' for '%s', skipping: %v", fields[0], key, err)
+				continue
+			}
+			val := cpuSettingVal(key, fields[1])
+			for _, cpu := range set {
+				if _, ok := cpuVals[cpu]; !ok {
+					system.WarningLog("cpu '%s' of cpu set '%s' not available on the system, skipping", cpu, fields[0])
+					continue
+				}
+				cpuVals[cpu] = val
+			}
+		}
+		rval = system.CPUMapToValues(cpuVals)
+	}
+	return strings.TrimSpace(rval)
+}
+
+// isPerCPUKey returns true, if the [cpu] setting has a value per cpu
+func isPerCPUKey(key string) bool {
+	switch key {
+	case "energy_perf_bias", "governor", "energy_performance_preference", "scaling_min_freq", "scaling_max_freq":
+		return true
+	}
+	return false
+}
+
+// optIntelPstateVal checks the values of the global intel_pstate settings
+func optIntelPstateVal(key, sval string) string {
+	ival, err := strconv.Atoi(sval)
+	switch {
+	case key == "no_turbo" && (err != nil || (ival != 0 && ival != 1)):
+		system.WarningLog("wrong selection '%s' for no_turbo. Now set to '0' to enable turbo", sval)
+		sval = "0"
+	case key == "min_perf_pct" && (err != nil || ival < 0 || ival > 100):
+		system.WarningLog("wrong selection '%s' for min_perf_pct. Only values between 0 and 100 are supported. Now set to '100'", sval)
+		sval = "100"
+	}
+	return sval
+}
+
+// cpuSettingVal returns the value to set for a [cpu] setting
+func cpuSettingVal(key, sval string) string {
+	val := sval
+	if key == "scaling_min_freq" || key == "scaling_max_freq" {
+		// frequency in kHz
+		if _, err := strconv.ParseUint(sval, 10, 64); err != nil {
+			system.WarningLog("wrong selection '%s' for %s. Only frequencies in kHz are supported", sval, key)
+		}
+	}
+	if key == "energy_perf_bias" {
 		//performance - 0, normal - 6, powersave - 15
 		switch sval {
 		case "performance":
@@ -369,21 +578,56 @@ func OptCPUVal(key, actval, cfgval string) string {
 			system.WarningLog("wrong selection for energy_perf_bias. Now set to 'performance'")
 			val = "0"
 		}
-		//ANGI TODO - if actval 'all:6', but cfgval 'cpu0:performance cpu1:normal cpu2:powersave'
-		// - does not work now - check lenght of both?
-		// same for governor
-		for _, entry := range strings.Fields(actval) {
-			fields := strings.Split(entry, ":")
-			rval = rval + fmt.Sprintf("%s:%s ", fields[0], val)
+	}
+	return val
+}
+
+// cpuValuesToMap converts per cpu values like 'cpu0:0 cpu1:6' or 'all:6'
+// into a map of cpu name and value. 'all' is expanded to the given CPUs
+func cpuValuesToMap(value string, cpus []string) map[string]string {
+	cpuVals := make(map[string]string)
+	for _, entry := range strings.Fields(value) {
+		fields := strings.SplitN(entry, ":", 2)
+		if len(fields) != 2 {
+			continue
 		}
-	case "governor":
-		val = sval
-		for _, entry := range strings.Fields(actval) {
-			fields := strings.Split(entry, ":")
-			rval = rval + fmt.Sprintf("%s:%s ", fields[0], val)
+		if fields[0] == "all" {
+			for _, cpu := range cpus {
+				cpuVals[cpu] = fields[1]
+			}
+			continue
 		}
+		cpuVals[fields[0]] = fields[1]
 	}
-	return strings.TrimSpace(rval)
+	return cpuVals
+}
+
+// CmpCPUValues compares per cpu values of the [cpu] section, even if the
+// sets of CPUs listed in the values differ (e.g. 'all:0' and 'cpu0:0 cpu1:0')
+func CmpCPUValues(actval, expval string) bool {
+	if actval == expval {
+		return true
+	}
+	if !strings.Contains(actval, ":") || !strings.Contains(expval, ":") {
+		return false
+	}
+	// collect all CPUs named in one of the values
+	cpus := []string{}
+	for cpu := range cpuValuesToMap(actval+" "+expval, []string{}) {
+		cpus = append(cpus, cpu)
+	}
+	if len(cpus) == 0 {
+		// both values are 'all:<value>', but differ
+		return false
+	}
+	actMap := cpuValuesToMap(actval, cpus)
+	expMap := cpuValuesToMap(expval, cpus)
+	for _, cpu := range cpus {
+		if actMap[cpu] != expMap[cpu] {
+			return false
+		}
+	}
+	return true
 }
 
 // SetCPUVal applies the settings to the system
@@ -407,6 +651,14 @@ func SetCPUVal(key, value, noteID, savedStates, oval, info string, revert bool)
 		err = system.SetPerfBias(value)
 	case "governor":
 		err = system.SetGovernor(value, info)
+	case "energy_performance_preference":
+		err = system.SetEPP(value)
+	case "scaling_min_freq":
+		err = system.SetScalingFreq("min", value)
+	case "scaling_max_freq":
+		err = system.SetScalingFreq("max", value)
+	case "no_turbo", "min_perf_pct":
+		err = system.SetIntelPstate(key, value)
 	}
 
 	return err
@@ -488,10 +740,18 @@ func SetMemVal(key, value string) error {
 // section [rpm]
 
 // GetRpmVal initialise the rpm structure with the current system settings
-func GetRpmVal(key string) string {
+// returns the installed version used for the comparison and, if more than
+// one version of the package is installed, the list of all installed versions
+func GetRpmVal(key string) (string, string) {
+	info := ""
 	keyFields := strings.Split(key, ":")
-	instvers := system.GetRpmVers(keyFields[1])
-	return instvers
+	allvers := system.GetAllRpmVers(keyFields[1])
+	instvers, multi := system.SelectRpmVers(keyFields[1], allvers, system.GetRunningKernelVers())
+	if multi {
+		info = strings.Join(allvers, " ")
+		system.InfoLog("more than one version of package '%s' installed (%s), using '%s' for comparison", keyFields[1], info, instvers)
+	}
+	return instvers, info
 }
 
 // OptRpmVal returns the value from the configuration file
@@ -531,6 +791,9 @@ func SetGrubVal(value string) error {
 
 // GetServiceVal initialise the systemd service structure with the current
 // system settings
+// returns the active state ('start' or 'stop') and the enablement state
+// ('enable', 'disable', 'mask' or the unit file state reported by systemd,
+// if it is none of them) like 'start, enable'
 func GetServiceVal(key string) string {
 	var val string
 	service := system.GetServiceName(key)
@@ -542,28 +805,115 @@ func GetServiceVal(key string) string {
 	} else {
 		val = "stop"
 	}
+	if enabled := getServiceEnablement(service); enabled != "" {
+		val = val + ", " + enabled
+	}
 	return val
 }
 
+// getServiceEnablement maps the unit file state of a service to the
+// enablement states used in the [service] section
+func getServiceEnablement(service string) string {
+	state := system.SystemctlIsEnabled(service)
+	switch state {
+	case "enabled", "enabled-runtime":
+		return "enable"
+	case "disabled":
+		return "disable"
+	case "masked", "masked-runtime":
+		return "mask"
+	}
+	return state
+}
+
+// splitServiceStates splits a value of the [service] section into the
+// active state and the enablement state
+func splitServiceStates(value string) (string, string) {
+	active := ""
+	enabled := ""
+	for _, state := range strings.Split(value, ",") {
+		state = strings.ToLower(strings.TrimSpace(state))
+		switch state {
+		case "":
+			continue
+		case "start", "stop":
+			active = state
+		default:
+			enabled = state
+		}
+	}
+	return active, enabled
+}
+
+// joinServiceStates builds a value of the [service] section from the
+// active state and the enablement state
+func joinServiceStates(active, enabled string) string {
+	if active != "" && enabled != "" {
+		return active + ", " + enabled
+	}
+	return active + enabled
+}
+
 // OptServiceVal optimises the systemd service structure with the settings
 // from the configuration file
-func OptServiceVal(key, cfgval string) string {
-	sval := strings.ToLower(cfgval)
+// valid states are 'start' or 'stop' for the active state and 'enable',
+// 'disable' or 'mask' for the enablement state. Both can be combined as
+// comma separated list. A state not defined in the configuration file
+// remains as found on the system
+func OptServiceVal(key, actval, cfgval string) string {
 	service := system.GetServiceName(key)
 	if service == "" {
 		return "NA"
 	}
-	if service == "uuidd.socket" && sval != "start" {
+	active := ""
+	enabled := ""
+	for _, state := range strings.Split(cfgval, ",") {
+		sval := strings.ToLower(strings.TrimSpace(state))
+		switch sval {
+		case "start", "stop":
+			if active != "" && active != sval {
+				system.WarningLog("contradictory selection '%s' for '%s'. Using '%s'\n", cfgval, service, sval)
+			}
+			active = sval
+		case "enable", "disable", "mask":
+			if enabled != "" && enabled != sval {
+				system.WarningLog("contradictory selection '%s' for '%s'. Using '%s'\n", cfgval, service, sval)
+			}
+			enabled = sval
+		default:
+			system.WarningLog("wrong selection '%s' for '%s'. Now set to 'start' to start the service\n", sval, service)
+			active = "start"
+		}
+	}
+	if service == "uuidd.socket" {
 		// for uuidd.socket we only support 'start' (bsc#1100107)
-		system.WarningLog("wrong selection '%s' for '%s'. Now set to 'start' to start the service\n", sval, service)
-		sval = "start"
+		// and 'enable'
+		if active != "" && active != "start" {
+			system.WarningLog("wrong selection '%s' for '%s'. Now set to 'start' to start the service\n", active, service)
+			active = "start"
+		}
+		if enabled != "" && enabled != "enable" {
+			system.WarningLog("wrong selection '%s' for '%s'. Now set to 'enable' to enable the service\n", enabled, service)
+			enabled = "enable"
+		}
 	}
-
-	if sval != "start" && sval != "stop" {
-		system.WarningLog("wrong selection '%s' for '%s'. Now set to 'start' to start the service\n", sval, service)
-		sval = "start"
+	if enabled == "mask" && active == "start" {
+		system.WarningLog("a masked service can not be started. Now set to 'stop' for '%s'\n", service)
+		active = "stop"
 	}
-	return sval
+	// use the current system states for states not defined in the
+	// configuration file
+	actActive, actEnabled := splitServiceStates(actval)
+	if active == "" {
+		active = actActive
+	}
+	if enabled == "" {
+		enabled = actEnabled
+		if enabled == "mask" && active == "start" {
+			system.WarningLog("service '%s' is masked and can not be started\n", service)
+		}
+	}
+	return joinServiceStates(active, enabled)
 }
 
 // SetServiceVal applies the settings to the system
@@ -573,19 +923,43 @@ func SetServiceVal(key, value string) error {
 	if service == "" {
 		return nil
 	}
-	if value == "start" && !system.SystemctlIsRunning(service) {
-		err = system.SystemctlStart(service)
+	active, enabled := splitServiceStates(value)
+	if service == "uuidd.socket" {
+		// uuidd.socket is essential, never stop or disable it
+		active = "start"
+		if enabled != "" {
+			enabled = "enable"
+		}
 	}
-	if value == "stop" {
-		if service == "uuidd.socket" {
-			if !system.SystemctlIsRunning(service) {
-				err = system.SystemctlStart(service)
-			}
-		} else {
-			if system.SystemctlIsRunning(service) {
-				err = system.SystemctlStop(service)
-			}
+	curEnabled := getServiceEnablement(service)
+	if curEnabled == "mask" && (enabled == "enable" || enabled == "disable") {
+		if err = system.SystemctlUnmask(service); err != nil {
+			return err
+		}
+		curEnabled = getServiceEnablement(service)
+	}
+	switch enabled {
+	case "enable":
+		if curEnabled != "enable" {
+			err = system.SystemctlEnable(service)
+		}
+	case "disable":
+		if curEnabled == "enable" {
+			err = system.SystemctlDisable(service)
 		}
+	case "mask":
+		if curEnabled != "mask" {
+			err = system.SystemctlMask(service)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if active == "start" && !system.SystemctlIsRunning(service) {
+		err = system.SystemctlStart(service)
+	}
+	if active == "stop" && system.SystemctlIsRunning(service) {
+		err = system.SystemctlStop(service)
 	}
 	return err
 }
@@ -599,7 +973,7 @@ func GetLoginVal(key string) (string, error) {
 	var utmPat = regexp.MustCompile(`UserTasksMax=(.*)`)
 	switch key {
 	case "UserTasksMax":
-		logindContent, err := ioutil.ReadFile(path.Join(LogindConfDir, LogindSAPConfFile))
+		logindContent, err := system.ReadFile(path.Join(LogindConfDir, LogindSAPConfFile))
 		if err != nil && !os.IsNotExist(err) {
 			return "", err
 		}
@@ -624,7 +998,7 @@ func SetLoginVal(key, value string, revert bool) error {
 	case "UserTasksMax":
 		if revert && IsLastNoteOfParameter(key) {
 			// revert - remove logind drop-in file
-			os.Remove(path.Join(LogindConfDir, LogindSAPConfFile))
+			system.RemoveFile(path.Join(LogindConfDir, LogindSAPConfFile))
 			// restart systemd-logind.service
 			err := system.SystemctlRestart("systemd-logind.service")
 			return err
@@ -636,10 +1010,10 @@ func SetLoginVal(key, value string, revert bool) error {
 			// LogindSAPConfContent is the verbatim content of
 			// SAP-specific logind settings file.
 			LogindSAPConfContent := fmt.Sprintf("[Login]\nUserTasksMax=%s\n", value)
-			if err := os.MkdirAll(LogindConfDir, 0755); err != nil {
+			if err := system.MkdirAll(LogindConfDir, 0755); err != nil {
 				return err
 			}
-			if err := ioutil.WriteFile(path.Join(LogindConfDir, LogindSAPConfFile), []byte(LogindSAPConfContent), 0644); err != nil {
+			if err := system.WriteFile(path.Join(LogindConfDir, LogindSAPConfFile), []byte(LogindSAPConfContent), 0644); err != nil {
 				return err
 			}
 			// restart systemd-logind.service
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -77,6 +78,26 @@ func TestOptSysctlVal(t *testing.T) {
 	if val != "120" {
 		t.Fatal(val)
 	}
+
+	// value specifications compute a matching value
+	op = txtparser.Operator("=")
+	val = OptSysctlVal(op, "TestParam", "120", "10..60")
+	if val != "60" {
+		t.Fatal(val)
+	}
+	val = OptSysctlVal(op, "TestParam", "4096	87380	6291456", ">=4096 * >=16M")
+	if val != "4096	87380	16777216" {
+		t.Fatal(val)
+	}
+	op = txtparser.Operator("!=")
+	val = OptSysctlVal(op, "TestParam", "120", "0")
+	if val != "120" {
+		t.Fatal(val)
+	}
+	val = OptSysctlVal(op, "TestParam", "0", "0")
+	if val != "0" {
+		t.Fatal(val)
+	}
 }
 
 func TestGetBlkVal(t *testing.T) {
@@ -185,7 +206,82 @@ func TestSetBlkVal(t *testing.T) {
 	err = SetBlkVal("IO_SCHEDULER_sda", oval, &tblck, true)
 }
 
-//GetLimitsVal
+func TestBlkTunableOfKey(t *testing.T) {
+	bdev, tunable := blkTunableOfKey("READ_AHEAD_KB_sda")
+	if bdev != "sda" || tunable != "read_ahead_kb" {
+		t.Fatal(bdev, tunable)
+	}
+	bdev, tunable = blkTunableOfKey("IOSCHED.READ_EXPIRE_nvme0n1")
+	if bdev != "nvme0n1" || tunable != "iosched/read_expire" {
+		t.Fatal(bdev, tunable)
+	}
+	bdev, tunable = blkTunableOfKey("NRREQ_sda")
+	if bdev != "" || tunable != "" {
+		t.Fatal(bdev, tunable)
+	}
+}
+
+func TestBlockDeviceKeys(t *testing.T) {
+	params := map[string]string{"IO_SCHEDULER_sda": "none", "NRREQ_sda": "1024", "READ_AHEAD_KB_sda": "4096", "IOSCHED.FIFO_BATCH_sda": "16", "IO_SCHEDULER_sdb": "none", "vm.swappiness": "10", "LIMIT_@sapsys_soft_nofile": "@sapsys soft nofile 65536"}
+	keys := BlockDeviceKeys(params, "sda")
+	if strings.Join(keys, " ") != "IOSCHED.FIFO_BATCH_sda IO_SCHEDULER_sda NRREQ_sda READ_AHEAD_KB_sda" {
+		t.Fatal(keys)
+	}
+	keys = BlockDeviceKeys(params, "sdc")
+	if len(keys) != 0 {
+		t.Fatal(keys)
+	}
+	if blkDevOfKey("vm.swappiness") != "" || blkDevOfKey("NRREQ_vdb") != "vdb" {
+		t.Fatal(blkDevOfKey("NRREQ_vdb"))
+	}
+}
+
+func TestBlkTunableVal(t *testing.T) {
+	blckOK := make(map[string][]string)
+	tblck := param.BlockDeviceQueue{BlockDeviceTunables: param.BlockDeviceTunables{Tunables: make(map[string]map[string]string)}}
+	val, info, err := GetBlkVal("RQ_AFFINITY_notavail", &tblck)
+	if err != nil || val != "NA" || info != "" {
+		t.Fatal(val, info, err)
+	}
+	val, info = OptBlkVal("RQ_AFFINITY_notavail", "2", &tblck, blckOK)
+	if val != "2" || tblck.Tunables["notavail"]["rq_affinity"] != "2" {
+		t.Fatal(val, tblck)
+	}
+	// apply skips the not available block device
+	if err = SetBlkVal("RQ_AFFINITY_notavail", "notUsed", &tblck, false); err != nil {
+		t.Fatal(err)
+	}
+	// revert - value will be used to change map before applying
+	if err = SetBlkVal("RQ_AFFINITY_notavail", "NA", &tblck, true); err != nil {
+		t.Fatal(err)
+	}
+	if tblck.Tunables["notavail"]["rq_affinity"] != "NA" {
+		t.Fatal(tblck)
+	}
+}
+
+func TestGetLimitsVal(t *testing.T) {
+	val, file := GetLimitsVal("")
+	if val != "" || file != "" {
+		t.Fatal(val, file)
+	}
+	val, file = GetLimitsVal("NA")
+	if val != "NA" || file != "" {
+		t.Fatal(val, file)
+	}
+	val, file = GetLimitsVal("@sdba soft nofile 32800")
+	if !strings.HasPrefix(val, "@sdba soft nofile ") {
+		t.Fatal(val, file)
+	}
+}
+
+func TestLimitsDropInFile(t *testing.T) {
+	val := limitsDropInFile([]string{"@sapsys", "soft", "nofile", "65536"})
+	if val != "/etc/security/limits.d/saptune-@sapsys-nofile-soft.conf" {
+		t.Fatal(val)
+	}
+}
+
 func TestOptLimitsVal(t *testing.T) {
 	val := OptLimitsVal("@sdba soft nofile NA", "@sdba soft nofile 32800")
 	if val != "@sdba soft nofile 32800" {
@@ -289,6 +385,15 @@ func TestGetCPUVal(t *testing.T) {
 	if val != "all:none" && val != "" {
 		t.Logf("governor supported: '%s'\n", val)
 	}
+	for _, key := range []string{"energy_performance_preference", "scaling_min_freq", "scaling_max_freq", "no_turbo", "min_perf_pct"} {
+		val, _, info := GetCPUVal(key)
+		if val == "all:none" && info != "notSupported" {
+			t.Fatal(key, info)
+		}
+		if val != "all:none" && val != "" {
+			t.Logf("%s supported: '%s'\n", key, val)
+		}
+	}
 }
 
 func TestOptCPUVal(t *testing.T) {
@@ -318,35 +423,143 @@ func TestOptCPUVal(t *testing.T) {
 		t.Fatal(val)
 	}
 
-	/* future feature
-	val = OptCPUVal("energy_perf_bias", "cpu0:6 cpu1:6 cpu2:6", "cpu0:performance cpu1:normal cpu2:powersave")
-	if val != "cpu0:0 cpu1:6 cpu2:15" {
+	val = OptCPUVal("governor", "all:powersave", "performance")
+	if val != "all:performance" {
 		t.Fatal(val)
 	}
-	val = OptCPUVal("energy_perf_bias", "all:6", "cpu0:performance cpu1:normal cpu2:powersave")
-	if val != "cpu0:performance cpu1:normal cpu2:powersave" {
+	val = OptCPUVal("governor", "cpu0:powersave cpu1:performance cpu2:powersave", "performance")
+	if val != "cpu0:performance cpu1:performance cpu2:performance" {
 		t.Fatal(val)
 	}
-	*/
+}
 
-	val = OptCPUVal("governor", "all:powersave", "performance")
+func TestOptCPUValPerCPU(t *testing.T) {
+	cpus := []string{"cpu0", "cpu1", "cpu2", "cpu3"}
+	resolve := func(set string) ([]string, error) {
+		switch set {
+		case "all":
+			return cpus, nil
+		case "isolated":
+			return []string{"cpu3"}, nil
+		case "node1":
+			return []string{"cpu2", "cpu3"}, nil
+		}
+		return system.ParseCPUList(set)
+	}
+	val := optCPUVal("energy_perf_bias", "cpu0:6 cpu1:6 cpu2:6 cpu3:6", "cpu0:performance cpu1:normal 2-3:powersave", cpus, resolve)
+	if val != "cpu0:0 cpu1:6 cpu2:15 cpu3:15" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("energy_perf_bias", "all:6", "0-1:performance isolated:powersave", cpus, resolve)
+	if val != "cpu0:0 cpu1:0 cpu2:6 cpu3:15" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("energy_perf_bias", "all:6", "all:powersave 0,2:performance", cpus, resolve)
+	if val != "cpu0:0 cpu1:15 cpu2:0 cpu3:15" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("energy_perf_bias", "cpu0:6 cpu1:0 cpu2:6 cpu3:0", "all:performance", cpus, resolve)
+	if val != "all:0" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("governor", "cpu0:powersave cpu1:performance cpu2:powersave cpu3:powersave", "cpu0:performance cpu1:powersave cpu2:performance", cpus, resolve)
+	if val != "cpu0:performance cpu1:powersave cpu2:performance cpu3:powersave" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("governor", "all:powersave", "node1:performance", cpus, resolve)
+	if val != "cpu0:powersave cpu1:powersave cpu2:performance cpu3:performance" {
+		t.Fatal(val)
+	}
+	// cpus not available and wrong cpu sets are skipped
+	val = optCPUVal("governor", "all:powersave", "2-5:performance x-y:performance", cpus, resolve)
+	if val != "cpu0:powersave cpu1:powersave cpu2:performance cpu3:performance" {
+		t.Fatal(val)
+	}
+}
+
+func TestOptCPUValPowerSettings(t *testing.T) {
+	cpus := []string{"cpu0", "cpu1"}
+	val := optCPUVal("energy_performance_preference", "all:balance_performance", "performance", cpus, system.ResolveCPUSet)
 	if val != "all:performance" {
 		t.Fatal(val)
 	}
-	val = OptCPUVal("governor", "cpu0:powersave cpu1:performance cpu2:powersave", "performance")
-	if val != "cpu0:performance cpu1:performance cpu2:performance" {
+	val = optCPUVal("energy_performance_preference", "all:balance_performance", "cpu1:performance", cpus, system.ResolveCPUSet)
+	if val != "cpu0:balance_performance cpu1:performance" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("scaling_min_freq", "cpu0:800000 cpu1:1200000", "2000000", cpus, system.ResolveCPUSet)
+	if val != "cpu0:2000000 cpu1:2000000" {
 		t.Fatal(val)
 	}
-	/* future feature
-	val = OptCPUVal("governor", "cpu0:powersave cpu1:performance cpu2:powersave", "cpu0:performance cpu1:powersave cpu2:performance")
-	if val != "cpu0:performance cpu1:powersave cpu2:performance" {
+	val = optCPUVal("scaling_max_freq", "all:3000000", "0:2000000", cpus, system.ResolveCPUSet)
+	if val != "cpu0:2000000 cpu1:3000000" {
 		t.Fatal(val)
 	}
-	val = OptCPUVal("energy_perf_bias", "all:powersave", "cpu0:performance cpu1:powersave cpu2:performance")
-	if val != "cpu0:performance cpu1:powersave cpu2:performance" {
+	val = optCPUVal("no_turbo", "0", "1", cpus, system.ResolveCPUSet)
+	if val != "1" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("no_turbo", "0", "2", cpus, system.ResolveCPUSet)
+	if val != "0" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("min_perf_pct", "20", "100", cpus, system.ResolveCPUSet)
+	if val != "100" {
+		t.Fatal(val)
+	}
+	val = optCPUVal("min_perf_pct", "20", "120", cpus, system.ResolveCPUSet)
+	if val != "100" {
+		t.Fatal(val)
+	}
+	if !isPerCPUKey("scaling_max_freq") || isPerCPUKey("no_turbo") || isPerCPUKey("force_latency") {
+		t.Fatal("wrong per cpu key detection")
+	}
+}
+
+func TestSetCPUValPowerSettings(t *testing.T) {
+	for _, key := range []string{"energy_performance_preference", "scaling_min_freq", "scaling_max_freq", "no_turbo", "min_perf_pct"} {
+		if err := SetCPUVal(key, "all:none", "", "", "", "notSupported", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCPUValuesMap(t *testing.T) {
+	cpuVals := cpuValuesToMap("all:6", []string{"cpu0", "cpu1"})
+	if len(cpuVals) != 2 || cpuVals["cpu0"] != "6" || cpuVals["cpu1"] != "6" {
+		t.Fatal(cpuVals)
+	}
+	if val := system.CPUMapToValues(cpuVals); val != "all:6" {
+		t.Fatal(val)
+	}
+	cpuVals = cpuValuesToMap("cpu10:0 cpu2:6 cpu1:6", []string{})
+	if val := system.CPUMapToValues(cpuVals); val != "cpu1:6 cpu2:6 cpu10:0" {
 		t.Fatal(val)
 	}
-	*/
+}
+
+func TestCmpCPUValues(t *testing.T) {
+	if !CmpCPUValues("all:0", "all:0") {
+		t.Fatal("equal values reported as different")
+	}
+	if CmpCPUValues("all:0", "all:6") {
+		t.Fatal("different values reported as equal")
+	}
+	if !CmpCPUValues("all:0", "cpu0:0 cpu1:0 cpu2:0") {
+		t.Fatal("equal values reported as different")
+	}
+	if !CmpCPUValues("cpu0:0 cpu1:0", "all:0") {
+		t.Fatal("equal values reported as different")
+	}
+	if CmpCPUValues("all:0", "cpu0:0 cpu1:6") {
+		t.Fatal("different values reported as equal")
+	}
+	if CmpCPUValues("cpu0:0 cpu1:6", "cpu0:0 cpu1:0") {
+		t.Fatal("different values reported as equal")
+	}
+	if CmpCPUValues("all:none", "all:0") {
+		t.Fatal("different values reported as equal")
+	}
 }
 
 //SetCPUVal
@@ -428,10 +641,13 @@ func TestOptMemVal(t *testing.T) {
 //SetMemVal
 
 func TestGetRpmVal(t *testing.T) {
-	val := GetRpmVal("rpm:glibc")
+	val, info := GetRpmVal("rpm:glibc")
 	if val == "" {
 		t.Log("rpm 'glibc' not found")
 	}
+	if info != "" {
+		t.Logf("more than one version of rpm 'glibc' installed - '%s'", info)
+	}
 }
 
 func TestOptRpmVal(t *testing.T) {
@@ -479,40 +695,95 @@ func TestGetServiceVal(t *testing.T) {
 		t.Fatal(val)
 	}
 	val = GetServiceVal("uuidd.socket")
-	if val != "start" && val != "stop" && val != "NA" {
+	if val != "NA" {
+		active, _ := splitServiceStates(val)
+		if active != "start" && active != "stop" {
+			t.Fatal(val)
+		}
+	}
+}
+
+func TestSplitServiceStates(t *testing.T) {
+	active, enabled := splitServiceStates("start, enable")
+	if active != "start" || enabled != "enable" {
+		t.Fatal(active, enabled)
+	}
+	active, enabled = splitServiceStates(" Mask,stop")
+	if active != "stop" || enabled != "mask" {
+		t.Fatal(active, enabled)
+	}
+	active, enabled = splitServiceStates("stop")
+	if active != "stop" || enabled != "" {
+		t.Fatal(active, enabled)
+	}
+	active, enabled = splitServiceStates("static")
+	if active != "" || enabled != "static" {
+		t.Fatal(active, enabled)
+	}
+	if val := joinServiceStates("start", "enable"); val != "start, enable" {
+		t.Fatal(val)
+	}
+	if val := joinServiceStates("", "disable"); val != "disable" {
+		t.Fatal(val)
+	}
+	if val := joinServiceStates("stop", ""); val != "stop" {
 		t.Fatal(val)
 	}
 }
 
 func TestOptServiceVal(t *testing.T) {
-	val := OptServiceVal("UnkownService", "start")
+	val := OptServiceVal("UnkownService", "NA", "start")
 	if val != "NA" {
 		t.Fatal(val)
 	}
-	val = OptServiceVal("uuidd.socket", "start")
+	val = OptServiceVal("uuidd.socket", "stop", "start")
 	if val != "start" && val != "NA" {
 		t.Fatal(val)
 	}
-	val = OptServiceVal("uuidd.socket", "stop")
+	val = OptServiceVal("uuidd.socket", "start", "stop")
 	if val != "start" && val != "NA" {
 		t.Fatal(val)
 	}
-	val = OptServiceVal("uuidd.socket", "unknown")
+	val = OptServiceVal("uuidd.socket", "stop", "unknown")
 	if val != "start" && val != "NA" {
 		t.Fatal(val)
 	}
-	val = OptServiceVal("sysstat", "start")
+	val = OptServiceVal("uuidd.socket", "start, enable", "start, mask")
+	if val != "start, enable" && val != "NA" {
+		t.Fatal(val)
+	}
+	val = OptServiceVal("sysstat", "stop", "start")
 	if val != "start" && val != "NA" {
 		t.Fatal(val)
 	}
-	val = OptServiceVal("sysstat.service", "stop")
+	val = OptServiceVal("sysstat.service", "start", "stop")
 	if val != "stop" && val != "NA" {
 		t.Fatal(val)
 	}
-	val = OptServiceVal("sysstat", "unknown")
+	val = OptServiceVal("sysstat", "stop", "unknown")
 	if val != "start" && val != "NA" {
 		t.Fatal(val)
 	}
+	val = OptServiceVal("sysstat", "stop, disable", "start")
+	if val != "start, disable" && val != "NA" {
+		t.Fatal(val)
+	}
+	val = OptServiceVal("sysstat", "stop, disable", "enable")
+	if val != "stop, enable" && val != "NA" {
+		t.Fatal(val)
+	}
+	val = OptServiceVal("sysstat", "start, enable", "Enable, Start")
+	if val != "start, enable" && val != "NA" {
+		t.Fatal(val)
+	}
+	val = OptServiceVal("sysstat", "start, enable", "start, mask")
+	if val != "stop, mask" && val != "NA" {
+		t.Fatal(val)
+	}
+	val = OptServiceVal("sysstat", "start, static", "stop")
+	if val != "stop, static" && val != "NA" {
+		t.Fatal(val)
+	}
 }
 
 func TestSetServiceVal(t *testing.T) {
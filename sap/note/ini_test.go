@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/SUSE/saptune/system"
 	"github.com/SUSE/saptune/txtparser"
+	"io/ioutil"
 	"os"
 	"path"
 	"runtime"
@@ -84,7 +85,7 @@ func TestVendorSettings(t *testing.T) {
 	if ini.Name() == "" {
 		t.Fatal(ini.Name())
 	}
-	if ini.Name() != fmt.Sprintf("ini_test: SAP Note file for ini_test\n\t\t\tVersion 2 from 02.11.2017 ") {
+	if ini.Name() != fmt.Sprintf("ini_test: SAP Note file for ini_test\n\t\t\tVersion 2 from 02.11.2017") {
 		t.Fatal(ini.Name())
 	}
 
@@ -129,6 +130,208 @@ func TestVendorSettings(t *testing.T) {
 	}
 }
 
+func TestComputedValues(t *testing.T) {
+	cleanUp()
+	iniPath := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/ini_expr_test.ini")
+	ini := INISettings{ConfFilePath: iniPath, ID: "7654321"}
+	ini = ini.SetValuesToApply([]string{"verify"}).(INISettings)
+
+	initialised, err := ini.Initialise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	initialisedINI := initialised.(INISettings)
+	if initialisedINI.Expressions["kernel.shmall"] != "${MEM_TOTAL_BYTES} / ${PAGE_SIZE}" {
+		t.Fatal(initialisedINI.Expressions)
+	}
+	if initialisedINI.Expressions["LIMIT_@sapsys_soft_memlock"] != "@sapsys soft memlock ${MEM_TOTAL_KB} * 90 / 100" {
+		t.Fatal(initialisedINI.Expressions)
+	}
+	if len(initialisedINI.Expressions) != 5 {
+		t.Fatal(initialisedINI.Expressions)
+	}
+	curMapCount, _ := strconv.ParseInt(initialisedINI.SysctlParams["vm.max_map_count"], 10, 64)
+	curMinFree := initialisedINI.SysctlParams["vm.min_free_kbytes"]
+
+	optimised, err := initialisedINI.Optimise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimisedINI := optimised.(INISettings)
+	memKB := system.ParseMeminfo()[system.MemMainTotalKey]
+	if optimisedINI.SysctlParams["kernel.shmall"] != strconv.FormatUint(memKB*1024/uint64(os.Getpagesize()), 10) {
+		t.Fatal(optimisedINI.SysctlParams["kernel.shmall"])
+	}
+	if i, err := strconv.ParseUint(optimisedINI.SysctlParams["kernel.shmmax"], 10, 64); err != nil || i < memKB*1024*90/100 {
+		t.Fatal(i, err)
+	}
+	if optimisedINI.SysctlParams["vm.max_map_count"] != strconv.FormatInt(curMapCount+1, 10) {
+		t.Fatal(optimisedINI.SysctlParams["vm.max_map_count"])
+	}
+	// unknown variable - parameter skipped, current value remains
+	if optimisedINI.SysctlParams["vm.min_free_kbytes"] != curMinFree {
+		t.Fatal(optimisedINI.SysctlParams["vm.min_free_kbytes"])
+	}
+	if optimisedINI.SysctlParams["LIMIT_@sapsys_soft_memlock"] != "@sapsys soft memlock "+strconv.FormatUint(memKB*90/100, 10) {
+		t.Fatal(optimisedINI.SysctlParams["LIMIT_@sapsys_soft_memlock"])
+	}
+}
+
+func TestHasExpressions(t *testing.T) {
+	ovDir := "/tmp/saptune_expr_override"
+	if err := os.MkdirAll(ovDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(ovDir)
+	exprPath := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/ini_expr_test.ini")
+	if ini := (INISettings{ConfFilePath: exprPath, ID: "7654321", OverrideDir: ovDir}); !ini.HasExpressions() {
+		t.Fatal("expressions of the Note definition not found")
+	}
+	iniPath := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/ini_all_test.ini")
+	ini := INISettings{ConfFilePath: iniPath, ID: "9876543", OverrideDir: ovDir}
+	if ini.HasExpressions() {
+		t.Fatal("Note definition without expressions")
+	}
+	// an expression only in the override file
+	if err := ioutil.WriteFile(path.Join(ovDir, "9876543"), []byte("[sysctl]\nvm.dirty_ratio = ${CURRENT} + 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !ini.HasExpressions() {
+		t.Fatal("expression of the override file not found")
+	}
+}
+
+func TestInheritedNote(t *testing.T) {
+	cleanUp()
+	inheritDir := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/inherit")
+	noteFiles := GetTuningOptions(inheritDir, "", "").NoteFiles()
+	if noteFiles["4711"] != path.Join(inheritDir, "4711") {
+		t.Fatal(noteFiles)
+	}
+
+	ini, err := ParseNoteFile(path.Join(inheritDir, "4712"), noteFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ini.Bases) != 1 || ini.KeyValue["sysctl"]["kernel.numa_balancing"].Origin != path.Join(inheritDir, "4711") {
+		t.Fatal(ini)
+	}
+	if _, err = ParseNoteFile(path.Join(inheritDir, "4716"), noteFiles); err == nil || !strings.Contains(err.Error(), "cyclic inheritance") {
+		t.Fatal(err)
+	}
+	// the base Notes are only found by the given Note files
+	if _, err = ParseNoteFile(path.Join(inheritDir, "4712"), nil); err == nil || !strings.Contains(err.Error(), "unknown Note ID") {
+		t.Fatal(err)
+	}
+
+	vend := INISettings{ConfFilePath: path.Join(inheritDir, "4712"), ID: "4712", NoteFiles: noteFiles}
+	vend = vend.SetValuesToApply([]string{"verify"}).(INISettings)
+	initialised, err := vend.Initialise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimised, err := initialised.(INISettings).Optimise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimisedINI := optimised.(INISettings)
+	expected := map[string]string{
+		"vm.swappiness":             "10",
+		"kernel.numa_balancing":     "0",
+		"kernel.shmmni":             "32768",
+		"LIMIT_@sapsys_soft_nofile": "@sapsys soft nofile 65536",
+		"LIMIT_@dba_hard_nofile":    "@dba hard nofile 1048576",
+		"reminder":                  "# reminder of the base note\n",
+	}
+	if len(optimisedINI.SysctlParams) != len(expected) {
+		t.Fatal(optimisedINI.SysctlParams)
+	}
+	for key, val := range expected {
+		if optimisedINI.SysctlParams[key] != val {
+			t.Fatal(key, optimisedINI.SysctlParams[key])
+		}
+	}
+}
+
+func TestValueSpecs(t *testing.T) {
+	cleanUp()
+	iniPath := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/ini_spec_test.ini")
+	ini := INISettings{ConfFilePath: iniPath, ID: "7654322"}
+	ini = ini.SetValuesToApply([]string{"verify"}).(INISettings)
+	initialised, err := ini.Initialise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	initialisedINI := initialised.(INISettings)
+	expSpecs := map[string]string{
+		"vm.swappiness":    "= 0..200",
+		"kernel.sem":       "= * * * >=1",
+		"vm.max_map_count": "!= 0",
+		"kernel.shmmni":    "= <0",
+	}
+	if len(initialisedINI.ValueSpecs) != len(expSpecs) {
+		t.Fatal(initialisedINI.ValueSpecs)
+	}
+	for key, spec := range expSpecs {
+		if initialisedINI.ValueSpecs[key] != spec {
+			t.Fatal(key, initialisedINI.ValueSpecs[key])
+		}
+	}
+	optimised, err := initialisedINI.Optimise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimisedINI := optimised.(INISettings)
+	// Optimise changes the maps of the initialised Note, so read the
+	// current values again
+	initialised, _ = ini.Initialise()
+	initialisedINI = initialised.(INISettings)
+	for _, key := range []string{"vm.swappiness", "kernel.sem", "vm.max_map_count"} {
+		if optimisedINI.SysctlParams[key] != initialisedINI.SysctlParams[key] {
+			t.Fatal(key, optimisedINI.SysctlParams[key], initialisedINI.SysctlParams[key])
+		}
+	}
+	if optimisedINI.SysctlParams["kernel.shmmni"] != "-1" || optimisedINI.SysctlParams["vm.dirty_ratio"] != "20" {
+		t.Fatal(optimisedINI.SysctlParams)
+	}
+
+	_, comparisons, _ := CompareNoteFields(initialisedINI, optimisedINI)
+	for _, key := range []string{"vm.swappiness", "kernel.sem", "vm.max_map_count"} {
+		if !comparisons["SysctlParams["+key+"]"].MatchExpectation {
+			t.Fatal(key, comparisons["SysctlParams["+key+"]"])
+		}
+	}
+	if comparisons["SysctlParams[kernel.shmmni]"].MatchExpectation {
+		t.Fatal(comparisons["SysctlParams[kernel.shmmni]"])
+	}
+	// value specification checked instead of the optimised value
+	initialisedINI.SysctlParams["vm.swappiness"] = "201"
+	_, comparisons, _ = CompareNoteFields(initialisedINI, optimisedINI)
+	if comparisons["SysctlParams[vm.swappiness]"].MatchExpectation {
+		t.Fatal(comparisons["SysctlParams[vm.swappiness]"])
+	}
+}
+
+func TestEvalExpression(t *testing.T) {
+	val, err := evalExpression(INISectionSysctl, "${CURRENT} * 2", "21")
+	if err != nil || val != "42" {
+		t.Fatal(val, err)
+	}
+	val, err = evalExpression(INISectionLimits, "@sapsys hard memlock ${CURRENT} / 2", "@sapsys hard memlock 1024")
+	if err != nil || val != "@sapsys hard memlock 512" {
+		t.Fatal(val, err)
+	}
+	if _, err = evalExpression(INISectionLimits, "@sapsys hard ${CURRENT}", "@sapsys hard memlock 1024"); err == nil {
+		t.Fatal("wrong limits entry accepted")
+	}
+	if _, err = evalExpression(INISectionSysctl, "${CURRENT} * 2", "1 2 3"); err == nil || !strings.Contains(err.Error(), "not a single integer") {
+		t.Fatal(err)
+	}
+	if _, err = evalExpression(INISectionSysctl, "${PAGE_SIZE} *", ""); err == nil || !strings.Contains(err.Error(), "unexpected end of expression") {
+		t.Fatal(err)
+	}
+}
+
 func TestNoConfig(t *testing.T) {
 	iniPath := "/no_config_file"
 	ini := INISettings{ConfFilePath: iniPath, ID: "47114711"}
@@ -156,7 +359,7 @@ func TestAllSettings(t *testing.T) {
 	if ini.Name() == "" {
 		t.Fatal(ini.Name())
 	}
-	if ini.Name() != fmt.Sprintf("ini_all_test: SAP Note file for ini_all_test\n\t\t\tVersion 3 from 02.01.2019 ") {
+	if ini.Name() != fmt.Sprintf("ini_all_test: SAP Note file for ini_all_test\n\t\t\tVersion 3 from 02.01.2019") {
 		t.Fatal(ini.Name())
 	}
 
@@ -342,7 +545,7 @@ func TestOverrideAllSettings(t *testing.T) {
 	if ini.Name() == "" {
 		t.Fatal(ini.Name())
 	}
-	if ini.Name() != fmt.Sprintf("ini_all_test: SAP Note file for ini_all_test\n\t\t\tVersion 3 from 02.01.2019 ") {
+	if ini.Name() != fmt.Sprintf("ini_all_test: SAP Note file for ini_all_test\n\t\t\tVersion 3 from 02.01.2019") {
 		t.Fatal(ini.Name())
 	}
 
@@ -463,7 +666,7 @@ func TestPageCacheSettings(t *testing.T) {
 	if ini.Name() == "" {
 		t.Fatal(ini.Name())
 	}
-	if ini.Name() != fmt.Sprintf("Linux paging improvements\n\t\t\tVersion 14 from 10.08.2015 ") {
+	if ini.Name() != fmt.Sprintf("Linux paging improvements\n\t\t\tVersion 14 from 10.08.2015") {
 		t.Fatal(ini.Name())
 	}
 
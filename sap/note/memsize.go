@@ -0,0 +1,91 @@
+package note
+
+// Memory sizes with unit suffix like 'ShmFileSystemSizeMB = 16G' or
+// 'vm.dirty_bytes = 512MiB' or as percentage of the main memory like
+// 'ShmFileSystemSizeMB = 50%' in Note definition and override files
+
+import (
+	"github.com/SUSE/saptune/system"
+	"strconv"
+	"strings"
+)
+
+// memSizeParams contains the memory related parameters, which accept
+// values with unit suffix, and the unit expected by the system
+// (empty for bytes, 'K' for KiB, 'M' for MiB)
+var memSizeParams = map[string]map[string]string{
+	INISectionSysctl: {
+		"vm.dirty_bytes":            "",
+		"vm.dirty_background_bytes": "",
+		"kernel.shmmax":             "",
+		"vm.min_free_kbytes":        "K",
+	},
+	INISectionMEM: {
+		"ShmFileSystemSizeMB": "M",
+	},
+	INISectionPagecache: {
+		"OVERRIDE_PAGECACHE_LIMIT_MB": "M",
+	},
+}
+
+// memSizeUnit returns the unit expected by the system for a memory related
+// parameter. The value of 'memlock' limits is in KiB
+func memSizeUnit(section, key string) (string, bool) {
+	if section == INISectionLimits {
+		return "K", isLimitSoft.MatchString(key) || isLimitHard.MatchString(key)
+	}
+	unit, ok := memSizeParams[section][key]
+	return unit, ok
+}
+
+// isUnlimited checks for the limit values without size
+func isUnlimited(value string) bool {
+	return value == "unlimited" || value == "infinity" || value == "-1"
+}
+
+// normaliseMemSize converts a memory size with unit suffix or percentage
+// into the unit expected by the system. For the [limits] section only the
+// limit value (the 4th field) is converted
+func normaliseMemSize(section, value, unit string) (string, error) {
+	prefix := ""
+	size := value
+	if section == INISectionLimits {
+		lim := strings.Fields(value)
+		if len(lim) != 4 {
+			return value, nil
+		}
+		prefix = strings.Join(lim[:3], " ") + " "
+		size = lim[3]
+	}
+	if !system.HasMemUnit(size) {
+		return value, nil
+	}
+	val, err := system.ParseMemSize(size, unit)
+	if err != nil {
+		return value, err
+	}
+	return prefix + strconv.FormatUint(val, 10), nil
+}
+
+// addMemSize stores the human-readable value of a memory related parameter
+// for the verify and simulate table. A value, which is not a memory size
+// like 'unlimited' or 'NA', is stored unchanged
+func (vend INISettings) addMemSize(section, key string) {
+	unit, ok := memSizeUnit(section, key)
+	if !ok {
+		return
+	}
+	size := vend.SysctlParams[key]
+	if section == INISectionLimits {
+		if lim := strings.Fields(size); len(lim) == 4 {
+			size = lim[3]
+		}
+	}
+	vend.MemSizes[key] = size
+	if isUnlimited(size) {
+		return
+	}
+	if val, err := strconv.ParseUint(size, 10, 64); err == nil {
+		vend.MemSizes[key] = system.FormatMemSize(val, unit)
+	}
+}
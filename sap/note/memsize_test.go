@@ -0,0 +1,89 @@
+package note
+
+import (
+	"github.com/SUSE/saptune/system"
+	"strconv"
+	"testing"
+)
+
+func TestMemSizeUnit(t *testing.T) {
+	type unitTest struct {
+		section string
+		key     string
+		unit    string
+		ok      bool
+	}
+	tests := []unitTest{
+		{"sysctl", "vm.dirty_bytes", "", true},
+		{"sysctl", "vm.min_free_kbytes", "K", true},
+		{"sysctl", "vm.swappiness", "", false},
+		{"mem", "ShmFileSystemSizeMB", "M", true},
+		{"mem", "VSZ_TMPFS_PERCENT", "", false},
+		{"pagecache", "OVERRIDE_PAGECACHE_LIMIT_MB", "M", true},
+		{"limits", "LIMIT_@sapsys_soft_memlock", "K", true},
+		{"limits", "LIMIT_@sapsys_hard_nofile", "K", false},
+	}
+	for _, tst := range tests {
+		unit, ok := memSizeUnit(tst.section, tst.key)
+		if ok != tst.ok || (ok && unit != tst.unit) {
+			t.Fatalf("%s: got '%s' '%v', expected '%s' '%v'\n", tst.key, unit, ok, tst.unit, tst.ok)
+		}
+	}
+}
+
+func TestNormaliseMemSize(t *testing.T) {
+	type normTest struct {
+		section string
+		value   string
+		unit    string
+		exp     string
+	}
+	tests := []normTest{
+		{"sysctl", "512MiB", "", "536870912"},
+		{"sysctl", "536870912", "", "536870912"},
+		{"mem", "16G", "M", "16384"},
+		{"mem", "0", "M", "0"},
+		{"limits", "@sapsys soft memlock 32GB", "K", "@sapsys soft memlock 33554432"},
+		{"limits", "@sapsys soft memlock unlimited", "K", "@sapsys soft memlock unlimited"},
+		{"limits", "NA", "K", "NA"},
+	}
+	for _, tst := range tests {
+		val, err := normaliseMemSize(tst.section, tst.value, tst.unit)
+		if err != nil || val != tst.exp {
+			t.Fatalf("'%s': got '%s', expected '%s' - %v\n", tst.value, val, tst.exp, err)
+		}
+	}
+	halfMem := system.ParseMeminfo()[system.MemMainTotalKey] / 1024 / 2
+	val, err := normaliseMemSize("mem", "50%", "M")
+	if err != nil || val != strconv.FormatUint(halfMem, 10) {
+		t.Fatalf("50%%: got '%s', expected '%d' - %v\n", val, halfMem, err)
+	}
+	if val, err := normaliseMemSize("sysctl", "-16M", ""); err == nil || val != "-16M" {
+		t.Fatalf("expected error for '-16M', got '%s'\n", val)
+	}
+}
+
+func TestAddMemSize(t *testing.T) {
+	vend := INISettings{SysctlParams: make(map[string]string), MemSizes: make(map[string]string)}
+	vend.SysctlParams["ShmFileSystemSizeMB"] = "16384"
+	vend.SysctlParams["LIMIT_@sapsys_soft_memlock"] = "@sapsys soft memlock 1536"
+	vend.SysctlParams["LIMIT_@sapsys_hard_memlock"] = "@sapsys hard memlock unlimited"
+	vend.SysctlParams["vm.swappiness"] = "60"
+	vend.addMemSize("mem", "ShmFileSystemSizeMB")
+	vend.addMemSize("limits", "LIMIT_@sapsys_soft_memlock")
+	vend.addMemSize("limits", "LIMIT_@sapsys_hard_memlock")
+	vend.addMemSize("sysctl", "vm.swappiness")
+	exp := map[string]string{
+		"ShmFileSystemSizeMB":        "16 GiB",
+		"LIMIT_@sapsys_soft_memlock": "1.5 MiB",
+		"LIMIT_@sapsys_hard_memlock": "unlimited",
+	}
+	if len(vend.MemSizes) != len(exp) {
+		t.Fatal(vend.MemSizes)
+	}
+	for key, val := range exp {
+		if vend.MemSizes[key] != val {
+			t.Fatalf("%s: got '%s', expected '%s'\n", key, vend.MemSizes[key], val)
+		}
+	}
+}
@@ -37,8 +37,9 @@ type Note interface {
 type TuningOptions map[string]Note
 
 // GetTuningOptions returns all built-in tunable SAP notes together with those
-// defined by 3rd party vendors.
-func GetTuningOptions(saptuneTuningDir, thirdPartyTuningDir string) TuningOptions {
+// defined by 3rd party vendors. The override files of the notes are located
+// in overrideDir, an empty overrideDir means OverrideTuningSheets
+func GetTuningOptions(saptuneTuningDir, thirdPartyTuningDir, overrideDir string) TuningOptions {
 	ret := TuningOptions{}
 	// Collect those defined by saptune
 	_, files := system.ListDir(saptuneTuningDir, "saptune tuning definitions")
@@ -47,7 +48,9 @@ func GetTuningOptions(saptuneTuningDir, thirdPartyTuningDir string) TuningOption
 			ConfFilePath:    path.Join(saptuneTuningDir, fileName),
 			ID:              fileName,
 			DescriptiveName: "",
+			OverrideDir:     overrideDir,
 		}
+		validateNoteMetadata(path.Join(saptuneTuningDir, fileName))
 	}
 
 	// Collect those defined by 3rd party
@@ -60,6 +63,10 @@ func GetTuningOptions(saptuneTuningDir, thirdPartyTuningDir string) TuningOption
 			system.WarningLog("For more information refer to the man page saptune-migrate(7)")
 			continue
 		}
+		if strings.HasSuffix(fileName, ".sol") {
+			// customer or vendor specific solution definition
+			continue
+		}
 		if !strings.HasSuffix(fileName, ".conf") {
 			// skip filenames without .conf suffix
 			system.WarningLog("skip file \"%s\", wrong filename syntax, missing '.conf' suffix", fileName)
@@ -88,6 +95,7 @@ func GetTuningOptions(saptuneTuningDir, thirdPartyTuningDir string) TuningOption
 			// description found in header of the file
 			// let name empty, to get the right information during 'note list'
 			id = strings.TrimSuffix(fileName, ".conf")
+			validateNoteMetadata(path.Join(thirdPartyTuningDir, fileName))
 		}
 		// Do not allow vendor to override built-in
 		if _, exists := ret[id]; exists {
@@ -98,11 +106,50 @@ func GetTuningOptions(saptuneTuningDir, thirdPartyTuningDir string) TuningOption
 			ConfFilePath:    path.Join(thirdPartyTuningDir, fileName),
 			ID:              id,
 			DescriptiveName: name,
+			OverrideDir:     overrideDir,
 		}
 	}
 	return ret
 }
 
+// validateNoteMetadata checks the metadata of a Note definition file and
+// logs the problems found
+func validateNoteMetadata(fileName string) {
+	meta, err := txtparser.GetNoteMetadata(fileName)
+	if err != nil {
+		system.WarningLog("GetTuningOptions: failed to read the header information of file \"%s\" - %v", fileName, err)
+		return
+	}
+	if err := meta.Validate(); err != nil {
+		system.WarningLog("GetTuningOptions: wrong header information in file \"%s\" - %v", fileName, err)
+	}
+}
+
+// ParseNoteFile reads a Note definition file including the Note
+// definitions it is based on ('INCLUDE=' or 'EXTENDS=' in the [version]
+// section). The files of the base Notes are looked up by their Note ID
+// in noteFiles
+func ParseNoteFile(fileName string, noteFiles map[string]string) (*txtparser.INIFile, error) {
+	return txtparser.ResolveINIFile(fileName, func(id string) (string, error) {
+		if noteFile, ok := noteFiles[id]; ok {
+			return noteFile, nil
+		}
+		return "", fmt.Errorf("unknown Note ID")
+	})
+}
+
+// NoteFiles returns the Note definition files of all Note definitions of
+// the tuning options, used to find the base Notes of a Note definition
+func (opts TuningOptions) NoteFiles() map[string]string {
+	files := make(map[string]string)
+	for id, aNote := range opts {
+		if iniNote, ok := aNote.(INISettings); ok {
+			files[id] = iniNote.ConfFilePath
+		}
+	}
+	return files
+}
+
 // GetSortedIDs returns all tuning option IDs, sorted in ascending order.
 func (opts *TuningOptions) GetSortedIDs() (ret []string) {
 	ret = make([]string, 0, len(*opts))
@@ -125,6 +172,10 @@ type FieldComparison struct {
 
 // CompareJSValue compares JSON representation of two values and see
 // if they match.
+// Supported operators are the empty operator and '==' (string comparison)
+// and the numeric comparisons '!=', '<', '<=', '>' and '>=', which accept
+// 64-bit values with unit suffix (e.g. '16M'). A non-numeric value does
+// not match
 func CompareJSValue(v1, v2 interface{}, op string) (v1JS, v2JS string, match bool) {
 	v1JSBytes, err := json.Marshal(v1)
 	if err != nil {
@@ -148,14 +199,13 @@ func CompareJSValue(v1, v2 interface{}, op string) (v1JS, v2JS string, match boo
 	switch op {
 	case "", "==":
 		match = v1JS == v2JS
-	case ">=":
-		v1JSi, _ := strconv.Atoi(v1JS)
-		v2JSi, _ := strconv.Atoi(v2JS)
-		match = v1JSi >= v2JSi
-	case "<=":
-		v1JSi, _ := strconv.Atoi(v1JS)
-		v2JSi, _ := strconv.Atoi(v2JS)
-		match = v1JSi <= v2JSi
+	case "!=", "<", "<=", ">", ">=":
+		var err error
+		if match, err = (fieldSpec{op: op, value: v2JS}).matchField(v1JS); err != nil {
+			_ = system.ErrorLog("CompareJSValue: failed to compare \"%s\" and \"%s\" - %v", v1JS, v2JS, err)
+		}
+	default:
+		_ = system.ErrorLog("CompareJSValue: unknown operator \"%s\"", op)
 	}
 	return
 }
@@ -171,6 +221,10 @@ func CompareNoteFields(actualNote, expectedNote Note) (allMatch bool, comparison
 	for i := 0; i < refActualNote.NumField(); i++ {
 		// Retrieve actualField value from actual and expected note
 		fieldName := reflect.TypeOf(actualNote).Field(i).Name
+		if reflect.TypeOf(actualNote).Field(i).Tag.Get("json") == "-" {
+			// fields not part of the saved state hold no tuning values
+			continue
+		}
 		// Compare map value or actualField value
 		if refActualNote.Field(i).Type().Kind() == reflect.Map {
 			// Compare map values
@@ -181,6 +235,11 @@ func CompareNoteFields(actualNote, expectedNote Note) (allMatch bool, comparison
 				expectedValue := expectedMap.MapIndex(key).Interface()
 				ckey := fmt.Sprintf("%s[%s]", fieldName, key.String())
 				comparisons[ckey] = cmpMapValue(fieldName, key, actualValue, expectedValue)
+				if fieldName == "SysctlParams" {
+					if spec := valueSpecOfNote(refExpectedNote, key.String()); spec != "" {
+						comparisons[ckey] = cmpValueSpec(comparisons[ckey], spec)
+					}
+				}
 				if !comparisons[ckey].MatchExpectation && comparisons[ckey].ReflectFieldName == "SysctlParams" {
 					valApplyList = append(valApplyList, comparisons[ckey].ReflectMapKey)
 				} else if key.String() == "force_latency" && comparisons[ckey].ReflectFieldName == "SysctlParams" {
@@ -208,8 +267,11 @@ func cmpMapValue(fieldName string, key reflect.Value, actVal, expVal interface{}
 		op = "<="
 	}
 	actualValueJS, expectedValueJS, match := CompareJSValue(actVal, expVal, op)
-	if strings.Split(key.String(), ":")[0] == "rpm" {
-		match = system.CmpRpmVers(actVal.(string), expVal.(string))
+	if strings.Split(key.String(), ":")[0] == "rpm" && fieldName == "SysctlParams" {
+		match = system.MatchRpmVersSpec(actVal.(string), expVal.(string))
+	}
+	if isPerCPUKey(key.String()) && fieldName == "SysctlParams" {
+		match = CmpCPUValues(actVal.(string), expVal.(string))
 	}
 	fieldComparison := FieldComparison{
 		ReflectFieldName: fieldName,
@@ -223,6 +285,36 @@ func cmpMapValue(fieldName string, key reflect.Value, actVal, expVal interface{}
 	return fieldComparison
 }
 
+// valueSpecOfNote returns the value specification of a parameter, if the
+// Note supports value specifications
+func valueSpecOfNote(refNote reflect.Value, key string) string {
+	specs := refNote.FieldByName("ValueSpecs")
+	if !specs.IsValid() || specs.Kind() != reflect.Map || specs.IsNil() {
+		return ""
+	}
+	spec := specs.MapIndex(reflect.ValueOf(key))
+	if !spec.IsValid() {
+		return ""
+	}
+	return spec.String()
+}
+
+// cmpValueSpec checks the actual value of a parameter against the value
+// specification instead of the optimised value
+func cmpValueSpec(fieldComparison FieldComparison, spec string) FieldComparison {
+	actVal, ok := fieldComparison.ActualValue.(string)
+	if !ok || actVal == "" {
+		return fieldComparison
+	}
+	op, value := splitValueSpec(spec)
+	match, err := MatchValueSpec(op, actVal, value)
+	if err != nil {
+		_ = system.ErrorLog("parameter '%s': value specification '%s' - %v", fieldComparison.ReflectMapKey, spec, err)
+	}
+	fieldComparison.MatchExpectation = match
+	return fieldComparison
+}
+
 // cmpFieldValue compares ordinary field value
 func cmpFieldValue(fNo int, fieldName string, actNote, expNote reflect.Value) FieldComparison {
 	actualValue := actNote.Field(fNo).Interface()
@@ -133,14 +133,53 @@ func TestCmpFieldValue(t *testing.T) {
 }
 
 func TestGetTuningOptions(t *testing.T) {
-	allOpts := GetTuningOptions(OSNotesInGOPATH, "")
+	allOpts := GetTuningOptions(OSNotesInGOPATH, "", "")
 	if sorted := allOpts.GetSortedIDs(); len(allOpts) != len(sorted) {
 		t.Fatal(sorted, allOpts)
 	}
-	allOpts = GetTuningOptions("", TstFilesInGOPATH)
+	allOpts = GetTuningOptions("", TstFilesInGOPATH, "")
 	if sorted := allOpts.GetSortedIDs(); len(allOpts) != len(sorted) {
 		t.Fatal(sorted, allOpts)
 	}
+	if ovFile := allOpts["simpleNote"].(INISettings).overrideFile(); ovFile != path.Join(OverrideTuningSheets, "simpleNote") {
+		t.Fatal(ovFile)
+	}
+	allOpts = GetTuningOptions(OSNotesInGOPATH, "", "/tmp/saptune_override")
+	if ovFile := allOpts["1410736"].(INISettings).overrideFile(); ovFile != "/tmp/saptune_override/1410736" {
+		t.Fatal(ovFile)
+	}
+}
+
+func TestCompareJSValueOperators(t *testing.T) {
+	type cmpTest struct {
+		v1    string
+		v2    string
+		op    string
+		match bool
+	}
+	tests := []cmpTest{
+		{"1", "2", "!=", true},
+		{"2", "2", "!=", false},
+		{"16777216", "16M", "!=", false},
+		{"1", "2", "<", true},
+		{"2", "2", "<", false},
+		{"3", "2", ">", true},
+		{"2", "2", ">", false},
+		{"18446744073709551615", "9223372036854775807", ">=", true},
+		{"9223372036854775807", "18446744073709551615", "<=", true},
+		{"-1", "0", "<", true},
+		{"16777216", "16M", ">=", true},
+		{"tst_string", "1", ">=", false},
+		{"1", "tst_string", "<=", false},
+		{"0", "tst_string", "<=", false},
+		{"1", "1", "~", false},
+	}
+	for _, tst := range tests {
+		r1, r2, match := CompareJSValue(tst.v1, tst.v2, tst.op)
+		if match != tst.match || r1 != tst.v1 || r2 != tst.v2 {
+			t.Fatalf("compare '%s' %s '%s': got '%v', expected '%v'\n", tst.v1, tst.op, tst.v2, match, tst.match)
+		}
+	}
 }
 
 func TestCompareJSValu(t *testing.T) {
@@ -0,0 +1,223 @@
+package note
+
+// Set, unset or untouch single parameters in the override file of a Note
+// without launching an editor like 'saptune note customise' does
+
+import (
+	"fmt"
+	"github.com/SUSE/saptune/system"
+	"github.com/SUSE/saptune/txtparser"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// actions to change a parameter in the override file
+const (
+	OverrideSet     = "set"
+	OverrideUnset   = "unset"
+	OverrideUntouch = "untouch"
+)
+
+// overrideChoices contains the valid values of parameters with a fixed
+// set of values
+var overrideChoices = map[string]map[string][]string{
+	INISectionVM: {
+		"THP": {"always", "madvise", "never"},
+		"KSM": {"0", "1"},
+	},
+	INISectionPagecache: {
+		"ENABLE_PAGECACHE_LIMIT":               {"yes", "no"},
+		system.SysctlPagecacheLimitIgnoreDirty: {"0", "1", "2"},
+	},
+}
+
+// numericBlkParams are the numeric parameters of the [block] section
+var numericBlkParams = []string{"NRREQ", "READ_AHEAD_KB", "MAX_SECTORS_KB", "RQ_AFFINITY", "NOMERGES", "ADD_RANDOM"}
+
+// ChangeOverride sets ('set'), removes ('unset') or disables ('untouch') a
+// parameter of the Note in the override file. The override file is created
+// as copy of the Note definition file, if it does not exist.
+// Returns false, if the override file was not changed
+func (vend INISettings) ChangeOverride(action, section, key, value string) (bool, error) {
+	ini, err := ParseNoteFile(vend.ConfFilePath, vend.NoteFiles)
+	if err != nil {
+		return false, err
+	}
+	if err := CheckOverride(ini, action, section, key, value); err != nil {
+		return false, err
+	}
+	noteValue := ""
+	if entry, ok := ini.KeyValue[section][key]; ok {
+		noteValue = entry.Value
+	}
+	return changeOverrideFile(vend.overrideFile(), vend.ConfFilePath, action, section, key, value, noteValue)
+}
+
+// CheckOverride checks, if the parameter 'key' is defined in the section
+// 'section' of the Note definition and if the value is valid for the
+// section
+func CheckOverride(ini *txtparser.INIFile, action, section, key, value string) error {
+	switch section {
+	case INISectionVersion, INISectionRpm, INISectionGrub, INISectionReminder:
+		return fmt.Errorf("the parameters of section [%s] can not be changed by an override file", section)
+	case INISectionPagecache:
+		if action == OverrideUntouch {
+			return fmt.Errorf("the parameters of section [%s] can not be untouched, please set 'ENABLE_PAGECACHE_LIMIT' to 'no' instead", section)
+		}
+	}
+	if !hasNoteParameter(ini, section, key) {
+		return fmt.Errorf("parameter '%s' is not defined in section [%s] of the Note definition", key, section)
+	}
+	if action != OverrideSet {
+		return nil
+	}
+	return checkOverrideValue(section, key, value)
+}
+
+// hasNoteParameter checks, if the Note definition contains the parameter.
+// The parameters of the [block] section are stored per block device and
+// the 'LIMITS' entry of the [limits] section per limit
+func hasNoteParameter(ini *txtparser.INIFile, section, key string) bool {
+	switch section {
+	case INISectionBlock:
+		for ikey := range ini.KeyValue[section] {
+			if ikey == key || strings.HasPrefix(ikey, key+"_") {
+				return true
+			}
+		}
+		return false
+	case INISectionLimits:
+		return key == "LIMITS" && len(ini.KeyValue[section]) != 0
+	}
+	_, ok := ini.KeyValue[section][key]
+	return ok
+}
+
+// checkOverrideValue checks, if the value is valid for the parameter
+func checkOverrideValue(section, key, value string) error {
+	if value == "" {
+		return fmt.Errorf("empty value for parameter '%s', use 'untouch' to leave the parameter untouched", key)
+	}
+	if strings.ContainsAny(value, "\n\r") {
+		return fmt.Errorf("value of parameter '%s' contains a line break", key)
+	}
+	if txtparser.IsExpression(value) {
+		return checkOverrideExpression(section, key, value)
+	}
+	if choices, ok := overrideChoices[section][key]; ok {
+		for _, choice := range choices {
+			if strings.ToLower(value) == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("wrong value '%s' for parameter '%s', valid values are '%s'", value, key, strings.Join(choices, "', '"))
+	}
+	switch section {
+	case INISectionSysctl:
+		if IsValueSpec(txtparser.OperatorEqual, value) {
+			if _, err := parseValueSpec(txtparser.OperatorEqual, value); err != nil {
+				return fmt.Errorf("wrong value specification '%s' for parameter '%s' - %v", value, key, err)
+			}
+			return nil
+		}
+	case INISectionBlock:
+		for _, param := range numericBlkParams {
+			if key == param && !system.IsNumeric(value) {
+				return fmt.Errorf("value '%s' of parameter '%s' is not numeric", value, key)
+			}
+		}
+	case INISectionService:
+		for _, state := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(state)) {
+			case "start", "stop", "enable", "disable", "mask":
+			default:
+				return fmt.Errorf("wrong service state '%s' for '%s', valid states are 'start', 'stop', 'enable', 'disable' and 'mask'", state, key)
+			}
+		}
+	case INISectionLimits:
+		for _, limit := range strings.Split(value, ",") {
+			if len(strings.Fields(limit)) != 4 {
+				return fmt.Errorf("wrong limits entry '%s', expected '<domain> <type> <item> <value>'", strings.TrimSpace(limit))
+			}
+			if _, err := normaliseMemSize(section, strings.TrimSpace(limit), "K"); err != nil {
+				return fmt.Errorf("wrong limits entry '%s' - %v", strings.TrimSpace(limit), err)
+			}
+		}
+		return nil
+	}
+	if unit, ok := memSizeUnit(section, key); ok && !isUnlimited(value) {
+		if !system.IsNumeric(value) && !system.HasMemUnit(value) {
+			return fmt.Errorf("wrong memory size '%s' for parameter '%s'", value, key)
+		}
+		if _, err := normaliseMemSize(section, value, unit); err != nil {
+			return fmt.Errorf("wrong memory size '%s' for parameter '%s' - %v", value, key, err)
+		}
+	}
+	return nil
+}
+
+// checkOverrideExpression checks the syntax of an expression. For the
+// [limits] section only the limit values (the 4th fields) are expressions
+func checkOverrideExpression(section, key, value string) error {
+	exprs := []string{value}
+	if section == INISectionLimits {
+		exprs = []string{}
+		for _, limit := range strings.Split(value, ",") {
+			lim := strings.Fields(limit)
+			if len(lim) < 4 {
+				return fmt.Errorf("wrong limits entry '%s', expected '<domain> <type> <item> <value>'", strings.TrimSpace(limit))
+			}
+			exprs = append(exprs, strings.Join(lim[3:], " "))
+		}
+	}
+	for _, expr := range exprs {
+		if !txtparser.IsExpression(expr) {
+			continue
+		}
+		if _, err := txtparser.ParseExpression(expr); err != nil {
+			return fmt.Errorf("wrong expression '%s' for parameter '%s' - %v", expr, key, err)
+		}
+	}
+	return nil
+}
+
+// changeOverrideFile changes the parameter in the override file. For the
+// [pagecache] section 'unset' sets the value of the Note definition, as
+// the page cache settings are read completely from the override file
+func changeOverrideFile(ovFile, noteFile, action, section, key, value, noteValue string) (bool, error) {
+	if _, err := os.Stat(ovFile); os.IsNotExist(err) {
+		if action == OverrideUnset {
+			// nothing to remove
+			return false, nil
+		}
+		if err := system.CopyFile(noteFile, ovFile); err != nil {
+			return false, fmt.Errorf("problems while copying '%s' to '%s' - %v", noteFile, ovFile, err)
+		}
+	} else if err != nil {
+		return false, err
+	}
+	content, err := ioutil.ReadFile(ovFile)
+	if err != nil {
+		return false, err
+	}
+	newContent := string(content)
+	switch action {
+	case OverrideSet:
+		newContent = txtparser.SetINIEntry(newContent, section, key, value)
+	case OverrideUntouch:
+		newContent = txtparser.SetINIEntry(newContent, section, key, "")
+	case OverrideUnset:
+		if section == INISectionPagecache {
+			newContent = txtparser.SetINIEntry(newContent, section, key, noteValue)
+		} else {
+			newContent, _ = txtparser.RemoveINIEntry(newContent, section, key)
+		}
+	default:
+		return false, fmt.Errorf("unknown override action '%s'", action)
+	}
+	if newContent == string(content) {
+		return false, nil
+	}
+	return true, ioutil.WriteFile(ovFile, []byte(newContent), 0644)
+}
@@ -0,0 +1,147 @@
+package note
+
+import (
+	"github.com/SUSE/saptune/txtparser"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+var overrideTestNote = `[version]
+# SAP-NOTE=ovTest VERSION=1 DATE=01.10.2019 NAME="override test"
+
+[sysctl]
+# comment
+vm.swappiness = 10
+vm.dirty_bytes = 629145600
+
+[vm]
+THP = always
+
+[block]
+IO_SCHEDULER = noop, none
+NRREQ = 1022
+
+[limits]
+LIMITS = @sapsys soft nofile 65536, @sapsys hard nofile 65536
+
+[service]
+sysstat = start
+
+[pagecache]
+ENABLE_PAGECACHE_LIMIT = no
+
+[rpm]
+glibc all 2.22-51.6
+`
+
+func TestCheckOverride(t *testing.T) {
+	ini := txtparser.ParseINI(overrideTestNote)
+	valid := []struct{ action, section, key, value string }{
+		{OverrideSet, "sysctl", "vm.swappiness", "60"},
+		{OverrideSet, "sysctl", "vm.swappiness", "0..60"},
+		{OverrideSet, "sysctl", "vm.dirty_bytes", "512MiB"},
+		{OverrideSet, "sysctl", "vm.dirty_bytes", "${MEM_TOTAL_BYTES} / 100"},
+		{OverrideSet, "vm", "THP", "Never"},
+		{OverrideSet, "block", "IO_SCHEDULER", "mq-deadline"},
+		{OverrideSet, "block", "NRREQ", "2048"},
+		{OverrideSet, "limits", "LIMITS", "@sapsys soft nofile 1048576, @sapsys hard memlock 16G"},
+		{OverrideSet, "service", "sysstat", "stop, disable"},
+		{OverrideSet, "pagecache", "ENABLE_PAGECACHE_LIMIT", "yes"},
+		{OverrideUntouch, "sysctl", "vm.swappiness", ""},
+		{OverrideUntouch, "limits", "LIMITS", ""},
+		{OverrideUnset, "block", "NRREQ", ""},
+		{OverrideUnset, "pagecache", "ENABLE_PAGECACHE_LIMIT", ""},
+	}
+	for _, tst := range valid {
+		if err := CheckOverride(ini, tst.action, tst.section, tst.key, tst.value); err != nil {
+			t.Errorf("%s %s.%s=%s: unexpected error '%v'\n", tst.action, tst.section, tst.key, tst.value, err)
+		}
+	}
+	invalid := []struct{ action, section, key, value, err string }{
+		{OverrideSet, "sysctl", "vm.max_map_count", "1", "parameter 'vm.max_map_count' is not defined in section [sysctl] of the Note definition"},
+		{OverrideSet, "vm", "vm.swappiness", "1", "parameter 'vm.swappiness' is not defined in section [vm] of the Note definition"},
+		{OverrideSet, "sysctl", "vm.swappiness", "", "empty value for parameter 'vm.swappiness', use 'untouch' to leave the parameter untouched"},
+		{OverrideSet, "sysctl", "vm.swappiness", ">=*", "wrong value specification '>=*' for parameter 'vm.swappiness' - operator '>=' not supported for '*'"},
+		{OverrideSet, "sysctl", "vm.dirty_bytes", "512XB", "wrong memory size"},
+		{OverrideSet, "sysctl", "vm.dirty_bytes", "${MEM_TOTAL_BYTES} / ", "wrong expression"},
+		{OverrideSet, "vm", "THP", "sometimes", "wrong value 'sometimes' for parameter 'THP', valid values are 'always', 'madvise', 'never'"},
+		{OverrideSet, "block", "NRREQ", "many", "value 'many' of parameter 'NRREQ' is not numeric"},
+		{OverrideSet, "limits", "LIMITS", "@sapsys soft nofile", "wrong limits entry '@sapsys soft nofile'"},
+		{OverrideSet, "service", "sysstat", "run", "wrong service state 'run' for 'sysstat'"},
+		{OverrideSet, "rpm", "glibc", "2.22", "the parameters of section [rpm] can not be changed by an override file"},
+		{OverrideUntouch, "pagecache", "ENABLE_PAGECACHE_LIMIT", "", "the parameters of section [pagecache] can not be untouched"},
+	}
+	for _, tst := range invalid {
+		err := CheckOverride(ini, tst.action, tst.section, tst.key, tst.value)
+		if err == nil {
+			if tst.err != "" {
+				t.Errorf("%s %s.%s=%s: missing error\n", tst.action, tst.section, tst.key, tst.value)
+			}
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tst.err) {
+			t.Errorf("%s %s.%s=%s: got error '%v', expected '%s'\n", tst.action, tst.section, tst.key, tst.value, err, tst.err)
+		}
+	}
+}
+
+func TestChangeOverrideFile(t *testing.T) {
+	tstDir, _ := ioutil.TempDir("", "saptune_override_test")
+	defer os.RemoveAll(tstDir)
+	noteFile := path.Join(tstDir, "ovTest.conf")
+	ovFile := path.Join(tstDir, "ovTest")
+	if err := ioutil.WriteFile(noteFile, []byte(overrideTestNote), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// no override file, nothing to unset
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideUnset, "sysctl", "vm.swappiness", "", "10"); changed || err != nil {
+		t.Fatalf("changed: %v, err: %v\n", changed, err)
+	}
+	if _, err := os.Stat(ovFile); !os.IsNotExist(err) {
+		t.Fatal("override file created by 'unset'")
+	}
+
+	// the override file is created from the Note definition file
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideSet, "sysctl", "vm.swappiness", "60", "10"); !changed || err != nil {
+		t.Fatalf("changed: %v, err: %v\n", changed, err)
+	}
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideSet, "sysctl", "vm.swappiness", "60", "10"); changed || err != nil {
+		t.Fatalf("unchanged setting reported as change: %v, err: %v\n", changed, err)
+	}
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideUntouch, "vm", "THP", "", "always"); !changed || err != nil {
+		t.Fatalf("changed: %v, err: %v\n", changed, err)
+	}
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideSet, "pagecache", "ENABLE_PAGECACHE_LIMIT", "yes", "no"); !changed || err != nil {
+		t.Fatalf("changed: %v, err: %v\n", changed, err)
+	}
+	ow, err := txtparser.ParseINIFile(ovFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ow.KeyValue["sysctl"]["vm.swappiness"].Value != "60" || ow.KeyValue["vm"]["THP"].Value != "" || ow.KeyValue["vm"]["THP"].Key != "THP" || ow.KeyValue["pagecache"]["ENABLE_PAGECACHE_LIMIT"].Value != "yes" {
+		t.Fatalf("wrong override file content: %+v\n", ow.KeyValue)
+	}
+	content, _ := ioutil.ReadFile(ovFile)
+	if !strings.Contains(string(content), "# comment\n") {
+		t.Fatalf("comments of the override file lost: '%s'\n", string(content))
+	}
+
+	// unset removes the entry, but resets the page cache settings
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideUnset, "vm", "THP", "", "always"); !changed || err != nil {
+		t.Fatalf("changed: %v, err: %v\n", changed, err)
+	}
+	if changed, err := changeOverrideFile(ovFile, noteFile, OverrideUnset, "pagecache", "ENABLE_PAGECACHE_LIMIT", "", "no"); !changed || err != nil {
+		t.Fatalf("changed: %v, err: %v\n", changed, err)
+	}
+	ow, _ = txtparser.ParseINIFile(ovFile, false)
+	if _, ok := ow.KeyValue["vm"]["THP"]; ok || ow.KeyValue["pagecache"]["ENABLE_PAGECACHE_LIMIT"].Value != "no" {
+		t.Fatalf("wrong override file content: %+v\n", ow.KeyValue)
+	}
+	if _, err := changeOverrideFile(ovFile, noteFile, "wrong", "vm", "THP", "", "always"); err == nil {
+		t.Fatal("missing error for unknown action")
+	}
+}
@@ -3,7 +3,6 @@ package note
 import (
 	"encoding/json"
 	"github.com/SUSE/saptune/system"
-	"io/ioutil"
 	"os"
 	"path"
 )
@@ -45,19 +44,13 @@ func IDInParameterList(noteID string, list []ParameterNoteEntry) bool {
 
 // ListParams lists all stored parameter states. Return parameter names
 func ListParams() (ret []string, err error) {
-	if err = os.MkdirAll(SaptuneParameterStateDir, 0755); err != nil {
+	if err = system.MkdirAll(SaptuneParameterStateDir, 0755); err != nil {
 		return
 	}
 	// List SaptuneParameterStateDir and collect parameter names from file names
-	dirContent, err := ioutil.ReadDir(SaptuneParameterStateDir)
+	ret, err = system.ReadDirNames(SaptuneParameterStateDir)
 	if os.IsNotExist(err) {
 		return []string{}, nil
-	} else if err != nil {
-		return
-	}
-	ret = make([]string, 0, len(dirContent))
-	for _, pname := range dirContent {
-		ret = append(ret, pname.Name())
 	}
 	return
 }
@@ -111,7 +104,7 @@ func GetSavedParameterNotes(param string) ParameterNotes {
 	pEntries := ParameterNotes{
 		AllNotes: make([]ParameterNoteEntry, 0, 64),
 	}
-	content, err := ioutil.ReadFile(GetPathToParameter(param))
+	content, err := system.ReadFile(GetPathToParameter(param))
 	if err != nil {
 		return pEntries
 	}
@@ -144,11 +137,11 @@ func StoreParameter(param string, obj ParameterNotes, overwriteExisting bool) er
 	if err != nil {
 		return err
 	}
-	if err = os.MkdirAll(SaptuneParameterStateDir, 0755); err != nil {
+	if err = system.MkdirAll(SaptuneParameterStateDir, 0755); err != nil {
 		return err
 	}
-	if _, err := os.Stat(GetPathToParameter(param)); os.IsNotExist(err) || overwriteExisting {
-		return ioutil.WriteFile(GetPathToParameter(param), content, 0644)
+	if !system.FileExists(GetPathToParameter(param)) || overwriteExisting {
+		return system.WriteFile(GetPathToParameter(param), content, 0644)
 	}
 	return nil
 }
@@ -219,8 +212,8 @@ func RevertParameter(param, noteID string) (string, string) {
 // CleanUpParamFile removes the parameter state file
 func CleanUpParamFile(param string) {
 	remFileName := GetPathToParameter(param)
-	if _, err := os.Stat(remFileName); err == nil {
-		os.Remove(remFileName)
+	if system.FileExists(remFileName) {
+		system.RemoveFile(remFileName)
 	}
 }
 
@@ -229,8 +222,5 @@ func CleanUpParamFile(param string) {
 // applied
 func IsLastNoteOfParameter(param string) bool {
 	chkFileName := GetPathToParameter(param)
-	if _, err := os.Stat(chkFileName); os.IsNotExist(err) {
-		return true
-	}
-	return false
+	return !system.FileExists(chkFileName)
 }
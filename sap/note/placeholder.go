@@ -0,0 +1,210 @@
+package note
+
+// Placeholders in the domain and type fields of the limits definitions of
+// the [limits] section like 'LIMITS = <DBMSuser> hard memlock unlimited' or
+// '<sid>adm soft nofile 1048576'. The values of the placeholders are taken
+// from the limits definitions of the override file or from the variables
+// 'LIMITS_<name>' in /etc/sysconfig/saptune
+
+import (
+	"github.com/SUSE/saptune/system"
+	"github.com/SUSE/saptune/txtparser"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LimitsPlaceholderPrefix is the prefix of the variables in
+// /etc/sysconfig/saptune defining the values of a placeholder like
+// 'LIMITS_DBMSuser="sybase"'
+const LimitsPlaceholderPrefix = "LIMITS_"
+
+// isLimitsPlaceholder matches a placeholder like '<DBMSuser>'
+var isLimitsPlaceholder = regexp.MustCompile(`<([\w-]+)>`)
+
+// hasLimitsPlaceholder checks, if the domain or the type field of a limits
+// definition contains a placeholder
+func hasLimitsPlaceholder(value string) bool {
+	lim := strings.Fields(value)
+	return len(lim) >= 2 && isLimitsPlaceholder.MatchString(lim[0]+" "+lim[1])
+}
+
+// limitsPattern returns a regular expression matching the fields 'domain
+// type item' of the limits definitions, which fit to a limits definition
+// with placeholders, and the names of the placeholders in the order of
+// the sub matches
+func limitsPattern(value string) (*regexp.Regexp, []string) {
+	lim := strings.Fields(value)
+	names := []string{}
+	fields := make([]string, 0, 3)
+	for _, field := range lim[:2] {
+		pattern := ""
+		last := 0
+		for _, loc := range isLimitsPlaceholder.FindAllStringSubmatchIndex(field, -1) {
+			pattern = pattern + regexp.QuoteMeta(field[last:loc[0]]) + `(\S+)`
+			names = append(names, field[loc[2]:loc[3]])
+			last = loc[1]
+		}
+		fields = append(fields, pattern+regexp.QuoteMeta(field[last:]))
+	}
+	fields = append(fields, regexp.QuoteMeta(lim[2]))
+	return regexp.MustCompile("^" + strings.Join(fields, " ") + "$"), names
+}
+
+// matchLimitsPlaceholder checks, if a concrete limits definition fits to
+// a limits definition with placeholders and returns the limits definition
+// with the domain and type of the concrete limits definition
+func matchLimitsPlaceholder(value, concrete string) (string, bool) {
+	lim := strings.Fields(value)
+	clim := strings.Fields(concrete)
+	if len(lim) < 3 || len(clim) < 3 || hasLimitsPlaceholder(concrete) {
+		return "", false
+	}
+	pattern, _ := limitsPattern(value)
+	if !pattern.MatchString(strings.Join(clim[:3], " ")) {
+		return "", false
+	}
+	return strings.Join(append(clim[:3], lim[3:]...), " "), true
+}
+
+// substLimitsPlaceholder replaces the placeholders of a limits definition
+// by all combinations of their values. Returns false, if the value of a
+// placeholder is not defined
+func substLimitsPlaceholder(value string, values map[string][]string) ([]string, string, bool) {
+	lim := strings.Fields(value)
+	_, names := limitsPattern(value)
+	defs := []string{strings.Join(lim[:2], " ")}
+	for _, name := range names {
+		vals := values[name]
+		if len(vals) == 0 {
+			return nil, name, false
+		}
+		substDefs := []string{}
+		for _, def := range defs {
+			for _, val := range vals {
+				substDefs = append(substDefs, strings.Replace(def, "<"+name+">", val, 1))
+			}
+		}
+		defs = substDefs
+	}
+	for idx := range defs {
+		defs[idx] = strings.Join(append([]string{defs[idx]}, lim[2:]...), " ")
+	}
+	return defs, "", true
+}
+
+// limitsEntry returns the entry of a concrete limits definition
+func limitsEntry(entry txtparser.INIEntry, value string) txtparser.INIEntry {
+	lim := strings.Fields(value)
+	entry.Key = "LIMIT_" + strings.Join(lim[:3], "_")
+	entry.Value = value
+	return entry
+}
+
+// LimitsPlaceholderValues returns the values of the placeholders from the
+// variables 'LIMITS_<name>' of the saptune sysconfig file. A variable can
+// contain a list of values separated by spaces or ','
+func LimitsPlaceholderValues(sysconf *txtparser.Sysconfig) map[string][]string {
+	values := make(map[string][]string)
+	if sysconf == nil {
+		return values
+	}
+	for _, entry := range sysconf.AllValues {
+		if !strings.HasPrefix(entry.Key, LimitsPlaceholderPrefix) {
+			continue
+		}
+		values[strings.TrimPrefix(entry.Key, LimitsPlaceholderPrefix)] = strings.FieldsFunc(entry.Value, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	}
+	return values
+}
+
+// resolveLimitsPlaceholders replaces the limits definitions with
+// placeholders by concrete limits definitions. The limits definitions of
+// the override file, which fit to the limits definition with placeholders,
+// win against the values of the variables in /etc/sysconfig/saptune.
+// A limits definition with a placeholder without value is skipped
+func resolveLimitsPlaceholders(entries []txtparser.INIEntry, over *txtparser.INIFile, values map[string][]string) []txtparser.INIEntry {
+	resolved := make([]txtparser.INIEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Section != INISectionLimits || !hasLimitsPlaceholder(entry.Value) || len(strings.Fields(entry.Value)) < 4 {
+			resolved = append(resolved, entry)
+			continue
+		}
+		found := false
+		if over != nil {
+			for _, oentry := range over.AllValues {
+				if oentry.Section != INISectionLimits {
+					continue
+				}
+				if value, ok := matchLimitsPlaceholder(entry.Value, oentry.Value); ok {
+					resolved = append(resolved, limitsEntry(entry, value))
+					found = true
+				}
+			}
+		}
+		if found {
+			continue
+		}
+		defs, name, ok := substLimitsPlaceholder(entry.Value, values)
+		if !ok {
+			system.WarningLog("limits definition '%s' skipped, as the placeholder '<%s>' is not defined. Please define the limit in an override file or the variable '%s%s' in /etc/sysconfig/saptune", entry.Value, name, LimitsPlaceholderPrefix, name)
+			continue
+		}
+		for _, value := range defs {
+			resolved = append(resolved, limitsEntry(entry, value))
+		}
+	}
+	return resolved
+}
+
+// resolveINILimitsPlaceholders resolves the placeholders of the limits
+// definitions of a Note definition file or an override file
+func resolveINILimitsPlaceholders(ini, over *txtparser.INIFile, values map[string][]string) {
+	ini.AllValues = resolveLimitsPlaceholders(ini.AllValues, over, values)
+	if _, ok := ini.KeyValue[INISectionLimits]; !ok {
+		return
+	}
+	limits := make(map[string]txtparser.INIEntry)
+	for _, entry := range ini.AllValues {
+		if entry.Section == INISectionLimits {
+			limits[entry.Key] = entry
+		}
+	}
+	ini.KeyValue[INISectionLimits] = limits
+}
+
+// expandLimitsPlaceholders replaces the limits definitions with placeholders
+// by the concrete limits definitions resolved during Initialise, which are
+// available in SysctlParams. So 'Optimise' and 'Apply' ('revert' too) use
+// the same limits as 'Initialise' did. The concrete limits definitions of
+// the Note definition itself are not used for the expansion
+func (vend INISettings) expandLimitsPlaceholders(entries []txtparser.INIEntry) []txtparser.INIEntry {
+	concrete := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Section == INISectionLimits && !hasLimitsPlaceholder(entry.Value) {
+			concrete[entry.Key] = true
+		}
+	}
+	keys := make([]string, 0, len(vend.SysctlParams))
+	for key := range vend.SysctlParams {
+		if strings.HasPrefix(key, "LIMIT_") && !concrete[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	expanded := make([]txtparser.INIEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Section != INISectionLimits || !hasLimitsPlaceholder(entry.Value) || len(strings.Fields(entry.Value)) < 4 {
+			expanded = append(expanded, entry)
+			continue
+		}
+		for _, key := range keys {
+			if value, ok := matchLimitsPlaceholder(entry.Value, vend.SysctlParams[key]); ok {
+				expanded = append(expanded, limitsEntry(entry, value))
+			}
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,115 @@
+package note
+
+import (
+	"github.com/SUSE/saptune/txtparser"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestSubstLimitsPlaceholder(t *testing.T) {
+	if !hasLimitsPlaceholder("<DBMSuser> hard memlock unlimited") || !hasLimitsPlaceholder("@sapsys <type> nofile 65536") || hasLimitsPlaceholder("@sapsys soft nofile <value>") {
+		t.Fatal("wrong placeholder detection")
+	}
+	values := map[string][]string{"sid": {"ha1", "ha2"}, "type": {"soft", "hard"}}
+	defs, _, ok := substLimitsPlaceholder("<sid>adm <type> nofile 1048576", values)
+	exp := []string{"ha1adm soft nofile 1048576", "ha1adm hard nofile 1048576", "ha2adm soft nofile 1048576", "ha2adm hard nofile 1048576"}
+	if !ok || !reflect.DeepEqual(defs, exp) {
+		t.Fatalf("got '%+v', expected '%+v'\n", defs, exp)
+	}
+	if _, name, ok := substLimitsPlaceholder("<DBMSuser> hard memlock unlimited", values); ok || name != "DBMSuser" {
+		t.Fatalf("missing placeholder value not detected: %v, '%s'\n", ok, name)
+	}
+
+	if val, ok := matchLimitsPlaceholder("<sid>adm <type> nofile 1048576", "ha1adm soft nofile 65536"); !ok || val != "ha1adm soft nofile 1048576" {
+		t.Fatalf("got '%s', %v\n", val, ok)
+	}
+	for _, concrete := range []string{"ha1 soft nofile 65536", "ha1adm soft memlock 65536", "<sid>adm soft nofile 65536", "NA"} {
+		if val, ok := matchLimitsPlaceholder("<sid>adm <type> nofile 1048576", concrete); ok {
+			t.Fatalf("'%s' matches with result '%s'\n", concrete, val)
+		}
+	}
+}
+
+func TestResolveLimitsPlaceholders(t *testing.T) {
+	ini := txtparser.ParseINI("[limits]\nLIMITS = <DBMSuser> hard memlock unlimited, <DBMSuser> soft memlock unlimited, <sid>adm soft nofile 65536, @sapsys soft nofile 65536\n[sysctl]\nvm.swappiness = 10\n")
+	over := txtparser.ParseINI("[limits]\nLIMITS = sybase hard memlock 1024, sybase soft memlock 1024\n")
+	values := map[string][]string{"DBMSuser": {"dbuser"}}
+
+	// limits definitions of the override file win, <sid> not defined
+	resolveINILimitsPlaceholders(ini, over, values)
+	exp := []string{"LIMIT_sybase_hard_memlock", "LIMIT_sybase_soft_memlock", "LIMIT_@sapsys_soft_nofile", "vm.swappiness"}
+	keys := []string{}
+	for _, entry := range ini.AllValues {
+		keys = append(keys, entry.Key)
+	}
+	if !reflect.DeepEqual(keys, exp) {
+		t.Fatalf("got '%+v', expected '%+v'\n", keys, exp)
+	}
+	if ini.KeyValue["limits"]["LIMIT_sybase_hard_memlock"].Value != "sybase hard memlock unlimited" || len(ini.KeyValue["limits"]) != 3 {
+		t.Fatalf("wrong limits: %+v\n", ini.KeyValue["limits"])
+	}
+
+	// values of the sysconfig variables
+	entries := resolveLimitsPlaceholders(txtparser.ParseINI("[limits]\nLIMITS = <DBMSuser> hard memlock unlimited\n").AllValues, nil, values)
+	if len(entries) != 1 || entries[0].Key != "LIMIT_dbuser_hard_memlock" || entries[0].Value != "dbuser hard memlock unlimited" {
+		t.Fatalf("wrong limits: %+v\n", entries)
+	}
+}
+
+func TestExpandLimitsPlaceholders(t *testing.T) {
+	vend := INISettings{SysctlParams: map[string]string{
+		"LIMIT_sybase_hard_memlock":  "sybase hard memlock 1024",
+		"LIMIT_@sapsys_hard_memlock": "@sapsys hard memlock 2048",
+		"vm.swappiness":              "60",
+	}}
+	entries := txtparser.ParseINI("[limits]\nLIMITS = <DBMSuser> hard memlock unlimited, @sapsys hard memlock 4096\n").AllValues
+	expanded := vend.expandLimitsPlaceholders(entries)
+	if len(expanded) != 2 || expanded[0].Key != "LIMIT_sybase_hard_memlock" || expanded[0].Value != "sybase hard memlock unlimited" || expanded[1].Key != "LIMIT_@sapsys_hard_memlock" || expanded[1].Value != "@sapsys hard memlock 4096" {
+		t.Fatalf("wrong limits: %+v\n", expanded)
+	}
+}
+
+func TestLimitsPlaceholderValues(t *testing.T) {
+	sysconf, err := txtparser.ParseSysconfig("TUNE_FOR_NOTES=\"1805750\"\nLIMITS_DBMSuser=\"sybase\"\nLIMITS_sid=\"ha1, ha2\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string][]string{"DBMSuser": {"sybase"}, "sid": {"ha1", "ha2"}}
+	values := LimitsPlaceholderValues(sysconf)
+	if !reflect.DeepEqual(values, exp) {
+		t.Fatalf("got '%+v', expected '%+v'\n", values, exp)
+	}
+	if values := LimitsPlaceholderValues(nil); len(values) != 0 {
+		t.Fatal(values)
+	}
+
+	// Initialise and Optimise use the resolved limits definitions
+	noteFile := path.Join(os.TempDir(), "saptune_placeholder_note")
+	defer os.Remove(noteFile)
+	if err := ioutil.WriteFile(noteFile, []byte("[limits]\nLIMITS = <DBMSuser> hard memlock unlimited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ini := INISettings{ConfFilePath: noteFile, ID: "saptune_placeholder_note", ValuesToApply: map[string]string{"verify": "verify"}, LimitsPlaceholders: values}
+	current, err := ini.Initialise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := current.(INISettings).SysctlParams["LIMIT_sybase_hard_memlock"]; !ok {
+		t.Fatalf("limit not resolved: %+v\n", current.(INISettings).SysctlParams)
+	}
+	optimised, err := current.Optimise()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := optimised.(INISettings).SysctlParams["LIMIT_sybase_hard_memlock"]; val != "sybase hard memlock unlimited" {
+		t.Fatalf("wrong optimised limit '%s'\n", val)
+	}
+	// the values of the placeholders are no tuning values
+	_, comparisons, _ := CompareNoteFields(current, optimised)
+	if _, ok := comparisons["LimitsPlaceholders[DBMSuser]"]; ok {
+		t.Fatalf("placeholder values compared: %+v\n", comparisons)
+	}
+}
@@ -0,0 +1,265 @@
+package note
+
+// Value specifications of parameters in Note definition files, which
+// define the allowed values of a parameter instead of a fixed value like
+// 'vm.swappiness = 10..60' or 'net.ipv4.tcp_rmem = >=4096 * >=16M'
+
+import (
+	"fmt"
+	"github.com/SUSE/saptune/system"
+	"github.com/SUSE/saptune/txtparser"
+	"math/big"
+	"strings"
+)
+
+// fieldOperators are the operators, which can prefix a single field of a
+// value specification. Longer operators first
+var fieldOperators = []string{"!=", "<=", ">=", "<", ">"}
+
+// fieldSpec is the specification of a single field of a parameter value
+type fieldSpec struct {
+	op    string
+	any   bool     // '*', any value
+	rng   []string // 'min..max', min or max can be empty
+	set   []string // 'a|b|c'
+	value string
+}
+
+// IsValueSpec checks, if a parameter value is a value specification
+// instead of a fixed value. That is the operator '!=' or a value with a
+// range 'min..max', a set 'a|b', the wildcard '*' or a field operator
+// like '>=4096' in one of its fields
+func IsValueSpec(operator txtparser.Operator, value string) bool {
+	if operator == txtparser.OperatorNotEqual {
+		return true
+	}
+	for _, field := range strings.Fields(value) {
+		spec := parseFieldSpec(field)
+		if spec.op != "" || spec.any || spec.rng != nil || spec.set != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldSpec parses the specification of a single field
+func parseFieldSpec(field string) fieldSpec {
+	spec := fieldSpec{value: field}
+	for _, op := range fieldOperators {
+		if strings.HasPrefix(field, op) && len(field) > len(op) {
+			spec.op = op
+			field = field[len(op):]
+			spec.value = field
+			break
+		}
+	}
+	switch {
+	case field == "*":
+		spec.any = true
+	case strings.Count(field, "..") == 1:
+		rng := strings.SplitN(field, "..", 2)
+		if (rng[0] != "" || rng[1] != "") && (rng[0] == "" || system.IsNumeric(rng[0])) && (rng[1] == "" || system.IsNumeric(rng[1])) {
+			spec.rng = rng
+		}
+	case strings.Contains(field, "|"):
+		set := strings.Split(field, "|")
+		for _, elem := range set {
+			if elem == "" {
+				// no set, e.g. kernel.core_pattern = |/usr/lib/...
+				return spec
+			}
+		}
+		spec.set = set
+	}
+	return spec
+}
+
+// parseValueSpec splits a value specification into the specifications of
+// the fields. Fields without own operator get the operator of the entry
+func parseValueSpec(operator txtparser.Operator, value string) ([]fieldSpec, error) {
+	specs := []fieldSpec{}
+	for _, field := range strings.Fields(value) {
+		spec := parseFieldSpec(field)
+		if spec.any && spec.op != "" {
+			return specs, fmt.Errorf("operator '%s' not supported for '*'", spec.op)
+		}
+		if spec.op == "" {
+			spec.op = string(operator)
+		}
+		if spec.op == "==" {
+			spec.op = "="
+		}
+		if (spec.rng != nil || spec.set != nil) && spec.op != "=" && spec.op != txtparser.OperatorNotEqual {
+			return specs, fmt.Errorf("operator '%s' not supported for '%s', only '=' or '!='", spec.op, spec.value)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return specs, fmt.Errorf("empty value specification")
+	}
+	return specs, nil
+}
+
+// cmpNumOrString compares two values numerically, if both are numeric
+// (with optional unit suffix), otherwise as strings. Returns -1, 0 or 1
+// and if the comparison was numeric
+func cmpNumOrString(val1, val2 string) (int, bool) {
+	num1, err1 := system.ParseNumber(val1)
+	num2, err2 := system.ParseNumber(val2)
+	if err1 != nil || err2 != nil {
+		return strings.Compare(val1, val2), false
+	}
+	return num1.Cmp(num2), true
+}
+
+// matchField checks, if the current value of a field matches the field
+// specification
+func (spec fieldSpec) matchField(curval string) (bool, error) {
+	switch {
+	case spec.any:
+		return true, nil
+	case spec.rng != nil:
+		cur, err := system.ParseNumber(curval)
+		if err != nil {
+			return false, fmt.Errorf("range '%s': current %v", spec.value, err)
+		}
+		inRange := true
+		if spec.rng[0] != "" {
+			low, _ := system.ParseNumber(spec.rng[0])
+			inRange = cur.Cmp(low) >= 0
+		}
+		if spec.rng[1] != "" {
+			high, _ := system.ParseNumber(spec.rng[1])
+			inRange = inRange && cur.Cmp(high) <= 0
+		}
+		return inRange == (spec.op == "="), nil
+	case spec.set != nil:
+		inSet := false
+		for _, elem := range spec.set {
+			if cmp, _ := cmpNumOrString(curval, elem); cmp == 0 {
+				inSet = true
+				break
+			}
+		}
+		return inSet == (spec.op == "="), nil
+	}
+	cmp, numeric := cmpNumOrString(curval, spec.value)
+	switch spec.op {
+	case "=":
+		return cmp == 0, nil
+	case txtparser.OperatorNotEqual:
+		return cmp != 0, nil
+	}
+	if !numeric {
+		// '<', '<=', '>', '>=' need numbers
+		if _, err := system.ParseNumber(spec.value); err != nil {
+			return false, fmt.Errorf("operator '%s': expected %v", spec.op, err)
+		}
+		_, err := system.ParseNumber(curval)
+		return false, fmt.Errorf("operator '%s': current %v", spec.op, err)
+	}
+	switch spec.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator '%s'", spec.op)
+}
+
+// optField returns a value for the field, which matches the field
+// specification. A matching current value is kept
+func (spec fieldSpec) optField(curval string) (string, error) {
+	match, err := spec.matchField(curval)
+	if match {
+		return curval, nil
+	}
+	switch {
+	case spec.op == txtparser.OperatorNotEqual:
+		if err != nil {
+			return curval, err
+		}
+		return curval, fmt.Errorf("no value can be computed for '%s%s'", spec.op, spec.value)
+	case spec.rng != nil:
+		// current value not numeric or outside of the range
+		cur, err := system.ParseNumber(curval)
+		if spec.rng[0] != "" {
+			low, _ := system.ParseNumber(spec.rng[0])
+			if err != nil || cur.Cmp(low) < 0 || spec.rng[1] == "" {
+				return low.String(), nil
+			}
+		}
+		high, _ := system.ParseNumber(spec.rng[1])
+		return high.String(), nil
+	case spec.set != nil:
+		return spec.set[0], nil
+	}
+	num, err := system.ParseNumber(spec.value)
+	if spec.op == "=" {
+		if err != nil {
+			// not numeric, use the value as it is
+			return spec.value, nil
+		}
+		return num.String(), nil
+	}
+	if err != nil {
+		return curval, fmt.Errorf("operator '%s': expected %v", spec.op, err)
+	}
+	switch spec.op {
+	case "<":
+		num.Sub(num, big.NewInt(1))
+	case ">":
+		num.Add(num, big.NewInt(1))
+	}
+	return num.String(), nil
+}
+
+// MatchValueSpec checks, if the current value of a parameter matches the
+// value specification. Multi-field values are compared field by field
+func MatchValueSpec(operator txtparser.Operator, curval, value string) (bool, error) {
+	specs, err := parseValueSpec(operator, value)
+	if err != nil {
+		return false, err
+	}
+	curFields := strings.Fields(curval)
+	if len(curFields) != len(specs) {
+		return false, fmt.Errorf("value '%s' has %d fields, value specification '%s' has %d fields", curval, len(curFields), value, len(specs))
+	}
+	for idx, spec := range specs {
+		match, err := spec.matchField(curFields[idx])
+		if err != nil {
+			return false, fmt.Errorf("field %d: %v", idx+1, err)
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// OptValueSpec computes a parameter value, which matches the value
+// specification. Fields of the current value, which already match, are
+// kept. The fields of the result are separated by tabs
+func OptValueSpec(operator txtparser.Operator, curval, value string) (string, error) {
+	specs, err := parseValueSpec(operator, value)
+	if err != nil {
+		return curval, err
+	}
+	curFields := strings.Fields(curval)
+	if len(curFields) != len(specs) {
+		return curval, fmt.Errorf("value '%s' has %d fields, value specification '%s' has %d fields", curval, len(curFields), value, len(specs))
+	}
+	optFields := make([]string, 0, len(specs))
+	for idx, spec := range specs {
+		field, err := spec.optField(curFields[idx])
+		if err != nil {
+			return curval, fmt.Errorf("field %d: %v", idx+1, err)
+		}
+		optFields = append(optFields, field)
+	}
+	return strings.Join(optFields, "\t"), nil
+}
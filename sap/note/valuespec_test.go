@@ -0,0 +1,107 @@
+package note
+
+import (
+	"github.com/SUSE/saptune/txtparser"
+	"strings"
+	"testing"
+)
+
+func TestIsValueSpec(t *testing.T) {
+	specs := map[string]txtparser.Operator{
+		"10..60":              "=",
+		"..60":                "=",
+		"16M..":               "=",
+		"never|madvise":       "=",
+		"0":                   "!=",
+		">=4096 * >=16M":      "=",
+		"4096 87380 <=16M":    "=",
+		"* * * 128":           "=",
+		"!=0":                 "=",
+		"1250 256000 10..100": "=",
+	}
+	for value, op := range specs {
+		if !IsValueSpec(op, value) {
+			t.Fatal(op, value)
+		}
+	}
+	noSpecs := map[string]txtparser.Operator{
+		"60":                                    "=",
+		"128":                                   ">=",
+		"1250 256000 100 8192":                  ">=",
+		"|/usr/lib/systemd/systemd-coredump %P": "=",
+		"a..b":                                  "=",
+		"/var/crash/..":                         "=",
+		"-1":                                    "<",
+	}
+	for value, op := range noSpecs {
+		if IsValueSpec(op, value) {
+			t.Fatal(op, value)
+		}
+	}
+}
+
+func TestMatchValueSpec(t *testing.T) {
+	type specTest struct {
+		op     txtparser.Operator
+		curval string
+		spec   string
+		match  bool
+		optval string
+	}
+	tests := []specTest{
+		{"=", "30", "10..60", true, "30"},
+		{"=", "5", "10..60", false, "10"},
+		{"=", "70", "10..60", false, "60"},
+		{"=", "70", "..60", false, "60"},
+		{"=", "5", "16M..", false, "16777216"},
+		{"=", "16777216", "16M..", true, "16777216"},
+		{"!=", "70", "10..60", true, "70"},
+		{"=", "madvise", "never|madvise", true, "madvise"},
+		{"=", "always", "never|madvise", false, "never"},
+		{"!=", "always", "never|madvise", true, "always"},
+		{"=", "1024", "512|1K", true, "1024"},
+		{"!=", "1", "0", true, "1"},
+		{"=", "4096	87380	6291456", ">=4096 * >=16M", false, "4096	87380	16777216"},
+		{"=", "8192	87380	16777216", ">=4096 * >=16M", true, "8192	87380	16777216"},
+		{">=", "4096	87380	6291456", "4096 * 16M", false, "4096	87380	16777216"},
+		{"=", "250	32000	32	128", "* * >=100 128", false, "250	32000	100	128"},
+		{"=", "250	32000	32	128", "* * >32 <=128", false, "250	32000	33	128"},
+		{"=", "250	32000	33	127", "* * >32 <128", true, "250	32000	33	127"},
+		{"=", "18446744073709551615", ">=18446744073709551615", true, "18446744073709551615"},
+		{"=", "2", "!=2|3", false, "2"},
+	}
+	for _, tst := range tests {
+		match, err := MatchValueSpec(tst.op, tst.curval, tst.spec)
+		if err != nil || match != tst.match {
+			t.Fatalf("'%s' '%s' '%s': got '%v', expected '%v' - %v\n", tst.curval, tst.op, tst.spec, match, tst.match, err)
+		}
+		if tst.spec == "!=2|3" {
+			continue
+		}
+		optval, err := OptValueSpec(tst.op, tst.curval, tst.spec)
+		if err != nil || optval != tst.optval {
+			t.Fatalf("'%s' '%s' '%s': got '%v', expected '%v' - %v\n", tst.curval, tst.op, tst.spec, optval, tst.optval, err)
+		}
+	}
+
+	errTests := []specTest{
+		{"=", "abc", ">=4096", false, "field 1: operator '>=': current value 'abc' is not numeric"},
+		{"=", "4096", ">=abc|def", false, "operator '>=' not supported for 'abc|def', only '=' or '!='"},
+		{"=", "4096", ">*", false, "operator '>' not supported for '*'"},
+		{"=", "abc", "10..60", false, "field 1: range '10..60': current value 'abc' is not numeric"},
+		{"=", "4096 87380", ">=4096 * >=16M", false, "value '4096 87380' has 2 fields, value specification '>=4096 * >=16M' has 3 fields"},
+		{"=", "4096", "", false, "empty value specification"},
+	}
+	for _, tst := range errTests {
+		match, err := MatchValueSpec(tst.op, tst.curval, tst.spec)
+		if match || err == nil || err.Error() != tst.optval {
+			t.Fatalf("'%s' '%s' '%s': expected error '%s', got '%v' - %v\n", tst.curval, tst.op, tst.spec, tst.optval, match, err)
+		}
+	}
+	if optval, err := OptValueSpec("!=", "0", "0"); err == nil || err.Error() != "field 1: no value can be computed for '!=0'" || optval != "0" {
+		t.Fatal(optval, err)
+	}
+	if optval, err := OptValueSpec("=", "100", "<abc"); err == nil || !strings.Contains(err.Error(), "expected value 'abc' is not numeric") || optval != "100" {
+		t.Fatal(optval, err)
+	}
+}
@@ -11,10 +11,24 @@ import (
 )
 
 // BlockDeviceQueue is the data structure for block devices
-// for schedulers and IO nr_request changes
+// for schedulers, IO nr_request and additional queue tunable changes
 type BlockDeviceQueue struct {
 	BlockDeviceSchedulers
 	BlockDeviceNrRequests
+	BlockDeviceTunables
+}
+
+// BlockTunables are the additional queue tunables of a block device, which
+// are supported in the [block] section. Additional the scheduler specific
+// tunables below 'queue/iosched/' are supported
+var BlockTunables = []string{"read_ahead_kb", "max_sectors_kb", "rq_affinity", "nomerges", "add_random"}
+
+// blockTunableRange contains the valid values of the queue tunables with a
+// restricted range of values
+var blockTunableRange = map[string][2]int{
+	"rq_affinity": {0, 2},
+	"nomerges":    {0, 2},
+	"add_random":  {0, 1},
 }
 
 // BlockDeviceSchedulers changes IO elevators on all IO devices
@@ -107,8 +121,26 @@ func (ior BlockDeviceNrRequests) Inspect() (Parameter, error) {
 }
 
 // Optimise gets the expected nr_requests value from the configuration
+// an int value is used for all block devices, a string value like
+// 'sda 1024' only for the named block device
 func (ior BlockDeviceNrRequests) Optimise(newNrRequestValue interface{}) (Parameter, error) {
 	newIOR := BlockDeviceNrRequests{NrRequests: make(map[string]int)}
+	if devReq, ok := newNrRequestValue.(string); ok {
+		for k, v := range ior.NrRequests {
+			newIOR.NrRequests[k] = v
+		}
+		fields := strings.Fields(devReq)
+		if len(fields) > 1 {
+			nrreq, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return newIOR, err
+			}
+			if _, ok := ior.NrRequests[fields[0]]; ok {
+				newIOR.NrRequests[fields[0]] = nrreq
+			}
+		}
+		return newIOR, nil
+	}
 	for k := range ior.NrRequests {
 		newIOR.NrRequests[k] = newNrRequestValue.(int)
 	}
@@ -129,6 +161,135 @@ func (ior BlockDeviceNrRequests) Apply() error {
 	return err
 }
 
+// BlockDeviceTunables changes additional queue tunables like read_ahead_kb
+// or the scheduler specific tunables in 'queue/iosched' on all block devices
+// Tunables maps the block device to the tunable and its value
+type BlockDeviceTunables struct {
+	Tunables map[string]map[string]string
+}
+
+// Inspect retrieves the current queue tunables from the system
+// For the scheduler specific tunables only the tunables of the currently
+// active scheduler are available
+func (iot BlockDeviceTunables) Inspect() (Parameter, error) {
+	newIOT := BlockDeviceTunables{Tunables: make(map[string]map[string]string)}
+	// List /sys/block and inspect the queue tunables of each one
+	dirContent, err := ioutil.ReadDir("/sys/block")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range dirContent {
+		if strings.Contains(entry.Name(), "dm-") {
+			// skip unsupported devices
+			continue
+		}
+		tunables := make([]string, 0, len(BlockTunables))
+		tunables = append(tunables, BlockTunables...)
+		_, ioscheds := system.ListDir(path.Join("/sys/block", entry.Name(), "queue", "iosched"), "")
+		for _, iosched := range ioscheds {
+			tunables = append(tunables, path.Join("iosched", iosched))
+		}
+		for _, tunable := range tunables {
+			if val := GetBlockTunable(entry.Name(), tunable); val != "NA" {
+				newIOT.SetTunable(entry.Name(), tunable, val)
+			}
+		}
+	}
+	return newIOT, nil
+}
+
+// Optimise gets the expected queue tunable value from the configuration
+// The value needs to be a string like 'sda read_ahead_kb 4096'
+// Only already known block devices and tunables are changed
+func (iot BlockDeviceTunables) Optimise(newTunableValue interface{}) (Parameter, error) {
+	newIOT := BlockDeviceTunables{Tunables: make(map[string]map[string]string)}
+	for bdev, tunables := range iot.Tunables {
+		for tunable, val := range tunables {
+			newIOT.SetTunable(bdev, tunable, val)
+		}
+	}
+	fields := strings.Fields(newTunableValue.(string))
+	if len(fields) != 3 {
+		return newIOT, fmt.Errorf("wrong queue tunable setting '%s'", newTunableValue.(string))
+	}
+	if _, ok := iot.Tunables[fields[0]][fields[1]]; ok {
+		newIOT.Tunables[fields[0]][fields[1]] = fields[2]
+	}
+	return newIOT, nil
+}
+
+// Apply sets the new queue tunable values in the system
+// Tunables not available on the system ('NA') are skipped
+func (iot BlockDeviceTunables) Apply() error {
+	errs := make([]error, 0, 0)
+	for name, tunables := range iot.Tunables {
+		for tunable, val := range tunables {
+			if val == "NA" || val == "" {
+				continue
+			}
+			if cur := GetBlockTunable(name, tunable); cur == val {
+				continue
+			}
+			if !IsValidBlockTunable(name, tunable, val) {
+				system.WarningLog("skipping device '%s', not valid for setting '%s' to '%v'", name, tunable, val)
+				continue
+			}
+			errs = append(errs, system.SetSysString(path.Join("block", name, "queue", tunable), val))
+		}
+	}
+	err := sap.PrintErrors(errs)
+	return err
+}
+
+// SetTunable sets the value of a queue tunable of a block device in the
+// block device structure without applying it to the system
+func (iot *BlockDeviceTunables) SetTunable(bdev, tunable, value string) {
+	if iot.Tunables == nil {
+		iot.Tunables = make(map[string]map[string]string)
+	}
+	if _, ok := iot.Tunables[bdev]; !ok {
+		iot.Tunables[bdev] = make(map[string]string)
+	}
+	iot.Tunables[bdev][tunable] = value
+}
+
+// GetBlockTunable returns the current value of a queue tunable of a block
+// device or 'NA', if the tunable is not available for the block device
+func GetBlockTunable(blockdev, tunable string) string {
+	val, err := ioutil.ReadFile(path.Join("/sys/block", blockdev, "queue", tunable))
+	if err != nil {
+		return "NA"
+	}
+	return strings.TrimSpace(string(val))
+}
+
+// IsValidBlockTunable checks, if the value of the queue tunable is supported
+// by the system
+func IsValidBlockTunable(blockdev, tunable, value string) bool {
+	if GetBlockTunable(blockdev, tunable) == "NA" {
+		return false
+	}
+	ival, err := strconv.Atoi(value)
+	if err != nil || ival < 0 {
+		return false
+	}
+	if vrange, ok := blockTunableRange[tunable]; ok && (ival < vrange[0] || ival > vrange[1]) {
+		return false
+	}
+	if tunable == "max_sectors_kb" {
+		// max_sectors_kb can not exceed max_hw_sectors_kb
+		maxhw, err := strconv.Atoi(GetBlockTunable(blockdev, "max_hw_sectors_kb"))
+		if err != nil || ival > maxhw || ival == 0 {
+			return false
+		}
+	}
+	file := path.Join("block", blockdev, "queue", tunable)
+	if tstErr := system.TestSysString(file, value); tstErr != nil {
+		return false
+	}
+	return true
+}
+
 // IsValidScheduler checks, if the scheduler value is supported by the system
 func IsValidScheduler(blockdev, scheduler string) bool {
 	val, err := ioutil.ReadFile(path.Join("/sys/block/", blockdev, "/queue/scheduler"))
@@ -1,6 +1,7 @@
 package param
 
 import (
+	"github.com/SUSE/saptune/system"
 	"io/ioutil"
 	"path"
 	"testing"
@@ -146,6 +147,101 @@ func TestNrRequests(t *testing.T) {
 	t.Logf("reverted - '%+v'\n", rev)
 }
 
+func TestNrRequestsOptimiseDevice(t *testing.T) {
+	ior := BlockDeviceNrRequests{NrRequests: map[string]int{"sda": 128, "sdb": 128}}
+	optimised, err := ior.Optimise("sdb 64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrreq := optimised.(BlockDeviceNrRequests).NrRequests
+	if nrreq["sda"] != 128 || nrreq["sdb"] != 64 {
+		t.Fatal(optimised)
+	}
+	if ior.NrRequests["sdb"] != 128 {
+		t.Fatal(ior)
+	}
+	optimised, err = ior.Optimise("sdc 64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := optimised.(BlockDeviceNrRequests).NrRequests["sdc"]; ok {
+		t.Fatal(optimised)
+	}
+	if _, err = ior.Optimise("sda many"); err == nil {
+		t.Fatal(optimised)
+	}
+}
+
+func TestBlockDeviceTunables(t *testing.T) {
+	inspected, err := BlockDeviceTunables{}.Inspect()
+	if err != nil {
+		t.Fatal(err, inspected)
+	}
+	if len(inspected.(BlockDeviceTunables).Tunables) == 0 {
+		t.Skip("the test case will not continue because inspection result turns out empty")
+	}
+	for name, tunables := range inspected.(BlockDeviceTunables).Tunables {
+		if name == "" || tunables["read_ahead_kb"] == "" {
+			t.Fatal(inspected)
+		}
+		for tunable, val := range tunables {
+			if val != GetBlockTunable(name, tunable) {
+				t.Fatal(name, tunable, val)
+			}
+		}
+	}
+	iot := BlockDeviceTunables{}
+	iot.SetTunable("sda", "read_ahead_kb", "128")
+	iot.SetTunable("sda", "iosched/read_expire", "NA")
+	optimised, err := iot.Optimise("sda read_ahead_kb 4096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if optimised.(BlockDeviceTunables).Tunables["sda"]["read_ahead_kb"] != "4096" || iot.Tunables["sda"]["read_ahead_kb"] != "128" {
+		t.Fatal(optimised, iot)
+	}
+	optimised, err = iot.Optimise("sda iosched/read_expire 100")
+	if err != nil || optimised.(BlockDeviceTunables).Tunables["sda"]["iosched/read_expire"] != "100" {
+		t.Fatal(optimised, err)
+	}
+	optimised, err = iot.Optimise("sdb read_ahead_kb 4096")
+	if err != nil || len(optimised.(BlockDeviceTunables).Tunables["sdb"]) != 0 {
+		t.Fatal(optimised, err)
+	}
+	if _, err = iot.Optimise("sda read_ahead_kb"); err == nil {
+		t.Fatal("missing value accepted")
+	}
+}
+
+func TestIsValidBlockTunable(t *testing.T) {
+	if GetBlockTunable("not_avail", "read_ahead_kb") != "NA" {
+		t.Fatal("tunable of not existing block device available")
+	}
+	if IsValidBlockTunable("not_avail", "read_ahead_kb", "128") {
+		t.Fatal("not existing block device is valid")
+	}
+	_, sysDevs := system.ListDir("/sys/block", "")
+	for _, bdev := range sysDevs {
+		if GetBlockTunable(bdev, "rq_affinity") == "NA" {
+			continue
+		}
+		for _, wrong := range []string{"3", "-1", "hugo"} {
+			if IsValidBlockTunable(bdev, "rq_affinity", wrong) {
+				t.Fatalf("'%s' is a valid value of 'rq_affinity' for '%s'\n", wrong, bdev)
+			}
+		}
+		if IsValidBlockTunable(bdev, "add_random", "2") {
+			t.Fatalf("'2' is a valid value of 'add_random' for '%s'\n", bdev)
+		}
+		if IsValidBlockTunable(bdev, "max_sectors_kb", "0") {
+			t.Fatalf("'0' is a valid value of 'max_sectors_kb' for '%s'\n", bdev)
+		}
+		if !IsValidBlockTunable(bdev, "rq_affinity", "1") {
+			t.Logf("'1' is not a valid value of 'rq_affinity' for '%s'\n", bdev)
+		}
+	}
+}
+
 func TestIsValidScheduler(t *testing.T) {
 	scheduler := ""
 	dirCont, err := ioutil.ReadDir("/sys/block")
@@ -0,0 +1,108 @@
+package solution
+
+// The solution catalog contains the solutions of all architectures read
+// from the solution definition file, the solution definition files, the
+// override files and the deprecation file. The catalog is loaded from
+// configurable locations, so alternate locations can be used for tests or
+// when embedding saptune
+
+import (
+	"path"
+	"sort"
+)
+
+// CatalogPaths are the locations of the files of the solution catalog
+type CatalogPaths struct {
+	SolutionFile   string // solution definition file
+	SolutionDir    string // directory of the shipped solution definition files
+	ExtraDir       string // directory of the customer or vendor specific solution definition files
+	OverrideDir    string // directory of the override files
+	DeprecatedFile string // deprecated solution file
+	NoteDir        string // directory of the Note definition files
+}
+
+// DefaultCatalogPaths returns the standard locations of the files of the
+// solution catalog
+func DefaultCatalogPaths() CatalogPaths {
+	return CatalogPaths{
+		SolutionFile:   SolutionSheet,
+		SolutionDir:    SolutionSheets,
+		ExtraDir:       ExtraSolutionSheets,
+		OverrideDir:    OverrideSolutionSheets,
+		DeprecatedFile: DeprecSolutionSheet,
+		NoteDir:        NoteTuningSheets,
+	}
+}
+
+// Catalog contains the available solutions with their related SAP Notes,
+// the override solutions and the deprecated solutions per architecture
+// and the solution definitions of the solution definition files
+type Catalog struct {
+	Definitions map[string]SolutionDefinition     // solution name VS solution definition
+	Solutions   map[string]map[string]Solution    // architecture VS solution name VS notes
+	Overrides   map[string]map[string]Solution    // architecture VS solution name VS notes
+	Deprecated  map[string]map[string]Deprecation // architecture VS solution name VS deprecation
+}
+
+// LoadCatalog reads the solution catalog from the given locations.
+// Solutions of the solution definition file are replaced by their override
+// solutions
+func LoadCatalog(paths CatalogPaths) *Catalog {
+	cat := &Catalog{}
+	cat.Definitions = GetSolutionDefinitions(paths.SolutionDir, paths.ExtraDir, paths.OverrideDir)
+	cat.Overrides = addSolutionOverrides(GetOverrideSolution(path.Join(paths.OverrideDir, "solutions"), paths.NoteDir), GetSolutionOverrides(paths.OverrideDir))
+	sols := GetSolutionDefintion(paths.SolutionFile)
+	for arch, archSols := range sols {
+		for solName := range archSols {
+			if len(cat.Overrides[arch][solName]) != 0 {
+				archSols[solName] = cat.Overrides[arch][solName]
+			}
+		}
+	}
+	cat.Solutions = addSolutionDefinitions(sols, cat.Definitions)
+	cat.Deprecated = addDeprecatedSolutions(GetDeprecatedSolution(paths.DeprecatedFile), cat.Definitions)
+	return cat
+}
+
+// NewCatalog returns a catalog, which contains the given solutions for
+// the architecture only
+func NewCatalog(arch string, sols map[string]Solution) *Catalog {
+	return &Catalog{
+		Definitions: make(map[string]SolutionDefinition),
+		Solutions:   map[string]map[string]Solution{arch: sols},
+		Overrides:   make(map[string]map[string]Solution),
+		Deprecated:  make(map[string]map[string]Deprecation),
+	}
+}
+
+// ArchSolutions returns the solutions of the architecture. An empty map
+// is returned, if there are no solutions for the architecture
+func (cat *Catalog) ArchSolutions(arch string) map[string]Solution {
+	if sols, ok := cat.Solutions[arch]; ok {
+		return sols
+	}
+	return make(map[string]Solution)
+}
+
+// Deprecation returns the deprecation information of the solution, if
+// the solution is deprecated for the architecture
+func (cat *Catalog) Deprecation(arch, solName string) (Deprecation, bool) {
+	deprec, ok := cat.Deprecated[arch][solName]
+	return deprec, ok
+}
+
+// IsOverridden checks, if an override file exists for the solution
+func (cat *Catalog) IsOverridden(arch, solName string) bool {
+	return len(cat.Overrides[arch][solName]) != 0 || cat.Definitions[solName].OverrideFile != ""
+}
+
+// SortedSolutionNames returns all solution names of the architecture,
+// sorted alphabetically.
+func (cat *Catalog) SortedSolutionNames(arch string) (ret []string) {
+	ret = make([]string, 0, len(cat.Solutions[arch]))
+	for id := range cat.Solutions[arch] {
+		ret = append(ret, id)
+	}
+	sort.Strings(ret)
+	return
+}
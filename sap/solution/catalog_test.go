@@ -0,0 +1,60 @@
+package solution
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	paths := CatalogPaths{
+		SolutionFile:   path.Join(TstFilesInGOPATH, "saptune-test-solutions"),
+		SolutionDir:    solDir,
+		ExtraDir:       extraSolDir,
+		OverrideDir:    ovSolDir,
+		DeprecatedFile: path.Join(TstFilesInGOPATH, "saptune-test-deprecated-sols"),
+		NoteDir:        TstFilesInGOPATH + "/",
+	}
+	cat := LoadCatalog(paths)
+	if len(cat.Definitions) != 3 {
+		t.Fatal(cat.Definitions)
+	}
+	sols := cat.ArchSolutions(runtime.GOARCH)
+	// the override file wins against the solution definition file
+	if !reflect.DeepEqual(sols["HANA"], Solution{"941735", "2382421"}) || !cat.IsOverridden(runtime.GOARCH, "HANA") {
+		t.Fatal(sols["HANA"])
+	}
+	if !reflect.DeepEqual(sols["TSTSOL"], Solution{"941735", "1980196"}) || !cat.IsOverridden(runtime.GOARCH, "TSTSOL") {
+		t.Fatal(sols["TSTSOL"])
+	}
+	if cat.IsOverridden(runtime.GOARCH, "NETW") {
+		t.Fatal("solution 'NETW' is not overridden")
+	}
+	if deprec, ok := cat.Deprecation(runtime.GOARCH, "OLDSOL"); !ok || deprec.Successor != "TSTSOL" {
+		t.Fatal(cat.Deprecated)
+	}
+	if _, ok := cat.Deprecation(runtime.GOARCH, "NETW"); ok {
+		t.Fatal(cat.Deprecated)
+	}
+	names := cat.SortedSolutionNames(runtime.GOARCH)
+	if len(names) != len(sols) || names[0] != "BWA" {
+		t.Fatal(names)
+	}
+
+	cat = LoadCatalog(CatalogPaths{SolutionFile: "/saptune_file_not_avail", SolutionDir: "/saptune_dir_not_avail", ExtraDir: "/saptune_dir_not_avail", OverrideDir: "/saptune_dir_not_avail", DeprecatedFile: "/saptune_file_not_avail"})
+	if len(cat.ArchSolutions(runtime.GOARCH)) != 0 || len(cat.SortedSolutionNames(runtime.GOARCH)) != 0 {
+		t.Fatal(cat.Solutions)
+	}
+}
+
+func TestNewCatalog(t *testing.T) {
+	sols := map[string]Solution{"SOL1": Solution{"1001"}}
+	cat := NewCatalog(ArchX86, sols)
+	if !reflect.DeepEqual(cat.ArchSolutions(ArchX86), sols) || len(cat.ArchSolutions(ArchS390X)) != 0 {
+		t.Fatal(cat.Solutions)
+	}
+	if _, ok := cat.Deprecation(ArchX86, "SOL1"); ok || cat.IsOverridden(ArchX86, "SOL1") {
+		t.Fatal(cat)
+	}
+}
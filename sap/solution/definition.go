@@ -0,0 +1,385 @@
+package solution
+
+// Solution definitions as individual files '<name>.sol' with metadata like
+// description, supported architectures, the Notes of the solution and the
+// deprecation status. Shipped solution definitions are located in
+// /usr/share/saptune/sols, customer or vendor specific ones in
+// /etc/saptune/extra and the override files in /etc/saptune/override
+
+import (
+	"fmt"
+	"github.com/SUSE/saptune/system"
+	"github.com/SUSE/saptune/txtparser"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// solution definition file constant definitions
+const (
+	SolutionSheets         = "/usr/share/saptune/sols/"
+	ExtraSolutionSheets    = "/etc/saptune/extra/"
+	OverrideSolutionSheets = "/etc/saptune/override/"
+	SolutionTemplate       = "/usr/share/saptune/SolutionTemplate.sol"
+	SolutionFileSuffix     = ".sol"
+	SolutionSection        = "solution"
+)
+
+// keys of the [solution] section of a solution definition file
+const (
+	SolKeyName          = "NAME"
+	SolKeyVersion       = "VERSION"
+	SolKeyDate          = "DATE"
+	SolKeyDescription   = "DESCRIPTION"
+	SolKeyArchitectures = "ARCHITECTURES"
+	SolKeyNotes         = "NOTES"
+	SolKeyDeprecated    = "DEPRECATED"
+	SolKeyReplacement   = "REPLACEMENT"
+)
+
+var isSolutionName = regexp.MustCompile(`^[\w.+-]+$`)
+
+// isArchName matches a GOARCH value like amd64, arm64, ppc64le or s390x
+var isArchName = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// KnownArchs contains the architectures a solution definition without
+// ARCHITECTURES is added to. The running architecture is always added
+var KnownArchs = []string{ArchX86, ArchARM64, ArchPPC64LE, ArchS390X}
+
+// SolutionDefinition contains the metadata and the Notes of a solution
+// read from a solution definition file. 'Deprecated' contains the reason of
+// the deprecation or 'yes', it is empty for a solution, which is not
+// deprecated. An empty list of architectures means all architectures
+type SolutionDefinition struct {
+	Name          string
+	Version       string
+	Date          string
+	Description   string
+	Architectures []string
+	Notes         Solution
+	Deprecated    string
+	Replacement   string
+	FileName      string
+	Custom        bool   // customer or vendor specific solution
+	OverrideFile  string // override file, if available
+	keys          map[string]bool
+}
+
+// ParseSolution reads the solution definition from the content of a
+// solution definition file
+func ParseSolution(content string) SolutionDefinition {
+	def := SolutionDefinition{keys: make(map[string]bool)}
+	currentSection := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			currentSection = line[1 : len(line)-1]
+			continue
+		}
+		if currentSection != SolutionSection {
+			continue
+		}
+		kov := txtparser.RegexKeyOperatorValue.FindStringSubmatch(line)
+		if kov == nil || kov[2] != string(txtparser.OperatorEqual) {
+			continue
+		}
+		def.set(kov[1], kov[3])
+	}
+	return def
+}
+
+// ParseSolutionFile reads the solution definition from a solution
+// definition file. The name of the solution defaults to the file name
+// without the suffix '.sol'
+func ParseSolutionFile(fileName string) (SolutionDefinition, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return SolutionDefinition{}, err
+	}
+	def := ParseSolution(string(content))
+	def.FileName = fileName
+	if def.Name == "" {
+		def.Name = strings.TrimSuffix(path.Base(fileName), SolutionFileSuffix)
+	}
+	return def, nil
+}
+
+// set stores the value of a key of the [solution] section
+func (def *SolutionDefinition) set(key, value string) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	list := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	def.keys[key] = true
+	switch key {
+	case SolKeyName:
+		def.Name = value
+	case SolKeyVersion:
+		def.Version = value
+	case SolKeyDate:
+		def.Date = value
+	case SolKeyDescription:
+		def.Description = value
+	case SolKeyArchitectures:
+		def.Architectures = list
+	case SolKeyNotes:
+		def.Notes = list
+	case SolKeyDeprecated:
+		switch strings.ToLower(value) {
+		case "", "no", "false":
+			def.Deprecated = ""
+		default:
+			def.Deprecated = value
+		}
+	case SolKeyReplacement:
+		def.Replacement = value
+	default:
+		delete(def.keys, key)
+		system.WarningLog("unknown key '%s' in section [%s] of solution definition", key, SolutionSection)
+	}
+}
+
+// Validate checks the solution definition and returns all problems found
+// as one error
+func (def SolutionDefinition) Validate() error {
+	errs := []string{}
+	if !IsValidSolutionName(def.Name) {
+		errs = append(errs, fmt.Sprintf("wrong solution name '%s'", def.Name))
+	}
+	if def.FileName != "" && path.Base(def.FileName) != def.Name+SolutionFileSuffix {
+		errs = append(errs, fmt.Sprintf("solution name '%s' does not match the file name '%s'", def.Name, path.Base(def.FileName)))
+	}
+	if len(def.Notes) == 0 {
+		errs = append(errs, "missing NOTES")
+	}
+	for _, arch := range def.Architectures {
+		if !isArchName.MatchString(arch) || arch == ArchAll {
+			errs = append(errs, fmt.Sprintf("wrong architecture '%s', please use the GOARCH names like '%s'", arch, strings.Join(KnownArchs, "', '")))
+		}
+	}
+	if def.Replacement != "" && def.Replacement == def.Name {
+		errs = append(errs, "solution can not replace itself")
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// IsValidSolutionName checks, if the name can be used as solution name
+func IsValidSolutionName(name string) bool {
+	return isSolutionName.MatchString(name)
+}
+
+// IsDeprecated returns true, if the solution is deprecated
+func (def SolutionDefinition) IsDeprecated() bool {
+	return def.Deprecated != ""
+}
+
+// SupportsArch checks, if the solution is available for the architecture
+func (def SolutionDefinition) SupportsArch(arch string) bool {
+	if len(def.Architectures) == 0 {
+		return true
+	}
+	for _, sarch := range def.Architectures {
+		if sarch == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOverride replaces the values of the solution definition by the
+// values defined in the override file
+func (def *SolutionDefinition) applyOverride(ov SolutionDefinition) {
+	if ov.keys[SolKeyVersion] {
+		def.Version = ov.Version
+	}
+	if ov.keys[SolKeyDate] {
+		def.Date = ov.Date
+	}
+	if ov.keys[SolKeyDescription] {
+		def.Description = ov.Description
+	}
+	if ov.keys[SolKeyArchitectures] {
+		def.Architectures = ov.Architectures
+	}
+	if ov.keys[SolKeyNotes] {
+		def.Notes = ov.Notes
+	}
+	if ov.keys[SolKeyDeprecated] {
+		def.Deprecated = ov.Deprecated
+	}
+	if ov.keys[SolKeyReplacement] {
+		def.Replacement = ov.Replacement
+	}
+	def.OverrideFile = ov.FileName
+}
+
+// listSolutionFiles returns the solution definition files of a directory
+func listSolutionFiles(dirName string) []string {
+	files := []string{}
+	_, fileNames := system.ListDir(dirName, "")
+	for _, fileName := range fileNames {
+		if strings.HasSuffix(fileName, SolutionFileSuffix) {
+			files = append(files, path.Join(dirName, fileName))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// GetSolutionDefinitions reads the shipped and the customer or vendor
+// specific solution definition files together with their override files.
+// Invalid solution definitions and customer or vendor specific solutions
+// with the name of a shipped solution are skipped
+func GetSolutionDefinitions(solDir, extraDir, overrideDir string) map[string]SolutionDefinition {
+	defs := make(map[string]SolutionDefinition)
+	for _, dir := range []string{solDir, extraDir} {
+		for _, fileName := range listSolutionFiles(dir) {
+			def, err := ParseSolutionFile(fileName)
+			if err != nil {
+				system.WarningLog("failed to read solution definition file '%s' - %v", fileName, err)
+				continue
+			}
+			if err := def.Validate(); err != nil {
+				system.WarningLog("skipping solution definition file '%s' - %v", fileName, err)
+				continue
+			}
+			if _, exists := defs[def.Name]; exists {
+				system.WarningLog("skipping solution definition file '%s', solution '%s' already defined in '%s'", fileName, def.Name, defs[def.Name].FileName)
+				continue
+			}
+			def.Custom = dir == extraDir
+			defs[def.Name] = def
+		}
+	}
+	for name, def := range defs {
+		ovFileName := path.Join(overrideDir, name+SolutionFileSuffix)
+		ov, err := ParseSolutionFile(ovFileName)
+		if err != nil {
+			continue
+		}
+		def.applyOverride(ov)
+		if err := def.Validate(); err != nil {
+			system.WarningLog("skipping override file '%s' of solution '%s' - %v", ovFileName, name, err)
+			continue
+		}
+		defs[name] = def
+	}
+	return defs
+}
+
+// GetSolutionOverrides reads the Notes of the solution override files
+// '<name>.sol' for all supported architectures. These override files can
+// be used for the solutions of the solution definition file too
+func GetSolutionOverrides(overrideDir string) map[string]map[string]Solution {
+	sols := make(map[string]map[string]Solution)
+	for _, fileName := range listSolutionFiles(overrideDir) {
+		ov, err := ParseSolutionFile(fileName)
+		if err != nil || len(ov.Notes) == 0 {
+			continue
+		}
+		for _, arch := range definitionArchs(ov) {
+			if sols[arch] == nil {
+				sols[arch] = make(map[string]Solution)
+			}
+			sols[arch][ov.Name] = ov.Notes
+		}
+	}
+	return sols
+}
+
+// definitionArchs returns the architectures of the solution definition.
+// A solution definition without ARCHITECTURES is available for the known
+// architectures and the running architecture
+func definitionArchs(def SolutionDefinition) []string {
+	if len(def.Architectures) != 0 {
+		return def.Architectures
+	}
+	archs := append([]string{}, KnownArchs...)
+	for _, arch := range KnownArchs {
+		if arch == runtime.GOARCH {
+			return archs
+		}
+	}
+	return append(archs, runtime.GOARCH)
+}
+
+// addSolutionDefinitions adds the Notes of the solution definitions to the
+// solutions of the solution definition file. The solutions of the solution
+// definition files win against the solutions of the solution definition
+// file, but customer or vendor specific solutions can not replace shipped
+// solutions
+func addSolutionDefinitions(sols map[string]map[string]Solution, defs map[string]SolutionDefinition) map[string]map[string]Solution {
+	for _, name := range sortedDefinitionNames(defs) {
+		def := defs[name]
+		for _, arch := range definitionArchs(def) {
+			if sols[arch] == nil {
+				sols[arch] = make(map[string]Solution)
+			}
+			if _, exists := sols[arch][name]; exists && def.Custom {
+				system.WarningLog("skipping customer specific solution '%s' from '%s', a shipped solution with this name already exists. Please use 'saptune solution customise %s' instead", name, def.FileName, name)
+				continue
+			}
+			sols[arch][name] = def.Notes
+		}
+	}
+	return sols
+}
+
+// addDeprecatedSolutions adds the deprecated solutions of the solution
+// definitions to the deprecated solutions of the deprecation file
+func addDeprecatedSolutions(sols map[string]map[string]Deprecation, defs map[string]SolutionDefinition) map[string]map[string]Deprecation {
+	for _, name := range sortedDefinitionNames(defs) {
+		def := defs[name]
+		if !def.IsDeprecated() {
+			continue
+		}
+		for _, arch := range definitionArchs(def) {
+			if sols[arch] == nil {
+				sols[arch] = make(map[string]Deprecation)
+			}
+			sols[arch][name] = Deprecation{Successor: def.Replacement, Reason: def.Deprecated}
+		}
+	}
+	return sols
+}
+
+// addSolutionOverrides adds the solution override files '<name>.sol' to
+// the solutions of the override file
+func addSolutionOverrides(sols, ovSols map[string]map[string]Solution) map[string]map[string]Solution {
+	for arch, archSols := range ovSols {
+		if sols[arch] == nil {
+			sols[arch] = make(map[string]Solution)
+		}
+		for name, notes := range archSols {
+			sols[arch][name] = notes
+		}
+	}
+	return sols
+}
+
+// sortedDefinitionNames returns the names of the solution definitions,
+// sorted alphabetically
+func sortedDefinitionNames(defs map[string]SolutionDefinition) []string {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SolutionDefinitionToText returns the content of a solution definition
+// file for a solution of the solution definition file, which is used as
+// base of an override file
+func SolutionDefinitionToText(name string, notes Solution) string {
+	return fmt.Sprintf("# override file of solution %s, see saptune(8)\n[%s]\n%s=%s\n%s=%s\n", name, SolutionSection, SolKeyName, name, SolKeyNotes, strings.Join(notes, " "))
+}
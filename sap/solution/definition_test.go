@@ -0,0 +1,123 @@
+package solution
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var solDir = path.Join(TstFilesInGOPATH, "sol/sols")
+var extraSolDir = path.Join(TstFilesInGOPATH, "sol/extra")
+var ovSolDir = path.Join(TstFilesInGOPATH, "sol/override")
+
+func TestParseSolution(t *testing.T) {
+	def := ParseSolution("[version]\nNOTES=4711\n[solution]\nNAME=TST\nVERSION=3\nDATE=01.02.2026\nDESCRIPTION=\"my solution\"\nARCHITECTURES=amd64,ppc64le\nNOTES=941735\t1771258 , 1980196\nDEPRECATED=yes\nREPLACEMENT=NEW\n# NOTES=0815\n")
+	if def.Name != "TST" || def.Version != "3" || def.Date != "01.02.2026" || def.Description != "my solution" || def.Replacement != "NEW" {
+		t.Fatalf("wrong metadata: %+v\n", def)
+	}
+	if !reflect.DeepEqual(def.Architectures, []string{"amd64", "ppc64le"}) {
+		t.Fatal(def.Architectures)
+	}
+	if !reflect.DeepEqual(def.Notes, Solution{"941735", "1771258", "1980196"}) {
+		t.Fatal(def.Notes)
+	}
+	if !def.IsDeprecated() || def.Deprecated != "yes" {
+		t.Fatal(def.Deprecated)
+	}
+	if err := def.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if def := ParseSolution("[solution]\nNOTES=4711\nDEPRECATED=no\n"); def.IsDeprecated() {
+		t.Fatal(def.Deprecated)
+	}
+
+	def = ParseSolution("[solution]\nNAME=TST SOL\nARCHITECTURES=s390x,x86_64\nREPLACEMENT=TST SOL\n")
+	err := def.Validate()
+	if err == nil {
+		t.Fatal("invalid solution definition not detected")
+	}
+	for _, txt := range []string{"wrong solution name 'TST SOL'", "missing NOTES", "wrong architecture 'x86_64'", "solution can not replace itself"} {
+		if !strings.Contains(err.Error(), txt) {
+			t.Errorf("'%s' missing in '%v'\n", txt, err)
+		}
+	}
+}
+
+func TestParseSolutionFile(t *testing.T) {
+	def, err := ParseSolutionFile(path.Join(solDir, "TSTSOL.sol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Name != "TSTSOL" || def.FileName != path.Join(solDir, "TSTSOL.sol") {
+		t.Fatalf("wrong name or file name: %+v\n", def)
+	}
+	if !def.SupportsArch(ArchX86) || !def.SupportsArch(ArchPPC64LE) || def.SupportsArch("s390x") {
+		t.Fatal(def.Architectures)
+	}
+	def, _ = ParseSolutionFile(path.Join(solDir, "WRONG.sol"))
+	if err := def.Validate(); err == nil || !strings.Contains(err.Error(), "does not match the file name") {
+		t.Fatalf("wrong solution name not detected: %v\n", err)
+	}
+	if _, err := ParseSolutionFile("/saptune_file_not_avail.sol"); err == nil {
+		t.Fatal("missing file not detected")
+	}
+	if !IsValidSolutionName("S4HANA-APP+DB") || IsValidSolutionName("HANA/DB") {
+		t.Fatal("wrong solution name check")
+	}
+}
+
+func TestGetSolutionDefinitions(t *testing.T) {
+	defs := GetSolutionDefinitions(solDir, extraSolDir, ovSolDir)
+	if !reflect.DeepEqual(sortedDefinitionNames(defs), []string{"CUSTSOL", "OLDSOL", "TSTSOL"}) {
+		t.Fatalf("wrong solutions: %+v\n", defs)
+	}
+	tst := defs["TSTSOL"]
+	if tst.Custom || tst.FileName != path.Join(solDir, "TSTSOL.sol") || tst.OverrideFile != path.Join(ovSolDir, "TSTSOL.sol") {
+		t.Fatalf("wrong solution: %+v\n", tst)
+	}
+	// values of the override file win, the others are kept
+	if tst.Description != "Test solution with override" || tst.Version != "2" || !reflect.DeepEqual(tst.Notes, Solution{"941735", "1980196"}) {
+		t.Fatalf("override not applied: %+v\n", tst)
+	}
+	if cust := defs["CUSTSOL"]; !cust.Custom || cust.OverrideFile != "" || cust.IsDeprecated() {
+		t.Fatalf("wrong custom solution: %+v\n", cust)
+	}
+	if old := defs["OLDSOL"]; old.Deprecated != "replaced by TSTSOL" || old.Replacement != "TSTSOL" {
+		t.Fatalf("wrong deprecated solution: %+v\n", old)
+	}
+
+	sols := addSolutionDefinitions(map[string]map[string]Solution{ArchX86: {"CUSTSOL": Solution{"4711"}}}, defs)
+	if !reflect.DeepEqual(sols[runtime.GOARCH]["TSTSOL"], Solution{"941735", "1980196"}) {
+		t.Fatal(sols)
+	}
+	// a custom solution does not replace an existing solution
+	if !reflect.DeepEqual(sols[ArchX86]["CUSTSOL"], Solution{"4711"}) || !reflect.DeepEqual(sols[ArchPPC64LE]["CUSTSOL"], Solution{"941735", "CUSTNOTE"}) {
+		t.Fatal(sols)
+	}
+	deprec := addDeprecatedSolutions(map[string]map[string]Deprecation{}, defs)
+	if len(deprec[runtime.GOARCH]) != 1 || deprec[runtime.GOARCH]["OLDSOL"] != (Deprecation{Successor: "TSTSOL", Reason: "replaced by TSTSOL"}) {
+		t.Fatal(deprec)
+	}
+
+	if defs := GetSolutionDefinitions("/saptune_dir_not_avail", "/saptune_dir_not_avail", ovSolDir); len(defs) != 0 {
+		t.Fatal(defs)
+	}
+}
+
+func TestGetSolutionOverrides(t *testing.T) {
+	ovSols := GetSolutionOverrides(ovSolDir)
+	if !reflect.DeepEqual(ovSols[runtime.GOARCH]["HANA"], Solution{"941735", "2382421"}) || !reflect.DeepEqual(ovSols[runtime.GOARCH]["TSTSOL"], Solution{"941735", "1980196"}) {
+		t.Fatal(ovSols)
+	}
+	sols := addSolutionOverrides(map[string]map[string]Solution{}, ovSols)
+	if !reflect.DeepEqual(sols, ovSols) {
+		t.Fatal(sols)
+	}
+	text := SolutionDefinitionToText("HANA", Solution{"941735", "2382421"})
+	def := ParseSolution(text)
+	if def.Name != "HANA" || !reflect.DeepEqual(def.Notes, Solution{"941735", "2382421"}) {
+		t.Fatalf("wrong override text '%s'\n", text)
+	}
+}
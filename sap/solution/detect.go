@@ -0,0 +1,228 @@
+package solution
+
+// Detection of the SAP products installed on the host to recommend a
+// matching solution. The file system is inspected below a root directory,
+// so the detection can be done against a copy of the relevant parts of a
+// host file system
+
+import (
+	"bufio"
+	"github.com/SUSE/saptune/system"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SAP components found by the detection
+const (
+	CompHANA        = "HANA database"
+	CompNetWeaver   = "NetWeaver application server"
+	CompCentralServ = "NetWeaver central services"
+	CompASE         = "SAP ASE database"
+	CompMaxDB       = "SAP MaxDB database"
+	CompBOBJ        = "SAP BusinessObjects"
+)
+
+// SAP installation paths inspected by the detection
+const (
+	usrSapDir     = "/usr/sap"
+	sapServices   = "/usr/sap/sapservices"
+	hanaSharedDir = "/hana/shared"
+	sybaseDir     = "/sybase"
+	sapdbDir      = "/sapdb"
+)
+
+// isSID matches a SAP system ID
+var isSID = regexp.MustCompile(`^[A-Z][A-Z0-9]{2}$`)
+
+// isInstance matches the name of an instance directory like HDB00 or ASCS01
+var isInstance = regexp.MustCompile(`^(HDB|DVEBMGS|D|J|JC|ASCS|SCS|ERS|W|G)\d{2}$`)
+
+// isProfile matches the name of an instance profile '<SID>_<INSTANCE>_<host>'
+var isProfile = regexp.MustCompile(`^([A-Z][A-Z0-9]{2})_([A-Z]+\d{2})_[\w.-]+$`)
+
+// isProfileParam matches the profile parameter of a sapstartsrv call in
+// the sapservices file
+var isProfileParam = regexp.MustCompile(`pf=(\S+)`)
+
+// Evidence is a SAP component found on the host together with the file or
+// directory, which proves its existence. 'Path' is the path on the host
+// without the root directory of the detection
+type Evidence struct {
+	Component string
+	SID       string
+	Instance  string
+	Path      string
+}
+
+// instanceComponent returns the SAP component of an instance name like
+// HDB00 or an empty string for unknown instance types
+func instanceComponent(instance string) string {
+	if !isInstance.MatchString(instance) {
+		return ""
+	}
+	switch strings.TrimRight(instance, "0123456789") {
+	case "HDB":
+		return CompHANA
+	case "ASCS", "SCS", "ERS":
+		return CompCentralServ
+	}
+	return CompNetWeaver
+}
+
+// detection collects the evidences of the detected SAP components
+type detection struct {
+	root     string
+	found    map[string]bool
+	evidence []Evidence
+}
+
+// add adds an evidence, if the component was not found before
+func (det *detection) add(comp, sid, instance, hostPath string) {
+	key := comp + "|" + sid + "|" + instance
+	if det.found[key] {
+		return
+	}
+	det.found[key] = true
+	det.evidence = append(det.evidence, Evidence{Component: comp, SID: sid, Instance: instance, Path: hostPath})
+}
+
+// listSIDs returns the SAP system IDs found as directories in dirName
+func (det *detection) listSIDs(dirName string) []string {
+	sids := []string{}
+	dirNames, _ := system.ListDir(path.Join(det.root, dirName), "")
+	for _, name := range dirNames {
+		if isSID.MatchString(name) {
+			sids = append(sids, name)
+		}
+	}
+	return sids
+}
+
+// scanUsrSap looks for instance directories, instance profiles and
+// BusinessObjects installations in /usr/sap/<SID>
+func (det *detection) scanUsrSap() {
+	for _, sid := range det.listSIDs(usrSapDir) {
+		sidDir := path.Join(usrSapDir, sid)
+		dirNames, _ := system.ListDir(path.Join(det.root, sidDir), "")
+		for _, name := range dirNames {
+			if name == "sap_bobj" {
+				det.add(CompBOBJ, sid, "", path.Join(sidDir, name))
+			} else if comp := instanceComponent(name); comp != "" {
+				det.add(comp, sid, name, path.Join(sidDir, name))
+			}
+		}
+		profDir := path.Join(sidDir, "SYS/profile")
+		_, fileNames := system.ListDir(path.Join(det.root, profDir), "")
+		for _, name := range fileNames {
+			det.addProfile(path.Join(profDir, name))
+		}
+	}
+}
+
+// addProfile adds the component of an instance profile
+func (det *detection) addProfile(profile string) {
+	match := isProfile.FindStringSubmatch(path.Base(profile))
+	if match == nil {
+		return
+	}
+	if comp := instanceComponent(match[2]); comp != "" {
+		det.add(comp, match[1], match[2], profile)
+	}
+}
+
+// scanSapServices looks for the instance profiles of the sapstartsrv calls
+// in /usr/sap/sapservices
+func (det *detection) scanSapServices() {
+	file, err := os.Open(path.Join(det.root, sapServices))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if match := isProfileParam.FindStringSubmatch(line); match != nil {
+			if profileMatch := isProfile.FindStringSubmatch(path.Base(match[1])); profileMatch != nil {
+				if comp := instanceComponent(profileMatch[2]); comp != "" {
+					det.add(comp, profileMatch[1], profileMatch[2], sapServices)
+				}
+			}
+		}
+	}
+}
+
+// scanDatabases looks for HANA, ASE and MaxDB installations
+func (det *detection) scanDatabases() {
+	for _, sid := range det.listSIDs(hanaSharedDir) {
+		det.add(CompHANA, sid, "", path.Join(hanaSharedDir, sid))
+	}
+	for _, sid := range det.listSIDs(sybaseDir) {
+		dirNames, _ := system.ListDir(path.Join(det.root, sybaseDir, sid), "")
+		for _, name := range dirNames {
+			if strings.HasPrefix(name, "ASE-") {
+				det.add(CompASE, sid, "", path.Join(sybaseDir, sid, name))
+			}
+		}
+	}
+	for _, sid := range det.listSIDs(sapdbDir) {
+		dbDir := path.Join(sapdbDir, sid, "db")
+		if _, err := os.Stat(path.Join(det.root, dbDir)); err == nil {
+			det.add(CompMaxDB, sid, "", dbDir)
+		}
+	}
+}
+
+// DetectSAPComponents inspects the file system below the root directory
+// for installed SAP components like instance directories and profiles in
+// /usr/sap, the sapservices file and the installation directories of
+// HANA, ASE and MaxDB
+func DetectSAPComponents(root string) []Evidence {
+	det := &detection{root: root, found: make(map[string]bool), evidence: []Evidence{}}
+	det.scanUsrSap()
+	det.scanSapServices()
+	det.scanDatabases()
+	return det.evidence
+}
+
+// RecommendSolution returns the name of the solution matching the detected
+// SAP components or an empty string, if no solution matches.
+// Only solutions available in 'sols' are recommended and a deprecated
+// solution is replaced by its successor. S/4HANA systems can not be
+// distinguished from NetWeaver systems by the file system
+func RecommendSolution(evidence []Evidence, sols map[string]Solution, deprecs map[string]Deprecation) string {
+	has := make(map[string]bool)
+	for _, ev := range evidence {
+		has[ev.Component] = true
+	}
+	nw := has[CompNetWeaver] || has[CompCentralServ]
+	candidates := []string{}
+	switch {
+	case has[CompHANA] && nw:
+		candidates = append(candidates, "NETWEAVER+HANA", "HANA")
+	case has[CompHANA]:
+		candidates = append(candidates, "HANA")
+	case has[CompASE]:
+		candidates = append(candidates, "SAP-ASE")
+	case has[CompMaxDB]:
+		candidates = append(candidates, "MAXDB")
+	case has[CompBOBJ]:
+		candidates = append(candidates, "BOBJ")
+	}
+	if nw {
+		candidates = append(candidates, "NETWEAVER")
+	}
+	for _, solName := range candidates {
+		if deprec, ok := deprecs[solName]; ok && deprec.Successor != "" {
+			solName = deprec.Successor
+		}
+		if _, ok := sols[solName]; ok {
+			return solName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package solution
+
+import (
+	"path"
+	"reflect"
+	"testing"
+)
+
+var detectDir = path.Join(TstFilesInGOPATH, "detect")
+
+func TestDetectSAPComponents(t *testing.T) {
+	expected := []Evidence{
+		{Component: CompHANA, SID: "HA0", Instance: "HDB00", Path: "/usr/sap/HA0/HDB00"},
+		{Component: CompNetWeaver, SID: "NW1", Instance: "D02", Path: "/usr/sap/NW1/D02"},
+		{Component: CompCentralServ, SID: "NW1", Instance: "ASCS01", Path: "/usr/sap/NW1/SYS/profile/NW1_ASCS01_ascshost"},
+		{Component: CompCentralServ, SID: "NW1", Instance: "ERS10", Path: "/usr/sap/sapservices"},
+		{Component: CompHANA, SID: "HA0", Instance: "", Path: "/hana/shared/HA0"},
+	}
+	evidence := DetectSAPComponents(path.Join(detectDir, "nwhana"))
+	if !reflect.DeepEqual(evidence, expected) {
+		t.Fatalf("got '%+v', expected '%+v'\n", evidence, expected)
+	}
+
+	expected = []Evidence{
+		{Component: CompNetWeaver, SID: "AS1", Instance: "D00", Path: "/usr/sap/AS1/D00"},
+		{Component: CompASE, SID: "AS1", Instance: "", Path: "/sybase/AS1/ASE-16_0"},
+	}
+	evidence = DetectSAPComponents(path.Join(detectDir, "ase"))
+	if !reflect.DeepEqual(evidence, expected) {
+		t.Fatalf("got '%+v', expected '%+v'\n", evidence, expected)
+	}
+
+	if evidence := DetectSAPComponents("/saptune_dir_not_avail"); len(evidence) != 0 {
+		t.Fatal(evidence)
+	}
+}
+
+func TestRecommendSolution(t *testing.T) {
+	sols := map[string]Solution{"HANA": {"941735"}, "NETWEAVER": {"941735"}, "NETWEAVER+HANA": {"941735"}, "SAP-ASE": {"941735"}, "MAXDB": {"941735"}}
+	deprecs := map[string]Deprecation{"MAXDB": {Successor: "NETWEAVER", Reason: "deprecated"}}
+
+	for _, tst := range []struct {
+		tree, solName string
+	}{
+		{"nwhana", "NETWEAVER+HANA"}, {"ase", "SAP-ASE"}, {"maxdb", "NETWEAVER"}, {"/saptune_dir_not_avail", ""},
+	} {
+		if solName := RecommendSolution(DetectSAPComponents(path.Join(detectDir, tst.tree)), sols, deprecs); solName != tst.solName {
+			t.Errorf("'%s': got '%s', expected '%s'\n", tst.tree, solName, tst.solName)
+		}
+	}
+	// fall back to the next matching solution
+	hanaOnly := []Evidence{{Component: CompHANA, SID: "HA0"}, {Component: CompNetWeaver, SID: "NW1"}}
+	if solName := RecommendSolution(hanaOnly, map[string]Solution{"HANA": {"941735"}}, deprecs); solName != "HANA" {
+		t.Fatal(solName)
+	}
+	if solName := RecommendSolution([]Evidence{{Component: CompBOBJ, SID: "BO1"}}, sols, deprecs); solName != "" {
+		t.Fatal(solName)
+	}
+}
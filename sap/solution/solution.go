@@ -11,7 +11,8 @@ import (
 	"github.com/SUSE/saptune/system"
 	"github.com/SUSE/saptune/txtparser"
 	"os"
-	"sort"
+	"path"
+	"runtime"
 	"strings"
 )
 
@@ -21,221 +22,252 @@ const (
 	OverrideSolutionSheet = "/etc/saptune/override/solutions"
 	DeprecSolutionSheet   = "/usr/share/saptune/solsdeprecated"
 	NoteTuningSheets      = "/usr/share/saptune/notes/"
-	ArchX86               = "amd64"      // ArchX86 is the GOARCH value for x86 platform.
-	ArchPPC64LE           = "ppc64le"    // ArchPPC64LE is the GOARCH for 64-bit PowerPC little endian platform.
-	ArchX86PC             = "amd64_PC"   // ArchX86 is the GOARCH value for x86 platform. PC indicates PageCache is available
-	ArchPPC64LEPC         = "ppc64le_PC" // ArchPPC64LE is the GOARCH for 64-bit PowerPC little endian platform. PC indicates PageCache is available
+	ArchX86               = "amd64"   // ArchX86 is the GOARCH value for x86 platform.
+	ArchPPC64LE           = "ppc64le" // ArchPPC64LE is the GOARCH for 64-bit PowerPC little endian platform.
+	ArchARM64             = "arm64"   // ArchARM64 is the GOARCH value for 64-bit ARM platform.
+	ArchS390X             = "s390x"   // ArchS390X is the GOARCH value for IBM Z platform.
+	ArchAll               = "all"     // ArchAll is the section of the solution files valid for all architectures.
 )
 
 // Solution is identified by set of note numbers.
+// An entry starting with '@' references another solution, whose notes are
+// part of the solution too
 type Solution []string
 
-// Architecture VS solution ID VS note numbers
-// AllSolutions = map[string]map[string]Solution
+// SolutionRefPrefix marks the reference to another solution like
+// '@NETWEAVER' in the list of notes of a solution
+const SolutionRefPrefix = "@"
 
-// AllSolutions contains a list of all available solutions with their related
-// SAP Notes for all supported architectures
-var AllSolutions = GetSolutionDefintion(SolutionSheet)
+// IsSolutionRef checks, if the entry of a solution references another
+// solution
+func IsSolutionRef(entry string) bool {
+	return strings.HasPrefix(entry, SolutionRefPrefix)
+}
 
-// OverrideSolutions contains a list of all available override solutions with
-// their related SAP Notes for all supported architectures
-var OverrideSolutions = GetOverrideSolution(OverrideSolutionSheet, NoteTuningSheets)
+// SolutionRefs returns the names of the solutions referenced by the solution
+func SolutionRefs(sol Solution) []string {
+	refs := []string{}
+	for _, entry := range sol {
+		if IsSolutionRef(entry) {
+			refs = append(refs, strings.TrimPrefix(entry, SolutionRefPrefix))
+		}
+	}
+	return refs
+}
 
-// DeprecSolutions contains a list of all solutions witch are deprecated
-var DeprecSolutions = GetDeprecatedSolution(DeprecSolutionSheet)
+// ResolveSolution returns the notes of the solution with the referenced
+// solutions resolved recursively. Each note is returned only once at the
+// position of its first occurrence. A reference cycle or the reference of
+// an unknown solution is returned as error
+func ResolveSolution(sols map[string]Solution, solName string) (Solution, error) {
+	notes := Solution{}
+	err := resolveSolution(sols, solName, []string{}, make(map[string]bool), &notes)
+	return notes, err
+}
 
-// GetSolutionDefintion reads solution definition from file
-// build same structure for AllSolutions as before
-// can be simplyfied later
-func GetSolutionDefintion(fileName string) map[string]map[string]Solution {
-	sols := make(map[string]map[string]Solution)
-	sol := make(map[string]Solution)
-	currentArch := ""
-	arch := ""
-	pcarch := ""
-	content, err := txtparser.ParseINIFile(fileName, false)
-	if err != nil {
-		_ = system.ErrorLog("Failed to read solution definition from file '%s'", fileName)
-		return sols
+// resolveSolution adds the notes of the solution to 'notes'. 'refPath'
+// contains the solutions referencing the solution to detect cycles
+func resolveSolution(sols map[string]Solution, solName string, refPath []string, found map[string]bool, notes *Solution) error {
+	for _, name := range refPath {
+		if name == solName {
+			return fmt.Errorf("solution reference cycle '%s'", strings.Join(append(refPath, solName), " -> "))
+		}
+	}
+	sol, ok := sols[solName]
+	if !ok {
+		if len(refPath) == 0 {
+			return fmt.Errorf("solution '%s' not found", solName)
+		}
+		return fmt.Errorf("solution '%s' referenced by solution '%s' not found", solName, refPath[len(refPath)-1])
 	}
+	refPath = append(append([]string{}, refPath...), solName)
+	for _, entry := range sol {
+		if IsSolutionRef(entry) {
+			if err := resolveSolution(sols, strings.TrimPrefix(entry, SolutionRefPrefix), refPath, found, notes); err != nil {
+				return err
+			}
+			continue
+		}
+		if !found[entry] {
+			found[entry] = true
+			*notes = append(*notes, entry)
+		}
+	}
+	return nil
+}
 
+// Deprecation describes why a solution is deprecated and which solution
+// replaces it. 'Successor' is empty, if there is no replacement
+type Deprecation struct {
+	Successor string
+	Reason    string
+}
+
+// deprecation keys of the values of the deprecated solution file
+const (
+	deprecKeySuccessor = "SUCCESSOR="
+	deprecKeyReason    = "REASON="
+)
+
+// sectionArch returns the architecture of a section of the solution files.
+// The sections are named like the GOARCH values (e.g. [amd64], [arm64],
+// [s390x]) or [all] for the entries of all architectures. The former
+// section names [ArchX86] and [ArchPPC64LE] are still supported
+func sectionArch(section string) string {
+	switch section {
+	case "ArchX86":
+		return ArchX86
+	case "ArchPPC64LE":
+		return ArchPPC64LE
+	}
+	return section
+}
+
+// archEntries returns the values of the entries of a solution file per
+// architecture. The entries of the section [all] are added to all
+// architecture sections of the file and to the running architecture,
+// the entries of an architecture section win against the entries of [all]
+func archEntries(content *txtparser.INIFile) map[string]map[string]string {
+	common := make(map[string]string)
+	archs := make(map[string]map[string]string)
 	for _, param := range content.AllValues {
 		if param.Section == "reminder" {
 			continue
 		}
-		if param.Section != currentArch {
-			// start a new arch
-			if currentArch != "" {
-				// save previous arch settings
-				if system.IsPagecacheAvailable() {
-					sols[pcarch] = sol
-				}
-				sols[arch] = sol
-			}
-			currentArch = param.Section
-			sol = make(map[string]Solution)
-			switch currentArch {
-			case "ArchPPC64LE":
-				arch = "ppc64le"
-				pcarch = "ppc64le_PC"
-			case "ArchX86":
-				arch = "amd64"
-				pcarch = "amd64_PC"
-			}
+		arch := sectionArch(param.Section)
+		if arch == ArchAll {
+			common[param.Key] = param.Value
+			continue
 		}
-
-		// looking for override solution
-		if len(OverrideSolutions[arch]) != 0 && len(OverrideSolutions[arch][param.Key]) != 0 {
-			param.Value = strings.Join(OverrideSolutions[arch][param.Key], " ")
+		if archs[arch] == nil {
+			archs[arch] = make(map[string]string)
 		}
-		sol[param.Key] = strings.Split(param.Value, "\t")
+		archs[arch][param.Key] = param.Value
 	}
-	switch currentArch {
-	case "ArchPPC64LE":
-		if system.IsPagecacheAvailable() {
-			sols[ArchPPC64LEPC] = sol
+	// an empty architecture section gets the entries of [all]
+	for section := range content.KeyValue {
+		if arch := sectionArch(section); section != "reminder" && arch != ArchAll && archs[arch] == nil {
+			archs[arch] = make(map[string]string)
 		}
-		sols[ArchPPC64LE] = sol
-	case "ArchX86":
-		if system.IsPagecacheAvailable() {
-			sols[ArchX86PC] = sol
+	}
+	if len(common) != 0 && archs[runtime.GOARCH] == nil {
+		archs[runtime.GOARCH] = make(map[string]string)
+	}
+	for _, entries := range archs {
+		for key, value := range common {
+			if _, ok := entries[key]; !ok {
+				entries[key] = value
+			}
+		}
+	}
+	return archs
+}
+
+// GetSolutionDefintion reads solution definition from file
+// build the solutions per architecture, the override solutions are added
+// by the catalog
+func GetSolutionDefintion(fileName string) map[string]map[string]Solution {
+	sols := make(map[string]map[string]Solution)
+	content, err := txtparser.ParseINIFile(fileName, false)
+	if err != nil {
+		_ = system.ErrorLog("Failed to read solution definition from file '%s'", fileName)
+		return sols
+	}
+	for arch, entries := range archEntries(content) {
+		sol := make(map[string]Solution)
+		for solName, value := range entries {
+			sol[solName] = strings.Split(value, "\t")
 		}
-		sols[ArchX86] = sol
+		sols[arch] = sol
 	}
 	return sols
 }
 
 // GetOverrideSolution reads solution override definition from file
-// build same structure for AllSolutions as before
-// can be simplyfied later
+// build the same structure as GetSolutionDefintion
 func GetOverrideSolution(fileName, noteFiles string) map[string]map[string]Solution {
 	sols := make(map[string]map[string]Solution)
-	sol := make(map[string]Solution)
-	currentArch := ""
-	arch := ""
-	pcarch := ""
 	// looking for override file
 	content, err := txtparser.ParseINIFile(fileName, false)
 	if err != nil {
 		return sols
 	}
-
-	for _, param := range content.AllValues {
-		//check, if all note files used in the override file are available in /usr/share/saptune/note
-		notesOK := true
-		for _, noteID := range strings.Split(content.KeyValue[param.Section][param.Key].Value, "\t") {
-			if _, err := os.Stat(fmt.Sprintf("%s%s", noteFiles, noteID)); err != nil {
-				system.WarningLog("Definition for note '%s' used for solution '%s' in override file '%s' not found in %s", noteID, param.Key, fileName, noteFiles)
-				notesOK = false
-			}
-		}
-		if !notesOK {
-			// skip solution definition, because one or more notes
-			// referenced in the solution definition do not have
-			// a note configuration file on the system
-			continue
-		}
-
-		if param.Section == "reminder" {
-			continue
-		}
-		if param.Section != currentArch {
-			// start a new arch
-			if currentArch != "" {
-				// save previous arch settings
-				if system.IsPagecacheAvailable() {
-					sols[pcarch] = sol
+	for arch, entries := range archEntries(content) {
+		sol := make(map[string]Solution)
+		for solName, value := range entries {
+			//check, if all note files used in the override file are available in /usr/share/saptune/note
+			notesOK := true
+			for _, noteID := range strings.Split(value, "\t") {
+				if IsSolutionRef(noteID) {
+					continue
+				}
+				if _, err := os.Stat(path.Join(noteFiles, noteID)); err != nil {
+					system.WarningLog("Definition for note '%s' used for solution '%s' in override file '%s' not found in %s", noteID, solName, fileName, noteFiles)
+					notesOK = false
 				}
-				sols[arch] = sol
 			}
-			currentArch = param.Section
-			sol = make(map[string]Solution)
-			switch currentArch {
-			case "ArchPPC64LE":
-				arch = "ppc64le"
-				pcarch = "ppc64le_PC"
-			case "ArchX86":
-				arch = "amd64"
-				pcarch = "amd64_PC"
+			if !notesOK {
+				// skip solution definition, because one or more notes
+				// referenced in the solution definition do not have
+				// a note configuration file on the system
+				continue
 			}
+			sol[solName] = strings.Split(value, "\t")
 		}
-		sol[param.Key] = strings.Split(param.Value, "\t")
-	}
-	switch currentArch {
-	case "ArchPPC64LE":
-		if system.IsPagecacheAvailable() {
-			sols[ArchPPC64LEPC] = sol
-		}
-		sols[ArchPPC64LE] = sol
-	case "ArchX86":
-		if system.IsPagecacheAvailable() {
-			sols[ArchX86PC] = sol
+		if len(sol) != 0 {
+			sols[arch] = sol
 		}
-		sols[ArchX86] = sol
 	}
 	return sols
 }
 
 // GetDeprecatedSolution reads solution deprecated definition from file
-func GetDeprecatedSolution(fileName string) map[string]map[string]string {
-	sols := make(map[string]map[string]string)
-	sol := make(map[string]string)
-	currentArch := ""
-	arch := ""
-	pcarch := ""
+func GetDeprecatedSolution(fileName string) map[string]map[string]Deprecation {
+	sols := make(map[string]map[string]Deprecation)
 	// looking for deprecated solution file
 	content, err := txtparser.ParseINIFile(fileName, false)
 	if err != nil {
 		return sols
 	}
-
-	for _, param := range content.AllValues {
-		if param.Section == "reminder" {
-			continue
-		}
-		if param.Section != currentArch {
-			// start a new arch
-			if currentArch != "" {
-				// save previous arch settings
-				if system.IsPagecacheAvailable() {
-					sols[pcarch] = sol
-				}
-				sols[arch] = sol
-			}
-			currentArch = param.Section
-			sol = make(map[string]string)
-			switch currentArch {
-			case "ArchPPC64LE":
-				arch = "ppc64le"
-				pcarch = "ppc64le_PC"
-			case "ArchX86":
-				arch = "amd64"
-				pcarch = "amd64_PC"
-			}
+	for arch, entries := range archEntries(content) {
+		sol := make(map[string]Deprecation)
+		for solName, value := range entries {
+			// the ini parser replaces the blanks of the value by tabs
+			sol[solName] = ParseDeprecation(strings.Replace(value, "\t", " ", -1))
 		}
-		sol[param.Key] = param.Value
-	}
-	switch currentArch {
-	case "ArchPPC64LE":
-		if system.IsPagecacheAvailable() {
-			sols[ArchPPC64LEPC] = sol
-		}
-		sols[ArchPPC64LE] = sol
-	case "ArchX86":
-		if system.IsPagecacheAvailable() {
-			sols[ArchX86PC] = sol
-		}
-		sols[ArchX86] = sol
+		sols[arch] = sol
 	}
 	return sols
 }
 
-// GetSortedSolutionNames returns all solution names, sorted alphabetically.
-func GetSortedSolutionNames(archName string) (ret []string) {
-	ret = make([]string, 0, len(AllSolutions))
-	for id := range AllSolutions[archName] {
-		ret = append(ret, id)
+// ParseDeprecation parses the value of an entry of the deprecated solution
+// file. The value is either a simple reason like 'deprecated' or a list of
+// the keys SUCCESSOR and REASON separated by ';' like
+// 'SUCCESSOR=NETWEAVER; REASON=covered by solution NETWEAVER'.
+// REASON has to be the last key, as its value may contain a ';'
+func ParseDeprecation(value string) Deprecation {
+	deprec := Deprecation{}
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, deprecKeySuccessor) && !strings.HasPrefix(value, deprecKeyReason) {
+		deprec.Reason = value
+		return deprec
+	}
+	for value != "" {
+		if strings.HasPrefix(value, deprecKeyReason) {
+			deprec.Reason = strings.TrimSpace(strings.TrimPrefix(value, deprecKeyReason))
+			break
+		}
+		field := value
+		value = ""
+		if i := strings.Index(field, ";"); i >= 0 {
+			field, value = field[:i], strings.TrimSpace(field[i+1:])
+		}
+		if strings.HasPrefix(field, deprecKeySuccessor) {
+			deprec.Successor = strings.TrimSpace(strings.TrimPrefix(field, deprecKeySuccessor))
+		}
+	}
+	if deprec.Reason == "" {
+		deprec.Reason = "deprecated"
 	}
-	sort.Strings(ret)
-	return
+	return deprec
 }
@@ -1,9 +1,9 @@
 package solution
 
 import (
-	"github.com/SUSE/saptune/system"
 	"os"
 	"path"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -15,10 +15,6 @@ func TestGetSolutionDefintion(t *testing.T) {
 	solutionFile := path.Join(TstFilesInGOPATH, "saptune-test-solutions")
 	nwsols := "941735 1771258 1980196 1984787 2534844"
 	solcount := 2
-	if system.IsPagecacheAvailable() {
-		solcount = 4
-		nwsols = "941735 1771258 1980196 1984787 2534844"
-	}
 
 	solutions := GetSolutionDefintion(solutionFile)
 	if len(solutions) != solcount {
@@ -34,15 +30,37 @@ func TestGetSolutionDefintion(t *testing.T) {
 	}
 }
 
+func TestArchSections(t *testing.T) {
+	solutionFile := path.Join(TstFilesInGOPATH, "saptune-test-solutions-arch")
+	solutions := GetSolutionDefintion(solutionFile)
+	// [all] is added to all architectures, an architecture section wins
+	if strings.Join(solutions[ArchX86]["NETW"], " ") != "941735 1771258" || strings.Join(solutions[ArchX86]["HANA"], " ") != "941735 1980196 2205917" {
+		t.Fatal(solutions)
+	}
+	if _, ok := solutions[ArchX86]["ZSOL"]; ok {
+		t.Fatal(solutions)
+	}
+	if strings.Join(solutions[ArchS390X]["HANA"], " ") != "941735 1980196" || strings.Join(solutions[ArchS390X]["ZSOL"], " ") != "941735 2534844" {
+		t.Fatal(solutions)
+	}
+	if strings.Join(solutions[ArchARM64]["NETW"], " ") != "941735 1771258" {
+		t.Fatal(solutions)
+	}
+	// the running architecture gets the solutions of [all]
+	if len(solutions[runtime.GOARCH]["NETW"]) == 0 {
+		t.Fatal(solutions)
+	}
+	if _, ok := solutions[ArchAll]; ok {
+		t.Fatal(solutions)
+	}
+}
+
 func TestGetOverrideSolution(t *testing.T) {
 	ovsolutionFile := path.Join(TstFilesInGOPATH, "saptune-test-override-sols")
 	noteFiles := TstFilesInGOPATH + "/"
 
 	hansol := "HANA1 NEWNOTE HANA2"
 	solcount := 2
-	if system.IsPagecacheAvailable() {
-		solcount = 4
-	}
 
 	t.Log(TstFilesInGOPATH)
 	ovsolutions := GetOverrideSolution(ovsolutionFile, noteFiles)
@@ -69,15 +87,15 @@ func TestGetDeprecatedSolution(t *testing.T) {
 	deprecSolutionFile := path.Join(TstFilesInGOPATH, "saptune-test-deprecated-sols")
 	deprec := "deprecated"
 	solcount := 2
-	if system.IsPagecacheAvailable() {
-		solcount = 4
-	}
 
 	solutions := GetDeprecatedSolution(deprecSolutionFile)
 	if len(solutions) != solcount {
 		t.Fatalf("'%+v' has len '%+v'\n", solutions, len(solutions))
 	}
-	if solutions[runtime.GOARCH]["MAXDB"] != deprec {
+	if solutions[runtime.GOARCH]["MAXDB"] != (Deprecation{Reason: deprec}) {
+		t.Fatal(solutions)
+	}
+	if solutions[runtime.GOARCH]["BOBJ"] != (Deprecation{Successor: "NETWEAVER", Reason: "only a test; with a semicolon"}) {
 		t.Fatal(solutions)
 	}
 
@@ -87,8 +105,48 @@ func TestGetDeprecatedSolution(t *testing.T) {
 	}
 }
 
-func TestGetSortedSolutionIDs(t *testing.T) {
-	if len(GetSortedSolutionNames(runtime.GOARCH)) != len(AllSolutions[runtime.GOARCH]) {
-		t.Fatal(GetSortedSolutionNames(runtime.GOARCH))
+func TestParseDeprecation(t *testing.T) {
+	for value, expected := range map[string]Deprecation{
+		"deprecated":                          {Reason: "deprecated"},
+		"SUCCESSOR=NETWEAVER":                 {Successor: "NETWEAVER", Reason: "deprecated"},
+		"SUCCESSOR=HANA ; REASON= new HANA  ": {Successor: "HANA", Reason: "new HANA"},
+		"REASON=no successor":                 {Reason: "no successor"},
+	} {
+		if deprec := ParseDeprecation(value); deprec != expected {
+			t.Errorf("'%s': got '%+v', expected '%+v'\n", value, deprec, expected)
+		}
+	}
+}
+
+func TestResolveSolution(t *testing.T) {
+	sols := map[string]Solution{
+		"NW":    {"941735", "1771258"},
+		"HANA":  {"941735", "1980196"},
+		"NW+HA": {"@HANA", "@NW", "2534844", "941735"},
+		"ALL":   {"@NW+HA", "@NW"},
+		"CYC1":  {"4711", "@CYC2"},
+		"CYC2":  {"@CYC1"},
+		"WRONG": {"@NOTAVAIL"},
+	}
+	notes, err := ResolveSolution(sols, "NW+HA")
+	if err != nil || !reflect.DeepEqual(notes, Solution{"941735", "1980196", "1771258", "2534844"}) {
+		t.Fatal(notes, err)
+	}
+	// the same solution referenced twice is no cycle
+	notes, err = ResolveSolution(sols, "ALL")
+	if err != nil || !reflect.DeepEqual(notes, Solution{"941735", "1980196", "1771258", "2534844"}) {
+		t.Fatal(notes, err)
+	}
+	if _, err := ResolveSolution(sols, "CYC1"); err == nil || err.Error() != "solution reference cycle 'CYC1 -> CYC2 -> CYC1'" {
+		t.Fatal(err)
+	}
+	if _, err := ResolveSolution(sols, "WRONG"); err == nil || err.Error() != "solution 'NOTAVAIL' referenced by solution 'WRONG' not found" {
+		t.Fatal(err)
+	}
+	if _, err := ResolveSolution(sols, "NOTAVAIL"); err == nil {
+		t.Fatal("unknown solution not detected")
+	}
+	if refs := SolutionRefs(sols["NW+HA"]); !reflect.DeepEqual(refs, []string{"HANA", "NW"}) {
+		t.Fatal(refs)
 	}
 }
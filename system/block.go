@@ -0,0 +1,328 @@
+package system
+
+// select block devices by their properties
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// block device selector types
+const (
+	BlockSelName       = "name"
+	BlockSelVendor     = "vendor"
+	BlockSelModel      = "model"
+	BlockSelTransport  = "transport"
+	BlockSelRotational = "rotational"
+	BlockSelDM         = "dm"
+	BlockSelMount      = "mount"
+)
+
+// BlockUdevRulesFile is the udev rules file, which applies the [block]
+// section settings of the applied Notes to newly added block devices
+const BlockUdevRulesFile = "/etc/udev/rules.d/99-saptune-block.rules"
+
+// blockUdevRule calls saptune for each newly added disk. Device mapper,
+// loop, ram, zram and cdrom devices are not tuned by saptune
+const blockUdevRule = `# saptune block device tuning for newly added block devices
+# (e.g. hot-plugged LUNs or new multipath paths)
+# generated by saptune - do not edit, will be removed during revert
+ACTION=="add", SUBSYSTEM=="block", ENV{DEVTYPE}=="disk", KERNEL!="dm-*|loop*|ram*|zram*|sr*", RUN+="%s block apply $kernel"
+`
+
+// saptuneCmd is the saptune command called by the udev rule
+var saptuneCmd = "/usr/sbin/saptune"
+
+var isVirtioBlk = regexp.MustCompile(`^vd\w+$`)
+var isNvmeBlk = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// BlockDev contains the properties of a block device, which can be used
+// to select the device in the [block] section of a Note definition file
+type BlockDev struct {
+	Name       string
+	Vendor     string
+	Model      string
+	Transport  string
+	Rotational string
+}
+
+// BlockSelector contains the selection criteria for block devices.
+// Different selector types need to match all, values of the same selector
+// type are alternatives
+type BlockSelector map[string][]string
+
+// ParseBlockSelector parses a block device selector like
+// 'model=LOGICAL VOLUME,rotational=0' into a BlockSelector
+func ParseBlockSelector(selector string) (BlockSelector, error) {
+	sel := make(BlockSelector)
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.SplitN(part, "=", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			return sel, fmt.Errorf("wrong block device selector '%s'", part)
+		}
+		selType := strings.ToLower(strings.TrimSpace(fields[0]))
+		selVal := strings.TrimSpace(fields[1])
+		switch selType {
+		case BlockSelName, BlockSelVendor, BlockSelModel, BlockSelDM:
+			if _, err := regexp.Compile(selVal); err != nil {
+				return sel, fmt.Errorf("wrong regular expression '%s' for block device selector '%s': %v", selVal, selType, err)
+			}
+		case BlockSelRotational:
+			if selVal != "0" && selVal != "1" {
+				return sel, fmt.Errorf("wrong value '%s' for block device selector '%s', only '0' or '1' supported", selVal, selType)
+			}
+		case BlockSelTransport:
+			selVal = strings.ToLower(selVal)
+		case BlockSelMount:
+		default:
+			return sel, fmt.Errorf("unknown block device selector '%s'", selType)
+		}
+		sel[selType] = append(sel[selType], selVal)
+	}
+	if len(sel) == 0 {
+		return sel, fmt.Errorf("empty block device selector")
+	}
+	return sel, nil
+}
+
+// Match checks, if the block device matches the selector.
+// 'dmDevs' and 'mountDevs' are the block devices backing the device mapper
+// devices and the mount points of the selector
+func (sel BlockSelector) Match(bdev BlockDev, dmDevs, mountDevs []string) bool {
+	for selType, selVals := range sel {
+		match := false
+		for _, selVal := range selVals {
+			switch selType {
+			case BlockSelName:
+				match = regexp.MustCompile(selVal).MatchString(bdev.Name)
+			case BlockSelVendor:
+				match = regexp.MustCompile(selVal).MatchString(bdev.Vendor)
+			case BlockSelModel:
+				match = regexp.MustCompile(selVal).MatchString(bdev.Model)
+			case BlockSelTransport:
+				match = selVal == bdev.Transport
+			case BlockSelRotational:
+				match = selVal == bdev.Rotational
+			}
+			if match {
+				break
+			}
+		}
+		switch selType {
+		case BlockSelDM:
+			match = StringInList(bdev.Name, dmDevs)
+		case BlockSelMount:
+			match = StringInList(bdev.Name, mountDevs)
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSupportedBlockDevice checks, if the block device is supported for
+// the tuning in the [block] section.
+// /sys/block/*/device/type (TYPE_DISK / 0x00) does not work for virtio
+// block devices and NVMe devices
+func IsSupportedBlockDevice(bdev string, withNvme bool) bool {
+	dtype, err := ioutil.ReadFile(fmt.Sprintf("/sys/block/%s/device/type", bdev))
+	if err == nil && strings.TrimSpace(string(dtype)) == "0" {
+		return true
+	}
+	if isVirtioBlk.MatchString(bdev) {
+		return true
+	}
+	return withNvme && isNvmeBlk.MatchString(bdev)
+}
+
+// GetBlockDev returns the properties of a block device
+func GetBlockDev(bdev string) BlockDev {
+	dev := BlockDev{Name: bdev}
+	if val, err := ioutil.ReadFile(path.Join("/sys/block", bdev, "device/vendor")); err == nil {
+		dev.Vendor = strings.TrimSpace(string(val))
+	}
+	if val, err := ioutil.ReadFile(path.Join("/sys/block", bdev, "device/model")); err == nil {
+		dev.Model = strings.TrimSpace(string(val))
+	}
+	if val, err := ioutil.ReadFile(path.Join("/sys/block", bdev, "queue/rotational")); err == nil {
+		dev.Rotational = strings.TrimSpace(string(val))
+	}
+	if devPath, err := filepath.EvalSymlinks(path.Join("/sys/block", bdev)); err == nil {
+		dev.Transport = BlockTransport(devPath)
+	}
+	return dev
+}
+
+// BlockTransport returns the transport type of a block device derived from
+// the sysfs device path of the block device like
+// /sys/devices/pci0000:00/0000:00:1f.2/ata1/host0/target0:0:0/0:0:0:0/block/sda
+func BlockTransport(devPath string) string {
+	transports := []struct {
+		pattern   *regexp.Regexp
+		transport string
+	}{
+		{regexp.MustCompile(`/virtio\d+/`), "virtio"},
+		{regexp.MustCompile(`/nvme\d*/`), "nvme"},
+		{regexp.MustCompile(`/usb\d+/`), "usb"},
+		{regexp.MustCompile(`/rport-\d+:\d+-\d+/`), "fc"},
+		{regexp.MustCompile(`/session\d+/`), "iscsi"},
+		{regexp.MustCompile(`/end_device-\d+:`), "sas"},
+		{regexp.MustCompile(`/ata\d+/`), "sata"},
+		{regexp.MustCompile(`/vmbus_\d+/|/VMBUS:`), "vmbus"},
+	}
+	for _, trans := range transports {
+		if trans.pattern.MatchString(devPath) {
+			return trans.transport
+		}
+	}
+	if strings.Contains(devPath, "/virtual/") {
+		return "virtual"
+	}
+	return "scsi"
+}
+
+// diskOfBlockDev returns the name of the disk a block device (e.g. a
+// partition) belongs to
+func diskOfBlockDev(bdev string) string {
+	if _, err := os.Stat(path.Join("/sys/block", bdev)); err == nil {
+		return bdev
+	}
+	devPath, err := filepath.EvalSymlinks(path.Join("/sys/class/block", bdev))
+	if err != nil {
+		return ""
+	}
+	return path.Base(path.Dir(devPath))
+}
+
+// BlockDevSlaves returns the disks backing a block device. For device
+// mapper devices the slaves are resolved recursively, for partitions the
+// disk is returned
+func BlockDevSlaves(bdev string) []string {
+	devs := []string{}
+	disk := diskOfBlockDev(bdev)
+	if disk == "" {
+		return devs
+	}
+	_, slaves := ListDir(path.Join("/sys/block", disk, "slaves"), "")
+	if len(slaves) == 0 {
+		return append(devs, disk)
+	}
+	for _, slave := range slaves {
+		devs = append(devs, BlockDevSlaves(slave)...)
+	}
+	return devs
+}
+
+// DMBlockDevs returns the disks backing the device mapper devices, whose
+// device mapper name (e.g. the multipath alias) matches the regular
+// expression
+func DMBlockDevs(dmName string) []string {
+	devs := []string{}
+	re, err := regexp.Compile(dmName)
+	if err != nil {
+		return devs
+	}
+	_, sysDevs := ListDir("/sys/block", "")
+	for _, bdev := range sysDevs {
+		name, err := ioutil.ReadFile(path.Join("/sys/block", bdev, "dm/name"))
+		if err != nil || !re.MatchString(strings.TrimSpace(string(name))) {
+			continue
+		}
+		devs = append(devs, BlockDevSlaves(bdev)...)
+	}
+	return devs
+}
+
+// MountBlockDevs returns the disks backing a mount point
+func MountBlockDevs(mountPoint string) []string {
+	devs := []string{}
+	mount, found := ParseProcMounts().GetByMountPoint(mountPoint)
+	if !found || !strings.HasPrefix(mount.Device, "/dev/") {
+		WarningLog("mount point '%s' not found or not backed by a block device", mountPoint)
+		return devs
+	}
+	dev, err := filepath.EvalSymlinks(mount.Device)
+	if err != nil {
+		return devs
+	}
+	return BlockDevSlaves(path.Base(dev))
+}
+
+// SelectBlockDevices returns the block devices of the list 'bdevs', which
+// match the block device selector
+func SelectBlockDevices(selector string, bdevs []string) ([]string, error) {
+	selected := []string{}
+	sel, err := ParseBlockSelector(selector)
+	if err != nil {
+		return selected, err
+	}
+	dmDevs := []string{}
+	for _, dmName := range sel[BlockSelDM] {
+		dmDevs = append(dmDevs, DMBlockDevs(dmName)...)
+	}
+	mountDevs := []string{}
+	for _, mountPoint := range sel[BlockSelMount] {
+		mountDevs = append(mountDevs, MountBlockDevs(mountPoint)...)
+	}
+	for _, bdev := range bdevs {
+		if sel.Match(GetBlockDev(bdev), dmDevs, mountDevs) {
+			selected = append(selected, bdev)
+		}
+	}
+	return selected, nil
+}
+
+// WriteBlockUdevRules writes the udev rules file, which calls
+// 'saptune block apply <device>' for newly added block devices
+// The file is only written and udev reloaded, if the content changed
+func WriteBlockUdevRules(file string) error {
+	rules := fmt.Sprintf(blockUdevRule, saptuneCmd)
+	if content, err := ReadFile(file); err == nil && string(content) == rules {
+		return nil
+	}
+	if err := MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := WriteFile(file, []byte(rules), 0644); err != nil {
+		return err
+	}
+	InfoLog("udev rules file '%s' written to tune newly added block devices", file)
+	reloadUdevRules()
+	return nil
+}
+
+// RemoveBlockUdevRules removes the udev rules file for newly added block
+// devices
+func RemoveBlockUdevRules(file string) error {
+	if !FileExists(file) {
+		return nil
+	}
+	if err := RemoveFile(file); err != nil {
+		return err
+	}
+	InfoLog("udev rules file '%s' removed", file)
+	reloadUdevRules()
+	return nil
+}
+
+// reloadUdevRules tells udev to reload the rules files
+func reloadUdevRules() {
+	cmdName := "/usr/bin/udevadm"
+	if !CmdIsAvailable(cmdName) {
+		WarningLog("command '%s' not found, udev rules not reloaded", cmdName)
+		return
+	}
+	if out, err := RunChangeCmd(cmdName, "control", "--reload"); err != nil {
+		WarningLog("failed to reload udev rules: %v %s", err, string(out))
+	}
+}
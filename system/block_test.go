@@ -0,0 +1,134 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseBlockSelector(t *testing.T) {
+	sel, err := ParseBlockSelector("name=^sd[a-c]$, Model=LOGICAL VOLUME,rotational=0,rotational=1,transport=FC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sel) != 4 || sel[BlockSelName][0] != "^sd[a-c]$" || sel[BlockSelModel][0] != "LOGICAL VOLUME" || len(sel[BlockSelRotational]) != 2 || sel[BlockSelTransport][0] != "fc" {
+		t.Fatal(sel)
+	}
+	sel, err = ParseBlockSelector("mount=/hana/data,mount=/hana/log,dm=^3600")
+	if err != nil || len(sel[BlockSelMount]) != 2 || sel[BlockSelDM][0] != "^3600" {
+		t.Fatal(sel, err)
+	}
+	for _, wrong := range []string{"", "name", "name=", "unknown=sda", "rotational=2", "vendor=("} {
+		if _, err := ParseBlockSelector(wrong); err == nil {
+			t.Fatalf("wrong selector '%s' accepted\n", wrong)
+		}
+	}
+}
+
+func TestBlockSelectorMatch(t *testing.T) {
+	sda := BlockDev{Name: "sda", Vendor: "HP", Model: "LOGICAL VOLUME", Transport: "sas", Rotational: "0"}
+	sdb := BlockDev{Name: "sdb", Vendor: "NETAPP", Model: "LUN C-Mode", Transport: "fc", Rotational: "1"}
+	sel, _ := ParseBlockSelector("model=LOGICAL VOLUME")
+	if !sel.Match(sda, nil, nil) || sel.Match(sdb, nil, nil) {
+		t.Fatal(sel)
+	}
+	sel, _ = ParseBlockSelector("vendor=^NETAPP$,transport=fc,transport=iscsi")
+	if sel.Match(sda, nil, nil) || !sel.Match(sdb, nil, nil) {
+		t.Fatal(sel)
+	}
+	sel, _ = ParseBlockSelector("name=^sd,rotational=0")
+	if !sel.Match(sda, nil, nil) || sel.Match(sdb, nil, nil) {
+		t.Fatal(sel)
+	}
+	sel, _ = ParseBlockSelector("dm=^hana_data$")
+	if sel.Match(sda, nil, nil) || !sel.Match(sdb, []string{"sdb", "sdc"}, nil) {
+		t.Fatal(sel)
+	}
+	sel, _ = ParseBlockSelector("mount=/hana/log,transport=sas")
+	if !sel.Match(sda, nil, []string{"sda"}) || sel.Match(sdb, nil, []string{"sdb"}) {
+		t.Fatal(sel)
+	}
+}
+
+func TestBlockTransport(t *testing.T) {
+	paths := map[string]string{
+		"/sys/devices/pci0000:00/0000:00:1f.2/ata1/host0/target0:0:0/0:0:0:0/block/sda":                                 "sata",
+		"/sys/devices/pci0000:00/0000:00:02.0/virtio1/block/vda":                                                        "virtio",
+		"/sys/devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0/nvme0n1":                                          "nvme",
+		"/sys/devices/pci0000:80/0000:80:03.0/0000:87:00.0/host1/rport-1:0-0/target1:0:0/1:0:0:1/block/sdb":             "fc",
+		"/sys/devices/platform/host2/session1/target2:0:0/2:0:0:0/block/sdc":                                            "iscsi",
+		"/sys/devices/pci0000:00/0000:00:01.0/0000:01:00.0/host0/port-0:0/end_device-0:0/target0:0:0/0:0:0:0/block/sdd": "sas",
+		"/sys/devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/host3/target3:0:0/3:0:0:0/block/sde":                     "usb",
+		"/sys/devices/virtual/block/dm-0":                                                                               "virtual",
+		"/sys/devices/pci0000:00/0000:00:10.0/host2/target2:0:0/2:0:0:0/block/sdf":                                      "scsi",
+	}
+	for devPath, exp := range paths {
+		if trans := BlockTransport(devPath); trans != exp {
+			t.Fatalf("transport '%s' instead of '%s' for '%s'\n", trans, exp, devPath)
+		}
+	}
+}
+
+func TestSelectBlockDevices(t *testing.T) {
+	_, sysDevs := ListDir("/sys/block", "")
+	devs, err := SelectBlockDevices("name=.*", sysDevs)
+	if err != nil || len(devs) != len(sysDevs) {
+		t.Fatal(devs, err)
+	}
+	devs, err = SelectBlockDevices("name=^not_avail$", sysDevs)
+	if err != nil || len(devs) != 0 {
+		t.Fatal(devs, err)
+	}
+	devs, err = SelectBlockDevices("dm=^not_avail$", sysDevs)
+	if err != nil || len(devs) != 0 {
+		t.Fatal(devs, err)
+	}
+	devs, err = SelectBlockDevices("mount=/not_avail", sysDevs)
+	if err != nil || len(devs) != 0 {
+		t.Fatal(devs, err)
+	}
+	if _, err = SelectBlockDevices("unknown=1", sysDevs); err == nil {
+		t.Fatal("wrong selector accepted")
+	}
+	for _, bdev := range sysDevs {
+		if IsSupportedBlockDevice(bdev, false) && strings.HasPrefix(bdev, "loop") {
+			t.Fatalf("loop device '%s' reported as supported\n", bdev)
+		}
+	}
+	if IsSupportedBlockDevice("nvme0n1", false) {
+		t.Fatal("nvme device reported as supported")
+	}
+	if !IsSupportedBlockDevice("nvme0n1", true) {
+		t.Fatal("nvme device reported as not supported")
+	}
+}
+
+func TestBlockUdevRules(t *testing.T) {
+	rulesFile := "/tmp/saptune_test/rules.d/99-saptune-block.rules"
+	defer os.RemoveAll("/tmp/saptune_test")
+	if err := WriteBlockUdevRules(rulesFile); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(rulesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `RUN+="/usr/sbin/saptune block apply $kernel"`) {
+		t.Fatal(string(content))
+	}
+	// unchanged content
+	if err := WriteBlockUdevRules(rulesFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveBlockUdevRules(rulesFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(rulesFile); !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	// file already removed
+	if err := RemoveBlockUdevRules(rulesFile); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,268 @@
+package system
+
+// Evaluate conditions of sections or entries in Note definition files
+// like 'arch=ppc64le,os=15..' against the properties of the running system
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// condition types
+const (
+	CondArch   = "arch"
+	CondOs     = "os"
+	CondKernel = "kernel"
+	CondVirt   = "virt"
+	CondMem    = "mem"
+	CondCPU    = "cpu"
+	CondPC     = "pagecache"
+)
+
+// ConditionTypes contains the supported condition types in the order used
+// for the evaluation and the explanation of a condition
+var ConditionTypes = []string{CondArch, CondOs, CondKernel, CondVirt, CondMem, CondCPU, CondPC}
+
+// archAliases maps the GOARCH values to the machine names reported by
+// 'uname -m', both can be used in an 'arch' condition
+var archAliases = map[string]string{"amd64": "x86_64", "arm64": "aarch64", "386": "i586"}
+
+var memSizePattern = regexp.MustCompile(`^(\d+)\s*([KMGT]?)(I?B)?$`)
+
+// HostFacts contains the properties of the running system, which can be
+// used in conditions
+type HostFacts struct {
+	Arch       string
+	OsVers     string
+	Kernel     string
+	Virt       string
+	MemTotalKB uint64
+	CPUVendor  string
+	Pagecache  bool
+}
+
+// GetHostFacts collects the properties of the running system used in
+// conditions
+func GetHostFacts() HostFacts {
+	kernel, _ := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	return HostFacts{
+		Arch:       runtime.GOARCH,
+		OsVers:     GetOsVers(),
+		Kernel:     strings.TrimSpace(string(kernel)),
+		Virt:       GetVirtType(),
+		MemTotalKB: ParseMeminfo()[MemMainTotalKey],
+		CPUVendor:  GetCPUVendor(),
+		Pagecache:  IsPagecacheAvailable(),
+	}
+}
+
+// String returns the host facts in condition syntax
+func (facts HostFacts) String() string {
+	return fmt.Sprintf("%s=%s %s=%s %s=%s %s=%s %s=%dM %s=%s %s=%s", CondArch, facts.Arch, CondOs, facts.OsVers, CondKernel, facts.Kernel, CondVirt, facts.Virt, CondMem, facts.MemTotalKB/1024, CondCPU, facts.CPUVendor, CondPC, yesNo(facts.Pagecache))
+}
+
+// yesNo returns 'yes' for true and 'no' for false
+func yesNo(val bool) string {
+	if val {
+		return "yes"
+	}
+	return "no"
+}
+
+// GetVirtType returns the virtualization type of the system as reported
+// by systemd-detect-virt ('none' for bare metal, 'kvm', 'xen',
+// 'microsoft', 'vmware', 'powervm', ...)
+func GetVirtType() string {
+	cmdName := "/usr/bin/systemd-detect-virt"
+	if !CmdIsAvailable(cmdName) {
+		WarningLog("command '%s' not found, virtualization type unknown", cmdName)
+		return ""
+	}
+	// systemd-detect-virt exits with 1, if no virtualization is detected
+	out, _ := exec.Command(cmdName).Output()
+	return strings.TrimSpace(string(out))
+}
+
+// GetCPUVendor returns the CPU vendor of the system ('intel', 'amd',
+// 'ibm', 'arm' or the lower case 'vendor_id' from /proc/cpuinfo)
+func GetCPUVendor() string {
+	if runtime.GOARCH == "ppc64le" || runtime.GOARCH == "s390x" {
+		return "ibm"
+	}
+	cpuinfo, err := ioutil.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(fields[0])
+		val := strings.TrimSpace(fields[1])
+		switch {
+		case key == "vendor_id" && val == "GenuineIntel":
+			return "intel"
+		case key == "vendor_id" && val == "AuthenticAMD":
+			return "amd"
+		case key == "vendor_id":
+			return strings.ToLower(val)
+		case key == "CPU implementer":
+			return "arm"
+		}
+	}
+	return ""
+}
+
+// Condition contains the conditions of a section or an entry of a Note
+// definition file. Different condition types need to match all, values
+// of the same condition type are alternatives
+type Condition map[string][]string
+
+// ParseCondition parses a condition like 'arch=ppc64le,mem=64G..' into a
+// Condition
+func ParseCondition(condition string) (Condition, error) {
+	cond := make(Condition)
+	for _, part := range strings.Split(condition, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.SplitN(part, "=", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			return cond, fmt.Errorf("wrong condition '%s'", part)
+		}
+		condType := strings.ToLower(strings.TrimSpace(fields[0]))
+		condVal := strings.TrimSpace(fields[1])
+		switch condType {
+		case CondMem:
+			if _, _, err := parseMemRange(condVal); err != nil {
+				return cond, err
+			}
+		case CondArch, CondVirt, CondCPU:
+			condVal = strings.ToLower(condVal)
+		case CondPC:
+			condVal = strings.ToLower(condVal)
+			if condVal != "yes" && condVal != "no" {
+				return cond, fmt.Errorf("wrong condition '%s', use '%s=yes' or '%s=no'", part, CondPC, CondPC)
+			}
+		case CondOs, CondKernel:
+		default:
+			return cond, fmt.Errorf("unknown condition '%s', supported are %s", condType, strings.Join(ConditionTypes, ", "))
+		}
+		cond[condType] = append(cond[condType], condVal)
+	}
+	if len(cond) == 0 {
+		return cond, fmt.Errorf("empty condition")
+	}
+	return cond, nil
+}
+
+// Match checks, if the host facts match the condition. Additionally it
+// returns an explanation for each condition type
+func (cond Condition) Match(facts HostFacts) (bool, []string) {
+	match := true
+	explain := []string{}
+	for _, condType := range ConditionTypes {
+		condVals, ok := cond[condType]
+		if !ok {
+			continue
+		}
+		typeMatch := false
+		hostVal := ""
+		for _, condVal := range condVals {
+			switch condType {
+			case CondArch:
+				hostVal = facts.Arch
+				typeMatch = condVal == facts.Arch || condVal == archAliases[facts.Arch]
+			case CondOs:
+				// MatchOsVers supports single versions,
+				// version ranges and wildcards
+				hostVal = facts.OsVers
+				typeMatch = MatchOsVers(condVal, facts.OsVers)
+			case CondKernel:
+				hostVal = facts.Kernel
+				typeMatch = MatchOsVers(condVal, facts.Kernel)
+			case CondVirt:
+				hostVal = facts.Virt
+				typeMatch = condVal == facts.Virt
+			case CondMem:
+				hostVal = fmt.Sprintf("%dM", facts.MemTotalKB/1024)
+				low, high, _ := parseMemRange(condVal)
+				typeMatch = facts.MemTotalKB >= low && facts.MemTotalKB <= high
+			case CondCPU:
+				hostVal = facts.CPUVendor
+				typeMatch = condVal == facts.CPUVendor
+			case CondPC:
+				hostVal = yesNo(facts.Pagecache)
+				typeMatch = condVal == hostVal
+			}
+			if typeMatch {
+				break
+			}
+		}
+		result := "matched"
+		if !typeMatch {
+			result = "not matched"
+			match = false
+		}
+		explain = append(explain, fmt.Sprintf("%s=%s: %s (system: %s)", condType, strings.Join(condVals, "|"), result, hostVal))
+	}
+	return match, explain
+}
+
+// parseMemRange parses a memory size range like '64G..512G', '..32G' or
+// '1T..' and returns the limits in KB. A single size is rejected, because
+// MemTotal is always a little bit smaller than the physical memory and
+// would never match.
+// Supported units are K, M, G and T, without unit the size is in MB
+func parseMemRange(rng string) (uint64, uint64, error) {
+	low := uint64(0)
+	high := ^uint64(0)
+	if !strings.Contains(rng, "..") {
+		return low, high, fmt.Errorf("wrong memory size range '%s', please use a range like '%s..' or '..%s'", rng, rng, rng)
+	}
+	limits := strings.SplitN(rng, "..", 2)
+	if limits[0] == "" && limits[1] == "" {
+		return low, high, fmt.Errorf("wrong memory size range '%s'", rng)
+	}
+	var err error
+	if limits[0] != "" {
+		if low, err = parseMemSizeKB(limits[0]); err != nil {
+			return low, high, err
+		}
+	}
+	if limits[1] != "" {
+		if high, err = parseMemSizeKB(limits[1]); err != nil {
+			return low, high, err
+		}
+	}
+	return low, high, nil
+}
+
+// parseMemSizeKB parses a memory size like '64G' or '512GiB' and returns
+// the size in KB
+func parseMemSizeKB(size string) (uint64, error) {
+	matches := memSizePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(size)))
+	if matches == nil {
+		return 0, fmt.Errorf("wrong memory size '%s'", size)
+	}
+	val, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("wrong memory size '%s'", size)
+	}
+	switch matches[2] {
+	case "K":
+		return val, nil
+	case "G":
+		return val * 1024 * 1024, nil
+	case "T":
+		return val * 1024 * 1024 * 1024, nil
+	}
+	return val * 1024, nil
+}
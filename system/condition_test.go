@@ -0,0 +1,155 @@
+package system
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var tstHostFacts = HostFacts{
+	Arch:       "amd64",
+	OsVers:     "15-SP1",
+	Kernel:     "4.12.14-197.29-default",
+	Virt:       "kvm",
+	MemTotalKB: 64 * 1024 * 1024,
+	CPUVendor:  "intel",
+}
+
+func TestParseCondition(t *testing.T) {
+	cond, err := ParseCondition("arch=PPC64LE, arch=x86_64,mem=64G..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cond) != 2 || strings.Join(cond[CondArch], " ") != "ppc64le x86_64" || cond[CondMem][0] != "64G.." {
+		t.Fatal(cond)
+	}
+	wrongConds := map[string]string{
+		"":                "empty condition",
+		"arch":            "wrong condition 'arch'",
+		"os=":             "wrong condition 'os='",
+		"color=blue":      "unknown condition 'color'",
+		"mem=..":          "wrong memory size range '..'",
+		"mem=64X..":       "wrong memory size '64X'",
+		"arch=amd64,mem=": "wrong condition 'mem='",
+		"pagecache=maybe": "wrong condition 'pagecache=maybe'",
+		"mem=64G":         "wrong memory size range '64G'",
+		"mem=65536":       "wrong memory size range '65536'",
+	}
+	for condition, msg := range wrongConds {
+		if _, err := ParseCondition(condition); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("'%s': expected error '%s', got '%v'\n", condition, msg, err)
+		}
+	}
+}
+
+func TestConditionMatch(t *testing.T) {
+	matching := []string{
+		"arch=amd64",
+		"arch=x86_64",
+		"arch=ppc64le,arch=amd64",
+		"os=15-SP1",
+		"os=12-SP4..15-SP2",
+		"os=15*",
+		"kernel=4.12..",
+		"kernel=4.12.14-*",
+		"virt=kvm",
+		"mem=64G..64G",
+		"mem=32G..128G",
+		"mem=65536..",
+		"mem=..1T",
+		"mem=64GiB..",
+		"cpu=Intel",
+		"pagecache=no",
+		"arch=amd64,os=15..,virt=kvm,mem=32G..,cpu=intel",
+	}
+	for _, condition := range matching {
+		cond, err := ParseCondition(condition)
+		if err != nil {
+			t.Fatal(condition, err)
+		}
+		if match, explain := cond.Match(tstHostFacts); !match {
+			t.Fatal(condition, explain)
+		}
+	}
+	notMatching := []string{
+		"arch=ppc64le",
+		"os=12-SP4",
+		"os=..12-SP5",
+		"kernel=5.3..",
+		"virt=none",
+		"mem=..63G",
+		"mem=128G..",
+		"cpu=amd",
+		"pagecache=YES",
+		"arch=amd64,virt=none",
+	}
+	for _, condition := range notMatching {
+		cond, err := ParseCondition(condition)
+		if err != nil {
+			t.Fatal(condition, err)
+		}
+		if match, explain := cond.Match(tstHostFacts); match {
+			t.Fatal(condition, explain)
+		}
+	}
+
+	cond, _ := ParseCondition("virt=none,arch=amd64,arch=x86_64")
+	match, explain := cond.Match(tstHostFacts)
+	if match || len(explain) != 2 {
+		t.Fatal(match, explain)
+	}
+	if explain[0] != "arch=amd64|x86_64: matched (system: amd64)" {
+		t.Fatal(explain[0])
+	}
+	if explain[1] != "virt=none: not matched (system: kvm)" {
+		t.Fatal(explain[1])
+	}
+}
+
+func TestMemConditionRealMemTotal(t *testing.T) {
+	// MemTotal of a system with 64G physical memory
+	facts := tstHostFacts
+	facts.MemTotalKB = 65842176
+	for condition, expected := range map[string]bool{
+		"mem=60G..66G":    true,
+		"mem=..64G":       true,
+		"mem=62G..":       true,
+		"mem=64G..":       false,
+		"mem=64GiB..128G": false,
+		"mem=62880M..63G": true,
+		"mem=..62G":       false,
+		"mem=65842176K..": true,
+		"mem=65842177K..": false,
+		"mem=..65842175K": false,
+	} {
+		cond, err := ParseCondition(condition)
+		if err != nil {
+			t.Fatal(condition, err)
+		}
+		if match, explain := cond.Match(facts); match != expected {
+			t.Fatal(condition, explain)
+		}
+	}
+}
+
+func TestGetHostFacts(t *testing.T) {
+	facts := GetHostFacts()
+	if facts.Arch != runtime.GOARCH {
+		t.Fatal(facts.Arch)
+	}
+	if facts.MemTotalKB != ParseMeminfo()[MemMainTotalKey] || facts.MemTotalKB == 0 {
+		t.Fatal(facts.MemTotalKB)
+	}
+	if facts.Kernel == "" {
+		t.Fatal("kernel version not found")
+	}
+	if facts.Pagecache != IsPagecacheAvailable() {
+		t.Fatal(facts.Pagecache)
+	}
+	if !strings.HasPrefix(facts.String(), "arch="+runtime.GOARCH+" os=") {
+		t.Fatal(facts.String())
+	}
+	if tstHostFacts.String() != "arch=amd64 os=15-SP1 kernel=4.12.14-197.29-default virt=kvm mem=65536M cpu=intel pagecache=no" {
+		t.Fatal(tstHostFacts.String())
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"path"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -21,13 +22,324 @@ const (
 	cpuDir       = "/sys/devices/system/cpu"
 	cpuDirSys    = "devices/system/cpu"
 	cpupowerCmd  = "/usr/bin/cpupower"
+	pstateDirSys = "devices/system/cpu/intel_pstate"
+
+	// per cpu sysfs files relative to /sys/devices/system/cpu/cpu<N>
+	perfBiasFile     = "power/energy_perf_bias"
+	governorFile     = "cpufreq/scaling_governor"
+	governorsFile    = "cpufreq/scaling_available_governors"
+	eppFile          = "cpufreq/energy_performance_preference"
+	eppAvailableFile = "cpufreq/energy_performance_available_preferences"
+	minFreqFile      = "cpufreq/scaling_min_freq"
+	maxFreqFile      = "cpufreq/scaling_max_freq"
 )
 
 var isCPU = regexp.MustCompile(`^cpu\d+$`)
 var isState = regexp.MustCompile(`^state\d+$`)
+var isNode = regexp.MustCompile(`^node\d+$`)
+
+// GetCPUList returns the names of all CPUs of the system (cpu0 ... cpuXY)
+// sorted by their number
+func GetCPUList() []string {
+	cpus := []string{}
+	dirCont, err := ioutil.ReadDir(cpuDir)
+	if err != nil {
+		return cpus
+	}
+	for _, entry := range dirCont {
+		if isCPU.MatchString(entry.Name()) {
+			cpus = append(cpus, entry.Name())
+		}
+	}
+	SortCPUNames(cpus)
+	return cpus
+}
+
+// SortCPUNames sorts a list of cpu names (cpu0 ... cpuXY) by their number
+func SortCPUNames(cpus []string) {
+	sort.Slice(cpus, func(i, j int) bool {
+		ci, _ := strconv.Atoi(strings.TrimPrefix(cpus[i], "cpu"))
+		cj, _ := strconv.Atoi(strings.TrimPrefix(cpus[j], "cpu"))
+		return ci < cj
+	})
+}
+
+// ParseCPUList parses a cpu list in the kernel format like '0-3,8,10-11'
+// and returns the names of the CPUs (cpu0 ... cpuXY)
+func ParseCPUList(list string) ([]string, error) {
+	cpus := []string{}
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "cpu")
+		if part == "" {
+			continue
+		}
+		rng := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(rng[0])
+		if err != nil {
+			return cpus, fmt.Errorf("invalid cpu list '%s'", list)
+		}
+		last := first
+		if len(rng) == 2 {
+			if last, err = strconv.Atoi(strings.TrimPrefix(rng[1], "cpu")); err != nil || last < first {
+				return cpus, fmt.Errorf("invalid cpu range '%s' in cpu list '%s'", part, list)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, fmt.Sprintf("cpu%d", cpu))
+		}
+	}
+	return cpus, nil
+}
+
+// ResolveCPUSet returns the names of the CPUs (cpu0 ... cpuXY) of a cpu set.
+// A cpu set can be 'all', 'isolated' (the isolated CPUs of the system),
+// 'node<N>' (the CPUs of the NUMA node N) or a cpu list like '0-3,8'
+func ResolveCPUSet(set string) ([]string, error) {
+	switch {
+	case set == "all":
+		return GetCPUList(), nil
+	case set == "isolated":
+		list, err := GetSysString(path.Join(cpuDirSys, "isolated"))
+		if err != nil {
+			return []string{}, err
+		}
+		return ParseCPUList(list)
+	case isNode.MatchString(set):
+		list, err := GetSysString(path.Join("devices/system/node", set, "cpulist"))
+		if err != nil {
+			return []string{}, fmt.Errorf("NUMA node '%s' not available", set)
+		}
+		return ParseCPUList(list)
+	}
+	return ParseCPUList(set)
+}
+
+// cpupowerCPU returns the cpu argument for the cpupower command and the
+// name of the first cpu of the given cpu set (all, cpuN or a cpu list)
+func cpupowerCPU(set string) (string, string) {
+	if set == "all" {
+		return set, "cpu0"
+	}
+	cpu := strings.TrimPrefix(set, "cpu")
+	first := strings.FieldsFunc(cpu, func(r rune) bool { return r == ',' || r == '-' })
+	if len(first) == 0 {
+		return cpu, "cpu0"
+	}
+	return cpu, "cpu" + first[0]
+}
+
+// CPUMapToValues converts a map of cpu name and value into the string
+// representation used for the per cpu settings. If all CPUs have the same
+// value, 'all:<value>' is returned, otherwise the cpu values sorted by
+// cpu number like 'cpu0:0 cpu1:6'
+func CPUMapToValues(cpuVals map[string]string) string {
+	cpus := make([]string, 0, len(cpuVals))
+	same := true
+	val := ""
+	for cpu, cval := range cpuVals {
+		if len(cpus) == 0 {
+			val = cval
+		}
+		if cval != val {
+			same = false
+		}
+		cpus = append(cpus, cpu)
+	}
+	if len(cpus) == 0 {
+		return ""
+	}
+	if same {
+		return "all:" + val
+	}
+	SortCPUNames(cpus)
+	ret := ""
+	for _, cpu := range cpus {
+		ret = ret + fmt.Sprintf("%s:%s ", cpu, cpuVals[cpu])
+	}
+	return strings.TrimSpace(ret)
+}
+
+// cpuSysFileAvailable checks, if the per cpu sysfs file is available for
+// the given cpu
+func cpuSysFileAvailable(cpu, file string) bool {
+	_, err := os.Stat(path.Join(cpuDir, cpu, file))
+	return err == nil
+}
+
+// GetCPUSysMap reads a per cpu sysfs file (relative to
+// /sys/devices/system/cpu/cpu<N>) for all CPUs of the system.
+// CPUs without the file get the value 'none'.
+// If the value is the same for all CPUs, a map with the only key 'all' is
+// returned
+func GetCPUSysMap(file string) map[string]string {
+	setAll := true
+	oldval := "99"
+	val := ""
+	cpuVals := make(map[string]string)
+
+	for _, cpu := range GetCPUList() {
+		if !cpuSysFileAvailable(cpu, file) {
+			// os.Stat needs cpuDir as path - including /sys
+			val = ""
+		} else {
+			// GetSysString needs cpuDirSys as path - without /sys
+			val, _ = GetSysString(path.Join(cpuDirSys, cpu, file))
+		}
+		if val == "" {
+			val = "none"
+		}
+		if oldval == "99" {
+			// starting point
+			oldval = val
+		}
+		if oldval != val {
+			setAll = false
+		}
+		cpuVals[cpu] = val
+	}
+	if setAll && oldval != "99" {
+		cpuVals = make(map[string]string)
+		cpuVals["all"] = oldval
+	}
+	return cpuVals
+}
+
+// SetCPUSysVal writes per cpu values like 'all:<value>', 'cpu<N>:<value>'
+// or '<cpu list>:<value>' separated by spaces to the per cpu sysfs file
+// (relative to /sys/devices/system/cpu/cpu<N>).
+// 'valid' checks, if a value is supported by a cpu. Entries with the
+// value 'none' are skipped
+func SetCPUSysVal(file, value string, valid func(cpu, val string) bool) error {
+	var err error
+	for _, entry := range strings.Fields(value) {
+		fields := strings.SplitN(entry, ":", 2)
+		if len(fields) < 2 || fields[1] == "none" {
+			continue
+		}
+		cpus, cerr := ResolveCPUSet(fields[0])
+		if cerr != nil {
+			WarningLog("wrong cpu set '%s', skipping: %v", fields[0], cerr)
+			continue
+		}
+		for _, cpu := range cpus {
+			if !cpuSysFileAvailable(cpu, file) {
+				continue
+			}
+			if valid != nil && !valid(cpu, fields[1]) {
+				WarningLog("'%s' is not a valid value for '%s' of cpu '%s', skipping.", fields[1], path.Base(file), cpu)
+				continue
+			}
+			if serr := SetSysString(path.Join(cpuDirSys, cpu, file), fields[1]); serr != nil {
+				err = serr
+			}
+		}
+	}
+	return err
+}
+
+// GetIntelPstate reads a global setting of the intel_pstate driver from
+// /sys/devices/system/cpu/intel_pstate. Returns 'all:none', if the setting
+// is not supported by the system
+func GetIntelPstate(knob string) string {
+	if _, err := os.Stat(path.Join("/sys", pstateDirSys, knob)); err != nil {
+		return "all:none"
+	}
+	val, err := GetSysString(path.Join(pstateDirSys, knob))
+	if err != nil {
+		return "all:none"
+	}
+	return val
+}
+
+// SetIntelPstate sets a global setting of the intel_pstate driver in
+// /sys/devices/system/cpu/intel_pstate
+func SetIntelPstate(knob, value string) error {
+	if value == "all:none" || GetIntelPstate(knob) == "all:none" {
+		WarningLog("intel_pstate setting '%s' not supported by the system", knob)
+		return nil
+	}
+	return SetSysString(path.Join(pstateDirSys, knob), value)
+}
+
+// GetEPP retrieve the energy performance preference of the CPUs from the
+// system
+func GetEPP() map[string]string {
+	return GetCPUSysMap(eppFile)
+}
+
+// SetEPP set the energy performance preference of the CPUs
+func SetEPP(value string) error {
+	if value == "all:none" {
+		WarningLog("energy performance preference not supported by the system")
+		return nil
+	}
+	return SetCPUSysVal(eppFile, value, IsValidEPP)
+}
+
+// IsValidEPP check, if the energy performance preference is supported
+// by the cpu
+func IsValidEPP(cpu, epp string) bool {
+	val, err := ioutil.ReadFile(path.Join(cpuDir, cpu, eppAvailableFile))
+	if err == nil {
+		for _, avail := range strings.Fields(string(val)) {
+			if avail == epp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetScalingFreq retrieve the minimal ('min') or maximal ('max') scaling
+// frequency (in kHz) of the CPUs from the system
+func GetScalingFreq(limit string) map[string]string {
+	if limit == "min" {
+		return GetCPUSysMap(minFreqFile)
+	}
+	return GetCPUSysMap(maxFreqFile)
+}
+
+// SetScalingFreq set the minimal ('min') or maximal ('max') scaling
+// frequency (in kHz) of the CPUs
+func SetScalingFreq(limit, value string) error {
+	if value == "all:none" {
+		WarningLog("scaling frequency settings not supported by the system")
+		return nil
+	}
+	isFreq := func(cpu, val string) bool {
+		_, err := strconv.ParseUint(val, 10, 64)
+		return err == nil
+	}
+	if limit == "min" {
+		return SetCPUSysVal(minFreqFile, value, isFreq)
+	}
+	return SetCPUSysVal(maxFreqFile, value, isFreq)
+}
+
+// supportsPerfBiasSysfs checks, if the performance bias is available
+// in sysfs
+func supportsPerfBiasSysfs() bool {
+	for _, cpu := range GetCPUList() {
+		if cpuSysFileAvailable(cpu, perfBiasFile) {
+			return true
+		}
+	}
+	return false
+}
 
 // GetPerfBias retrieve CPU performance configuration from the system
+// /sys/devices/system/cpu/cpu*/power/energy_perf_bias is used, if
+// available. The 'cpupower' command is only used as fallback
 func GetPerfBias() string {
+	if supportsPerfBiasSysfs() {
+		return CPUMapToValues(GetCPUSysMap(perfBiasFile))
+	}
+	return getPerfBiasCpupower()
+}
+
+// getPerfBiasCpupower retrieve CPU performance configuration from the
+// system using 'cpupower' command
+func getPerfBiasCpupower() string {
 	isPBCpu := regexp.MustCompile(`analyzing CPU \d+`)
 	isPBias := regexp.MustCompile(`perf-bias: \d+`)
 	setAll := true
@@ -70,23 +382,32 @@ func GetPerfBias() string {
 	return strings.TrimSpace(str)
 }
 
-// SetPerfBias set CPU performance configuration to the system using 'cpupower' command
+// SetPerfBias set CPU performance configuration to the system
+// /sys/devices/system/cpu/cpu*/power/energy_perf_bias is used, if
+// available. The 'cpupower' command is only used as fallback
 func SetPerfBias(value string) error {
+	if supportsPerfBiasSysfs() {
+		return SetCPUSysVal(perfBiasFile, value, nil)
+	}
+	return setPerfBiasCpupower(value)
+}
+
+// setPerfBiasCpupower set CPU performance configuration to the system
+// using 'cpupower' command
+func setPerfBiasCpupower(value string) error {
 	//cmd := exec.Command("cpupower", "-c", "all", "set", "-b", value)
 	cpu := ""
 	if !SupportsPerfBias() {
 		WarningLog(notSupported)
 		return nil
 	}
-	for k, entry := range strings.Fields(value) {
+	for _, entry := range strings.Fields(value) {
 		fields := strings.Split(entry, ":")
-		if fields[0] != "all" {
-			cpu = strconv.Itoa(k)
-		} else {
-			cpu = fields[0]
+		if len(fields) < 2 || fields[1] == "none" {
+			continue
 		}
-		cmd := exec.Command(cpupowerCmd, "-c", cpu, "set", "-b", fields[1])
-		out, err := cmd.CombinedOutput()
+		cpu, _ = cpupowerCPU(fields[0])
+		out, err := RunChangeCmd(cpupowerCmd, "-c", cpu, "set", "-b", fields[1])
 		if err != nil {
 			WarningLog("failed to invoke external command 'cpupower -c %s set -b %s': %v, output: %s", cpu, fields[1], err, out)
 			return err
@@ -97,6 +418,9 @@ func SetPerfBias(value string) error {
 
 // SupportsPerfBias check, if the system will support CPU performance settings
 func SupportsPerfBias() bool {
+	if supportsPerfBiasSysfs() {
+		return true
+	}
 	cmdName := cpupowerCmd
 	cmdArgs := []string{"info", "-b"}
 
@@ -115,75 +439,47 @@ func SupportsPerfBias() bool {
 // GetGovernor retrieve performance configuration regarding to cpu frequency
 // from the system
 func GetGovernor() map[string]string {
-	setAll := true
-	oldgov := "99"
-	gov := ""
-	gGov := make(map[string]string)
+	return GetCPUSysMap(governorFile)
+}
 
-	dirCont, err := ioutil.ReadDir(cpuDir)
-	if err != nil {
-		return gGov
-	}
-	for _, entry := range dirCont {
-		if isCPU.MatchString(entry.Name()) {
-			if _, err = os.Stat(path.Join(cpuDir, entry.Name(), "cpufreq", "scaling_governor")); os.IsNotExist(err) {
-				// os.Stat needs cpuDir as path - including /sys
-				gov = ""
-			} else {
-				// GetSysString needs cpuDirSys as path - without /sys
-				gov, _ = GetSysString(path.Join(cpuDirSys, entry.Name(), "cpufreq", "scaling_governor"))
-			}
-			if gov == "" {
-				gov = "none"
-			}
-			if oldgov == "99" {
-				// starting point
-				oldgov = gov
-			}
-			if oldgov != gov {
-				setAll = false
-			}
-			gGov[entry.Name()] = gov
-		}
+// SetGovernor set performance configuration regarding to cpu frequency
+// to the system
+// /sys/devices/system/cpu/cpu*/cpufreq/scaling_governor is used, if
+// available. The 'cpupower' command is only used as fallback
+func SetGovernor(value, info string) error {
+	if value == "all:none" || info == "notSupported" {
+		WarningLog("governor settings not supported by the system")
+		return nil
 	}
-	if setAll {
-		gGov = make(map[string]string)
-		gGov["all"] = oldgov
+	if cpuSysFileAvailable("cpu0", governorFile) {
+		return SetCPUSysVal(governorFile, value, IsValidGovernor)
 	}
-	return gGov
+	return setGovernorCpupower(value)
 }
 
-// SetGovernor set performance configuration regarding to cpu frequency
-// to the system using 'cpupower' command
-func SetGovernor(value, info string) error {
+// setGovernorCpupower set performance configuration regarding to cpu
+// frequency to the system using 'cpupower' command
+func setGovernorCpupower(value string) error {
 	//cmd := exec.Command("cpupower", "-c", "all", "frequency-set", "-g", value)
 	cpu := ""
 	tst := ""
 	cmdName := cpupowerCmd
 
-	if value == "all:none" || info == "notSupported" {
-		WarningLog("governor settings not supported by the system")
-		return nil
-	}
 	if !CmdIsAvailable(cmdName) {
 		WarningLog("command '%s' not found", cmdName)
 		return nil
 	}
-	for k, entry := range strings.Fields(value) {
+	for _, entry := range strings.Fields(value) {
 		fields := strings.Split(entry, ":")
-		if fields[0] != "all" {
-			cpu = strconv.Itoa(k)
-			tst = cpu
-		} else {
-			cpu = fields[0]
-			tst = "cpu0"
+		if len(fields) < 2 || fields[1] == "none" {
+			continue
 		}
+		cpu, tst = cpupowerCPU(fields[0])
 		if !IsValidGovernor(tst, fields[1]) {
 			WarningLog("'%s' is not a valid governor, skipping.", fields[1])
 			continue
 		}
-		cmd := exec.Command(cpupowerCmd, "-c", cpu, "frequency-set", "-g", fields[1])
-		out, err := cmd.CombinedOutput()
+		out, err := RunChangeCmd(cpupowerCmd, "-c", cpu, "frequency-set", "-g", fields[1])
 		if err != nil {
 			WarningLog("failed to invoke external command 'cpupower -c %s frequency-set -g %s': %v, output: %s", cpu, fields[1], err, out)
 			return err
@@ -194,9 +490,13 @@ func SetGovernor(value, info string) error {
 
 // IsValidGovernor check, if the system will support CPU frequency settings
 func IsValidGovernor(cpu, gov string) bool {
-	val, err := ioutil.ReadFile(path.Join(cpuDir, cpu, "/cpufreq/scaling_available_governors"))
-	if err == nil && strings.Contains(string(val), gov) {
-		return true
+	val, err := ioutil.ReadFile(path.Join(cpuDir, cpu, governorsFile))
+	if err == nil {
+		for _, avail := range strings.Fields(string(val)) {
+			if avail == gov {
+				return true
+			}
+		}
 	}
 	return false
 }
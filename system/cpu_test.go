@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -202,14 +203,15 @@ func TestMissingCmd(t *testing.T) {
 	if err := os.Rename(cmdName, savName); err != nil {
 		t.Fatal(err)
 	}
+	// cpupower is only used as fallback, if sysfs is not available
 	value := GetPerfBias()
-	if value != "all:none" {
+	if value != "all:none" && !supportsPerfBiasSysfs() {
 		t.Fatal(value)
 	}
 	if err := SetPerfBias("all:15"); err != nil {
 		t.Fatal(err)
 	}
-	if SupportsPerfBias() {
+	if SupportsPerfBias() && !supportsPerfBiasSysfs() {
 		t.Fatalf("reports supported, but shouldn't")
 	}
 	if err := SetGovernor("all:performance", ""); err != nil {
@@ -219,3 +221,131 @@ func TestMissingCmd(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := ParseCPUList("0-3,8,10-11")
+	if err != nil || strings.Join(cpus, " ") != "cpu0 cpu1 cpu2 cpu3 cpu8 cpu10 cpu11" {
+		t.Fatal(cpus, err)
+	}
+	cpus, err = ParseCPUList("cpu2")
+	if err != nil || strings.Join(cpus, " ") != "cpu2" {
+		t.Fatal(cpus, err)
+	}
+	cpus, err = ParseCPUList("")
+	if err != nil || len(cpus) != 0 {
+		t.Fatal(cpus, err)
+	}
+	if _, err = ParseCPUList("3-1"); err == nil {
+		t.Fatal("invalid range '3-1' accepted")
+	}
+	if _, err = ParseCPUList("a-b"); err == nil {
+		t.Fatal("invalid list 'a-b' accepted")
+	}
+}
+
+func TestResolveCPUSet(t *testing.T) {
+	cpus, err := ResolveCPUSet("1-2")
+	if err != nil || strings.Join(cpus, " ") != "cpu1 cpu2" {
+		t.Fatal(cpus, err)
+	}
+	cpus, err = ResolveCPUSet("all")
+	if err != nil || strings.Join(cpus, " ") != strings.Join(GetCPUList(), " ") {
+		t.Fatal(cpus, err)
+	}
+	if _, err = ResolveCPUSet("node4711"); err == nil {
+		t.Fatal("not existing NUMA node accepted")
+	}
+}
+
+func TestSortCPUNames(t *testing.T) {
+	cpus := []string{"cpu10", "cpu2", "cpu1", "cpu0"}
+	SortCPUNames(cpus)
+	if strings.Join(cpus, " ") != "cpu0 cpu1 cpu2 cpu10" {
+		t.Fatal(cpus)
+	}
+}
+
+func TestCpupowerCPU(t *testing.T) {
+	cpu, first := cpupowerCPU("all")
+	if cpu != "all" || first != "cpu0" {
+		t.Fatal(cpu, first)
+	}
+	cpu, first = cpupowerCPU("cpu3")
+	if cpu != "3" || first != "cpu3" {
+		t.Fatal(cpu, first)
+	}
+	cpu, first = cpupowerCPU("4-7,9")
+	if cpu != "4-7,9" || first != "cpu4" {
+		t.Fatal(cpu, first)
+	}
+}
+
+func TestCPUMapToValues(t *testing.T) {
+	if val := CPUMapToValues(map[string]string{"cpu0": "6", "cpu1": "6"}); val != "all:6" {
+		t.Fatal(val)
+	}
+	if val := CPUMapToValues(map[string]string{"cpu10": "0", "cpu2": "6", "cpu1": "6"}); val != "cpu1:6 cpu2:6 cpu10:0" {
+		t.Fatal(val)
+	}
+	if val := CPUMapToValues(map[string]string{"all": "performance"}); val != "all:performance" {
+		t.Fatal(val)
+	}
+	if val := CPUMapToValues(map[string]string{}); val != "" {
+		t.Fatal(val)
+	}
+}
+
+func TestGetCPUSysMap(t *testing.T) {
+	cpuVals := GetCPUSysMap("not_avail")
+	if len(GetCPUList()) == 0 {
+		if len(cpuVals) != 0 {
+			t.Fatal(cpuVals)
+		}
+	} else if len(cpuVals) != 1 || cpuVals["all"] != "none" {
+		t.Fatal(cpuVals)
+	}
+	if err := SetCPUSysVal("not_avail", "all:1 cpu0:none", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetCPUSysVal("not_avail", "x-y:1", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntelPstate(t *testing.T) {
+	if val := GetIntelPstate("not_avail"); val != "all:none" {
+		t.Fatal(val)
+	}
+	if err := SetIntelPstate("not_avail", "1"); err != nil {
+		t.Fatal(err)
+	}
+	val := GetIntelPstate("no_turbo")
+	if val != "all:none" && val != "0" && val != "1" {
+		t.Fatal(val)
+	}
+}
+
+func TestEPPAndScalingFreq(t *testing.T) {
+	if IsValidEPP("not_avail", "performance") {
+		t.Fatal("epp reported as valid for not existing cpu")
+	}
+	for cpu, val := range GetEPP() {
+		t.Logf("energy performance preference of '%s': '%s'\n", cpu, val)
+	}
+	for cpu, val := range GetScalingFreq("min") {
+		if _, err := strconv.Atoi(val); err != nil && val != "none" {
+			t.Fatalf("wrong scaling_min_freq '%s' for '%s'\n", val, cpu)
+		}
+	}
+	for cpu, val := range GetScalingFreq("max") {
+		if _, err := strconv.Atoi(val); err != nil && val != "none" {
+			t.Fatalf("wrong scaling_max_freq '%s' for '%s'\n", val, cpu)
+		}
+	}
+	if err := SetEPP("all:none"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetScalingFreq("min", "all:none"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,7 +1,6 @@
 package system
 
 import (
-	"io/ioutil"
 	"os/exec"
 	"regexp"
 	"strings"
@@ -9,7 +8,7 @@ import (
 
 // SystemctlEnable call systemctl enable on thing.
 func SystemctlEnable(thing string) error {
-	if out, err := exec.Command("systemctl", "enable", thing).CombinedOutput(); err != nil {
+	if out, err := RunChangeCmd("systemctl", "enable", thing); err != nil {
 		return ErrorLog("%v - Failed to call systemctl enable on %s - %s", err, thing, string(out))
 	}
 	return nil
@@ -17,16 +16,32 @@ func SystemctlEnable(thing string) error {
 
 // SystemctlDisable call systemctl disable on thing.
 func SystemctlDisable(thing string) error {
-	if out, err := exec.Command("systemctl", "disable", thing).CombinedOutput(); err != nil {
+	if out, err := RunChangeCmd("systemctl", "disable", thing); err != nil {
 		return ErrorLog("%v - Failed to call systemctl disable on %s - %s", err, thing, string(out))
 	}
 	return nil
 }
 
+// SystemctlMask call systemctl mask on thing.
+func SystemctlMask(thing string) error {
+	if out, err := RunChangeCmd("systemctl", "mask", thing); err != nil {
+		return ErrorLog("%v - Failed to call systemctl mask on %s - %s", err, thing, string(out))
+	}
+	return nil
+}
+
+// SystemctlUnmask call systemctl unmask on thing.
+func SystemctlUnmask(thing string) error {
+	if out, err := RunChangeCmd("systemctl", "unmask", thing); err != nil {
+		return ErrorLog("%v - Failed to call systemctl unmask on %s - %s", err, thing, string(out))
+	}
+	return nil
+}
+
 // SystemctlRestart call systemctl restart on thing.
 func SystemctlRestart(thing string) error {
 	if IsSystemRunning() {
-		if out, err := exec.Command("systemctl", "restart", thing).CombinedOutput(); err != nil {
+		if out, err := RunChangeCmd("systemctl", "restart", thing); err != nil {
 			return ErrorLog("%v - Failed to call systemctl restart on %s - %s", err, thing, string(out))
 		}
 	}
@@ -36,7 +51,7 @@ func SystemctlRestart(thing string) error {
 // SystemctlStart call systemctl start on thing.
 func SystemctlStart(thing string) error {
 	if IsSystemRunning() {
-		if out, err := exec.Command("systemctl", "start", thing).CombinedOutput(); err != nil {
+		if out, err := RunChangeCmd("systemctl", "start", thing); err != nil {
 			return ErrorLog("%v - Failed to call systemctl start on %s - %s", err, thing, string(out))
 		}
 	}
@@ -46,7 +61,7 @@ func SystemctlStart(thing string) error {
 // SystemctlStop call systemctl stop on thing.
 func SystemctlStop(thing string) error {
 	if IsSystemRunning() {
-		if out, err := exec.Command("systemctl", "stop", thing).CombinedOutput(); err != nil {
+		if out, err := RunChangeCmd("systemctl", "stop", thing); err != nil {
 			return ErrorLog("%v - Failed to call systemctl stop on %s - %s", err, thing, string(out))
 		}
 	}
@@ -81,6 +96,21 @@ func SystemctlIsRunning(thing string) bool {
 	return false
 }
 
+// SystemctlIsEnabled returns the unit file state of thing as reported by
+// 'systemctl is-enabled' (e.g. 'enabled', 'disabled', 'masked', 'static').
+// Return empty string if it cannot be determined.
+func SystemctlIsEnabled(thing string) string {
+	// is-enabled returns a non-zero exit code for disabled or masked
+	// units, so only the output is evaluated
+	out, _ := exec.Command("systemctl", "is-enabled", thing).CombinedOutput()
+	state := strings.TrimSpace(strings.Split(string(out), "\n")[0])
+	switch state {
+	case "enabled", "enabled-runtime", "linked", "linked-runtime", "alias", "masked", "masked-runtime", "static", "indirect", "disabled", "generated", "transient":
+		return state
+	}
+	return ""
+}
+
 // IsSystemRunning returns true, if 'is-system-running' reports 'running'
 // or 'starting'. In all other cases it returns false, which means: do not
 // call 'start' or 'restart' to prevent 'Transaction is destructive' messages
@@ -101,7 +131,7 @@ func IsSystemRunning() bool {
 // WriteTunedAdmProfile write new profile to tuned, used instead of sometimes
 // unreliable 'tuned-adm' command
 func WriteTunedAdmProfile(profileName string) error {
-	err := ioutil.WriteFile("/etc/tuned/active_profile", []byte(profileName), 0644)
+	err := WriteFile("/etc/tuned/active_profile", []byte(profileName), 0644)
 	if err != nil {
 		return ErrorLog("Failed to write tuned profile '%s' to '%s': %v", profileName, "/etc/tuned/active_profile", err)
 	}
@@ -113,7 +143,7 @@ func WriteTunedAdmProfile(profileName string) error {
 // may be unreliable in newer tuned versions, so better use 'tuned-adm active'
 // Return empty string if it cannot be determined.
 func GetTunedProfile() string {
-	content, err := ioutil.ReadFile("/etc/tuned/active_profile")
+	content, err := ReadFile("/etc/tuned/active_profile")
 	if err != nil {
 		return ""
 	}
@@ -122,7 +152,7 @@ func GetTunedProfile() string {
 
 // TunedAdmOff calls tuned-adm to switch off the active profile.
 func TunedAdmOff() error {
-	if out, err := exec.Command("tuned-adm", "off").CombinedOutput(); err != nil {
+	if out, err := RunChangeCmd("tuned-adm", "off"); err != nil {
 		return ErrorLog("Failed to call tuned-adm to switch off the active profile - %v %s", err, string(out))
 	}
 	return nil
@@ -132,7 +162,7 @@ func TunedAdmOff() error {
 // newer versions of tuned seems to be reliable with this command and they
 // changed the behaviour/handling of the file /etc/tuned/active_profile
 func TunedAdmProfile(profileName string) error {
-	if out, err := exec.Command("tuned-adm", "profile", profileName).CombinedOutput(); err != nil {
+	if out, err := RunChangeCmd("tuned-adm", "profile", profileName); err != nil {
 		return ErrorLog("Failed to call tuned-adm to active profile %s - %v %s", profileName, err, string(out))
 	}
 	return nil
@@ -41,6 +41,18 @@ func TestSystemctl(t *testing.T) {
 	if SystemctlIsRunning(testService) {
 		t.Fatalf("service '%s' still running\n", testService)
 	}
+	if err := SystemctlMask(testService); err != nil {
+		t.Fatal(err)
+	}
+	if state := SystemctlIsEnabled(testService); state != "masked" {
+		t.Fatalf("service '%s' not masked - '%s'\n", testService, state)
+	}
+	if err := SystemctlUnmask(testService); err != nil {
+		t.Fatal(err)
+	}
+	if state := SystemctlIsEnabled(testService); state == "masked" {
+		t.Fatalf("service '%s' still masked\n", testService)
+	}
 	if err := SystemctlEnableStart(testService); err != nil {
 		t.Fatal(err)
 	}
@@ -66,6 +78,9 @@ func TestSystemctl(t *testing.T) {
 	if err := SystemctlDisableStop("UnkownService"); err == nil {
 		t.Fatal(err)
 	}
+	if state := SystemctlIsEnabled("UnkownService"); state != "" {
+		t.Fatal(state)
+	}
 }
 
 func TestSystemctlIsRunning(t *testing.T) {
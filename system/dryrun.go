@@ -0,0 +1,183 @@
+package system
+
+// Dry run mode - all changes of the system are recorded as write plan
+// instead of being done. The written and removed files are kept in an
+// overlay, so following reads of saptune see the planned content
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// actions of the steps of the write plan
+const (
+	PlanWrite  = "write"
+	PlanRemove = "remove"
+	PlanMkdir  = "mkdir"
+	PlanRun    = "run"
+)
+
+// PlanStep is a change of the system recorded in dry run mode.
+// 'Target' is the file or directory name or the command line,
+// 'Value' the content written to the file
+type PlanStep struct {
+	Action string
+	Target string
+	Value  string
+}
+
+var dryRun = false
+var writePlan = []PlanStep{}
+
+// dryRunFiles contains the files written (content) or removed (nil) in
+// dry run mode
+var dryRunFiles = make(map[string]*string)
+
+// SetDryRun switches the dry run mode on or off and clears the write plan
+func SetDryRun(on bool) {
+	dryRun = on
+	writePlan = []PlanStep{}
+	dryRunFiles = make(map[string]*string)
+}
+
+// IsDryRun returns true, if the dry run mode is switched on
+func IsDryRun() bool {
+	return dryRun
+}
+
+// WritePlan returns the changes recorded in dry run mode in the order
+// they would be done
+func WritePlan() []PlanStep {
+	return writePlan
+}
+
+// recordStep adds a step to the write plan
+func recordStep(action, target, value string) {
+	writePlan = append(writePlan, PlanStep{Action: action, Target: target, Value: value})
+}
+
+// isKernelFile checks, if the file is a file of the kernel interfaces
+// /proc and /sys, which can not be created by writing
+func isKernelFile(fileName string) bool {
+	return strings.HasPrefix(fileName, "/proc/") || strings.HasPrefix(fileName, "/sys/")
+}
+
+// WriteFile writes the content to the file like ioutil.WriteFile.
+// In dry run mode the write is only recorded
+func WriteFile(fileName string, content []byte, perm os.FileMode) error {
+	if !dryRun {
+		return ioutil.WriteFile(fileName, content, perm)
+	}
+	if isKernelFile(fileName) {
+		// the kernel file has to exist, as writing would fail
+		if _, err := os.Stat(fileName); err != nil {
+			return err
+		}
+	}
+	value := string(content)
+	dryRunFiles[fileName] = &value
+	recordStep(PlanWrite, fileName, value)
+	return nil
+}
+
+// RemoveFile removes the file like os.Remove.
+// In dry run mode the removal is only recorded
+func RemoveFile(fileName string) error {
+	if !dryRun {
+		return os.Remove(fileName)
+	}
+	if !FileExists(fileName) {
+		return &os.PathError{Op: "remove", Path: fileName, Err: syscall.ENOENT}
+	}
+	dryRunFiles[fileName] = nil
+	recordStep(PlanRemove, fileName, "")
+	return nil
+}
+
+// MkdirAll creates the directory and all missing parent directories like
+// os.MkdirAll. In dry run mode the creation of a missing directory is
+// only recorded
+func MkdirAll(dirName string, perm os.FileMode) error {
+	if !dryRun {
+		return os.MkdirAll(dirName, perm)
+	}
+	if _, err := os.Stat(dirName); err == nil {
+		return nil
+	}
+	for _, step := range writePlan {
+		if step.Action == PlanMkdir && step.Target == dirName {
+			return nil
+		}
+	}
+	recordStep(PlanMkdir, dirName, "")
+	return nil
+}
+
+// ReadFile reads the content of the file like ioutil.ReadFile.
+// In dry run mode the files written or removed before are respected
+func ReadFile(fileName string) ([]byte, error) {
+	if dryRun {
+		if content, ok := dryRunFiles[fileName]; ok {
+			if content == nil {
+				return nil, &os.PathError{Op: "open", Path: fileName, Err: syscall.ENOENT}
+			}
+			return []byte(*content), nil
+		}
+	}
+	return ioutil.ReadFile(fileName)
+}
+
+// FileExists checks, if the file exists.
+// In dry run mode the files written or removed before are respected
+func FileExists(fileName string) bool {
+	if dryRun {
+		if content, ok := dryRunFiles[fileName]; ok {
+			return content != nil
+		}
+	}
+	_, err := os.Stat(fileName)
+	return err == nil
+}
+
+// ReadDirNames returns the sorted names of the files in the directory.
+// In dry run mode the files written or removed before are respected
+func ReadDirNames(dirName string) ([]string, error) {
+	names := []string{}
+	entries, err := ioutil.ReadDir(dirName)
+	if err != nil && (!dryRun || !os.IsNotExist(err)) {
+		return names, err
+	}
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		found[entry.Name()] = true
+	}
+	if dryRun {
+		for fileName, content := range dryRunFiles {
+			if path.Dir(fileName) == path.Clean(dirName) {
+				found[path.Base(fileName)] = content != nil
+			}
+		}
+	}
+	for name, exists := range found {
+		if exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RunChangeCmd runs a command, which changes the system, and returns its
+// combined output. In dry run mode the command is only recorded
+func RunChangeCmd(cmdName string, cmdArgs ...string) ([]byte, error) {
+	if !dryRun {
+		return exec.Command(cmdName, cmdArgs...).CombinedOutput()
+	}
+	recordStep(PlanRun, strings.Join(append([]string{cmdName}, cmdArgs...), " "), "")
+	return []byte{}, nil
+}
@@ -0,0 +1,96 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	tstDir := "/tmp/saptune_test/dryrun"
+	os.RemoveAll("/tmp/saptune_test")
+	defer os.RemoveAll("/tmp/saptune_test")
+	if err := os.MkdirAll(tstDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	oldFile := path.Join(tstDir, "old")
+	if err := ioutil.WriteFile(oldFile, []byte("old content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetDryRun(true)
+	defer SetDryRun(false)
+	if !IsDryRun() {
+		t.Fatal("dry run mode not switched on")
+	}
+
+	newDir := path.Join(tstDir, "new")
+	newFile := path.Join(newDir, "file")
+	if err := MkdirAll(newDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// existing directory, not recorded
+	if err := MkdirAll(tstDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(newFile, []byte("new content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFile(oldFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFile(path.Join(tstDir, "not_avail")); !os.IsNotExist(err) {
+		t.Fatalf("remove of a not existing file: '%v'\n", err)
+	}
+	if err := WriteFile("/proc/sys/vm/not_avail", []byte("1"), 0644); !os.IsNotExist(err) {
+		t.Fatalf("write of a not existing kernel file: '%v'\n", err)
+	}
+	if _, err := RunChangeCmd("systemctl", "restart", "not_avail.service"); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing changed on the file system
+	if _, err := os.Stat(newDir); !os.IsNotExist(err) {
+		t.Fatalf("directory '%s' created in dry run mode\n", newDir)
+	}
+	if content, err := ioutil.ReadFile(oldFile); err != nil || string(content) != "old content" {
+		t.Fatalf("file '%s' changed in dry run mode: '%s', '%v'\n", oldFile, string(content), err)
+	}
+
+	// the overlay shows the planned content
+	if content, err := ReadFile(newFile); err != nil || string(content) != "new content" {
+		t.Fatalf("wrong planned content '%s', '%v'\n", string(content), err)
+	}
+	if _, err := ReadFile(oldFile); !os.IsNotExist(err) {
+		t.Fatalf("removed file '%s' still readable: '%v'\n", oldFile, err)
+	}
+	if !FileExists(newFile) || FileExists(oldFile) {
+		t.Fatal("wrong planned file existence")
+	}
+	if names, err := ReadDirNames(newDir); err != nil || !reflect.DeepEqual(names, []string{"file"}) {
+		t.Fatalf("wrong planned directory content '%+v', '%v'\n", names, err)
+	}
+	if names, err := ReadDirNames(tstDir); err != nil || len(names) != 0 {
+		t.Fatalf("wrong planned directory content '%+v', '%v'\n", names, err)
+	}
+
+	expected := []PlanStep{
+		{Action: PlanMkdir, Target: newDir},
+		{Action: PlanWrite, Target: newFile, Value: "new content"},
+		{Action: PlanRemove, Target: oldFile},
+		{Action: PlanRun, Target: "systemctl restart not_avail.service"},
+	}
+	if !reflect.DeepEqual(WritePlan(), expected) {
+		t.Fatalf("got '%+v', expected '%+v'\n", WritePlan(), expected)
+	}
+
+	// switching the dry run mode clears the plan and the overlay
+	SetDryRun(false)
+	if IsDryRun() || len(WritePlan()) != 0 {
+		t.Fatal("dry run mode not switched off")
+	}
+	if content, err := ReadFile(oldFile); err != nil || string(content) != "old content" {
+		t.Fatalf("wrong content '%s', '%v'\n", string(content), err)
+	}
+}
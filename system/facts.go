@@ -0,0 +1,60 @@
+package system
+
+// Provide system facts, which can be used in expressions of Note
+// definition files to compute parameter values.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SystemFactNames contains the names of the supported system facts
+var SystemFactNames = []string{"MEM_TOTAL_BYTES", "MEM_TOTAL_KB", "MEM_TOTAL_MB", "SWAP_TOTAL_KB", "PAGE_SIZE", "HUGEPAGE_SIZE_KB", "CPU_COUNT", "NUMA_NODES"}
+
+// SysctlFactPrefix is the prefix of a system fact referencing the current
+// value of a sysctl parameter like 'sysctl:kernel.shmmax'
+const SysctlFactPrefix = "sysctl:"
+
+// GetSystemFact returns the value of a system fact like 'MEM_TOTAL_BYTES'
+// or 'sysctl:kernel.shmmax'
+func GetSystemFact(name string) (int64, error) {
+	switch name {
+	case "MEM_TOTAL_BYTES":
+		return int64(ParseMeminfo()[MemMainTotalKey] * 1024), nil
+	case "MEM_TOTAL_KB":
+		return int64(ParseMeminfo()[MemMainTotalKey]), nil
+	case "MEM_TOTAL_MB":
+		return int64(GetMainMemSizeMB()), nil
+	case "SWAP_TOTAL_KB":
+		return int64(ParseMeminfo()[MemSwapTotalKey]), nil
+	case "PAGE_SIZE":
+		return int64(os.Getpagesize()), nil
+	case "HUGEPAGE_SIZE_KB":
+		return int64(ParseMeminfo()["Hugepagesize"]), nil
+	case "CPU_COUNT":
+		return int64(len(GetCPUList())), nil
+	case "NUMA_NODES":
+		nodes, _ := filepath.Glob("/sys/devices/system/node/node[0-9]*")
+		if len(nodes) == 0 {
+			// system without NUMA support has one node
+			return 1, nil
+		}
+		return int64(len(nodes)), nil
+	}
+	if strings.HasPrefix(name, SysctlFactPrefix) {
+		key := strings.TrimPrefix(name, SysctlFactPrefix)
+		val, err := GetSysctlString(key)
+		if err != nil {
+			return 0, fmt.Errorf("sysctl parameter '%s' not available", key)
+		}
+		ival, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value '%s' of sysctl parameter '%s' is not a single integer", val, key)
+		}
+		return ival, nil
+	}
+	return 0, fmt.Errorf("unknown system fact, supported are %s and %s<parameter>", strings.Join(SystemFactNames, ", "), SysctlFactPrefix)
+}
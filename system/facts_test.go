@@ -0,0 +1,38 @@
+package system
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetSystemFact(t *testing.T) {
+	for _, fact := range SystemFactNames {
+		val, err := GetSystemFact(fact)
+		if err != nil || val < 0 {
+			t.Fatal(fact, val, err)
+		}
+		if fact != "SWAP_TOTAL_KB" && fact != "HUGEPAGE_SIZE_KB" && val == 0 {
+			t.Fatal(fact, val)
+		}
+	}
+	if val, _ := GetSystemFact("PAGE_SIZE"); val != int64(os.Getpagesize()) {
+		t.Fatal(val)
+	}
+	memKB, _ := GetSystemFact("MEM_TOTAL_KB")
+	if val, _ := GetSystemFact("MEM_TOTAL_BYTES"); val != memKB*1024 {
+		t.Fatal(val)
+	}
+	if val, err := GetSystemFact("sysctl:vm.max_map_count"); err != nil || val <= 0 {
+		t.Fatal(val, err)
+	}
+	if _, err := GetSystemFact("sysctl:kernel.sem"); err == nil || !strings.Contains(err.Error(), "not a single integer") {
+		t.Fatal(err)
+	}
+	if _, err := GetSystemFact("sysctl:not.avail"); err == nil {
+		t.Fatal("not available sysctl parameter accepted")
+	}
+	if _, err := GetSystemFact("MEM_TOTL_KB"); err == nil || !strings.Contains(err.Error(), "unknown system fact") {
+		t.Fatal(err)
+	}
+}
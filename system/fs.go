@@ -3,7 +3,6 @@ package system
 import (
 	"fmt"
 	"io/ioutil"
-	"os/exec"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -114,8 +113,7 @@ func ParseMtabMounts() MountPoints {
 
 // RemountSHM invoke mount command to resize /dev/shm to the specified value.
 func RemountSHM(newSizeMB uint64) error {
-	cmd := exec.Command("mount", "-o", fmt.Sprintf("remount,size=%dM", newSizeMB), "/dev/shm")
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := RunChangeCmd("mount", "-o", fmt.Sprintf("remount,size=%dM", newSizeMB), "/dev/shm"); err != nil {
 		return fmt.Errorf("failed to invoke external command mount: %v, output: %s", err, out)
 	}
 	return nil
@@ -5,15 +5,54 @@ package system
 import (
 	"bytes"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"os/user"
+	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 var consecutiveSpaces = regexp.MustCompile("[[:space:]]+")
 
+// LimitsConfFile is the main configuration file of pam_limits
+const LimitsConfFile = "/etc/security/limits.conf"
+
+// LimitsDropInDir is the drop-in directory of pam_limits
+const LimitsDropInDir = "/etc/security/limits.d"
+
+// priorities of the limits domains as used by pam_limits.
+// a lower number means a more specific domain
+const (
+	limitsPrioUser = iota
+	limitsPrioGroup
+	limitsPrioAllGroup
+	limitsPrioAll
+	limitsPrioNone
+)
+
+// lookupUserGroups returns the names of the groups a user belongs to.
+// variable to be able to replace the lookup during testing
+var lookupUserGroups = func(name string) []string {
+	groups := []string{}
+	usr, err := user.Lookup(name)
+	if err != nil {
+		return groups
+	}
+	gids, err := usr.GroupIds()
+	if err != nil {
+		return groups
+	}
+	for _, gid := range gids {
+		if grp, err := user.LookupGroupId(gid); err == nil {
+			groups = append(groups, grp.Name)
+		}
+	}
+	return groups
+}
+
 // SecurityLimitInt is an integer number where -1 represents unlimited value.
 type SecurityLimitInt int
 
@@ -60,9 +99,9 @@ type SecLimits struct {
 // structures.
 func ParseSecLimitsFile(fileName string) (*SecLimits, error) {
 	limitsConfFile := "/etc/security/limits.conf"
-	content, err := ioutil.ReadFile(fileName)
+	content, err := ReadFile(fileName)
 	if err != nil {
-		content, err = ioutil.ReadFile(limitsConfFile)
+		content, err = ReadFile(limitsConfFile)
 		if err != nil {
 			return nil, ErrorLog("failed to open limits config file: %v", err)
 		}
@@ -148,6 +187,90 @@ func (limits *SecLimits) Set(domain, typeName, item, value string) {
 	})
 }
 
+// GetSecLimitsFiles returns the limits configuration files in the order
+// pam_limits reads them: the main configuration file first, followed by
+// the '*.conf' files of the drop-in directory in lexical order
+func GetSecLimitsFiles(confFile, dropInDir string) []string {
+	files := []string{confFile}
+	dropIns, err := filepath.Glob(path.Join(dropInDir, "*.conf"))
+	if err != nil {
+		return files
+	}
+	sort.Strings(dropIns)
+	return append(files, dropIns...)
+}
+
+// secLimitsDomainPrio returns the priority of the limits entry domain
+// 'entryDomain' for the requested domain 'domain' like pam_limits would do.
+// 'groups' are the groups of the requested domain, if it is a user name.
+// Return limitsPrioNone, if the entry does not apply to the domain
+func secLimitsDomainPrio(entryDomain, domain string, groups []string) int {
+	switch {
+	case entryDomain == domain:
+		switch domain[0] {
+		case '@':
+			return limitsPrioGroup
+		case '%':
+			return limitsPrioAllGroup
+		case '*':
+			return limitsPrioAll
+		}
+		return limitsPrioUser
+	case entryDomain == "*":
+		return limitsPrioAll
+	case strings.HasPrefix(domain, "@") && entryDomain == "%"+domain[1:]:
+		return limitsPrioAllGroup
+	}
+	for _, grp := range groups {
+		if entryDomain == "@"+grp {
+			return limitsPrioGroup
+		}
+		if entryDomain == "%"+grp {
+			return limitsPrioAllGroup
+		}
+	}
+	return limitsPrioNone
+}
+
+// EffectiveSecLimit returns the effective value of the limit defined by
+// domain, type and item and the file, which defines this value.
+// The files are evaluated in the given order like pam_limits does: a more
+// specific domain (user before @group before %group before '*') wins and
+// for domains of the same priority the last entry found wins.
+// The type '-' of an entry sets the soft and the hard limit.
+// Return empty strings, if no entry is found
+func EffectiveSecLimit(files []string, domain, typeName, item string) (string, string) {
+	value := ""
+	file := ""
+	prio := limitsPrioNone
+	groups := []string{}
+	if domain != "" && !strings.ContainsAny(domain[:1], "@%*") {
+		groups = lookupUserGroups(domain)
+	}
+	for _, fileName := range files {
+		content, err := ReadFile(fileName)
+		if err != nil {
+			continue
+		}
+		for _, entry := range ParseSecLimits(string(content)).Entries {
+			if entry.Item != item || entry.Value == "" {
+				continue
+			}
+			if entry.Type != typeName && entry.Type != "-" && typeName != "-" {
+				continue
+			}
+			entryPrio := secLimitsDomainPrio(entry.Domain, domain, groups)
+			if entryPrio == limitsPrioNone || entryPrio > prio {
+				continue
+			}
+			prio = entryPrio
+			value = entry.Value
+			file = fileName
+		}
+	}
+	return value, file
+}
+
 // ToText convert the entries back into text.
 func (limits *SecLimits) ToText() string {
 	var ret bytes.Buffer
@@ -184,17 +307,17 @@ func (limits *SecLimits) ToDropIn(lim []string, noteID, fileName string) string
 // ApplyDropIn overwrite file 'dropInFile' with the content of this structure.
 func (limits *SecLimits) ApplyDropIn(lim []string, noteID string) error {
 	// /etc/security/limits.d/saptune-<domain>-<item>-<type>.conf
-	limitsDropDir := "/etc/security/limits.d"
+	limitsDropDir := LimitsDropInDir
 	dropInFile := fmt.Sprintf("%s/saptune-%s-%s-%s.conf", limitsDropDir, lim[0], lim[2], lim[1])
 	if _, err := os.Stat(limitsDropDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(limitsDropDir, 0755); err != nil {
+		if err := MkdirAll(limitsDropDir, 0755); err != nil {
 			return ErrorLog("failed to create needed directories for the limits drop in file: %v", err)
 		}
 	}
-	return ioutil.WriteFile(dropInFile, []byte(limits.ToDropIn(lim, noteID, dropInFile)), 0644)
+	return WriteFile(dropInFile, []byte(limits.ToDropIn(lim, noteID, dropInFile)), 0644)
 }
 
 // Apply overwrite /etc/security/limits.conf with the content of this structure.
 func (limits *SecLimits) Apply() error {
-	return ioutil.WriteFile("/etc/security/limits.conf", []byte(limits.ToText()), 0644)
+	return WriteFile("/etc/security/limits.conf", []byte(limits.ToText()), 0644)
 }
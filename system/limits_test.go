@@ -3,9 +3,12 @@ package system
 import (
 	"fmt"
 	"os"
+	"path"
 	"testing"
 )
 
+var tstLimitsDir = path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/etc/security")
+
 var limitsSampleText = `# yadi yadi yada
 # /etc/security/limits.conf
 *               hard    nproc           8000
@@ -125,3 +128,75 @@ func TestSecLimits(t *testing.T) {
 	}
 	os.Remove(dropInFile)
 }
+
+func TestGetSecLimitsFiles(t *testing.T) {
+	files := GetSecLimitsFiles(path.Join(tstLimitsDir, "limits.conf"), path.Join(tstLimitsDir, "limits.d"))
+	if len(files) != 3 {
+		t.Fatal(files)
+	}
+	if files[0] != path.Join(tstLimitsDir, "limits.conf") || files[1] != path.Join(tstLimitsDir, "limits.d/saptune-@sapsys-nofile-soft.conf") || files[2] != path.Join(tstLimitsDir, "limits.d/zz-custom.conf") {
+		t.Fatal(files)
+	}
+	files = GetSecLimitsFiles("/file_does_not_exist", "/dir_does_not_exist")
+	if len(files) != 1 {
+		t.Fatal(files)
+	}
+}
+
+func TestEffectiveSecLimit(t *testing.T) {
+	oldLookup := lookupUserGroups
+	defer func() { lookupUserGroups = oldLookup }()
+	lookupUserGroups = func(name string) []string {
+		if name == "sidadm" {
+			return []string{"sapsys", "sdba"}
+		}
+		return []string{}
+	}
+	conf := path.Join(tstLimitsDir, "limits.conf")
+	saptune := path.Join(tstLimitsDir, "limits.d/saptune-@sapsys-nofile-soft.conf")
+	custom := path.Join(tstLimitsDir, "limits.d/zz-custom.conf")
+	files := GetSecLimitsFiles(conf, path.Join(tstLimitsDir, "limits.d"))
+
+	// group entry of saptune drop-in wins against wildcard of custom file
+	val, file := EffectiveSecLimit(files, "@sapsys", "soft", "nofile")
+	if val != "65536" || file != saptune {
+		t.Fatal(val, file)
+	}
+	// '-' sets soft and hard limit, later file wins for same priority
+	val, file = EffectiveSecLimit(files, "@sapsys", "soft", "memlock")
+	if val != "unlimited" || file != conf {
+		t.Fatal(val, file)
+	}
+	val, file = EffectiveSecLimit(files, "@sapsys", "hard", "memlock")
+	if val != "8192" || file != custom {
+		t.Fatal(val, file)
+	}
+	// wildcard only
+	val, file = EffectiveSecLimit(files, "@dba", "soft", "nofile")
+	if val != "4096" || file != custom {
+		t.Fatal(val, file)
+	}
+	val, file = EffectiveSecLimit(files, "@dba", "hard", "nofile")
+	if val != "32000" || file != conf {
+		t.Fatal(val, file)
+	}
+	// '%' group domain
+	val, file = EffectiveSecLimit(files, "@sdba", "hard", "nproc")
+	if val != "4096" || file != conf {
+		t.Fatal(val, file)
+	}
+	// user entry wins against group and wildcard entries
+	val, file = EffectiveSecLimit(files, "sidadm", "soft", "nofile")
+	if val != "1048576" || file != conf {
+		t.Fatal(val, file)
+	}
+	// group entries of the groups of a user
+	val, file = EffectiveSecLimit(files, "sidadm", "hard", "memlock")
+	if val != "8192" || file != custom {
+		t.Fatal(val, file)
+	}
+	val, file = EffectiveSecLimit(files, "@sapsys", "soft", "nproc")
+	if val != "" || file != "" {
+		t.Fatal(val, file)
+	}
+}
@@ -67,6 +67,6 @@ func SetTasksMax(userID, limit string) error {
 	if !CmdIsAvailable(cmdName) {
 		return fmt.Errorf("command '%s' not found", cmdName)
 	}
-	_, err := exec.Command(cmdName, cmdArgs...).CombinedOutput()
+	_, err := RunChangeCmd(cmdName, cmdArgs...)
 	return err
 }
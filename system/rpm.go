@@ -5,18 +5,32 @@ package system
 import (
 	"fmt"
 	"os/exec"
+	"path"
 	"regexp"
 	"strings"
 	"unicode"
 )
 
 var alphanumPattern = regexp.MustCompile("([a-zA-Z]+)|([0-9]+)|(~)")
+var rpmVersOperator = regexp.MustCompile(`^(<=|>=|!=|==|<|>|=)?\s*(.*)$`)
+
+// RpmNotInstalled is the version specification used in the [rpm] section
+// of a Note definition file to require that a package is NOT installed
+const RpmNotInstalled = "not-installed"
 
 // GetRpmVers return the version of an installed RPM
+// if more than one version of the package is installed, the version of the
+// running kernel (for kernel packages) or the highest version is returned
 func GetRpmVers(rpm string) string {
+	vers, _ := SelectRpmVers(rpm, GetAllRpmVers(rpm), GetRunningKernelVers())
+	return vers
+}
+
+// GetAllRpmVers return all installed versions of a RPM
+func GetAllRpmVers(rpm string) []string {
 	// rpm -q --qf '%{VERSION}-%{RELEASE}\n' glibc
 	notInstalled := fmt.Sprintf("package %s is not installed", rpm)
-	rpmVers := ""
+	rpmVers := []string{}
 	cmdName := "/bin/rpm"
 	cmdArgs := []string{"-q", "--qf", "%{VERSION}-%{RELEASE}\n", rpm}
 
@@ -25,15 +39,59 @@ func GetRpmVers(rpm string) string {
 		if len(string(cmdOut)) == 0 || strings.TrimSpace(string(cmdOut)) != notInstalled {
 			WarningLog("There was an error running external command 'rpm -q --qf '%%{VERSION}-%%{RELEASE}' %s': %v, output: %s", rpm, err, cmdOut)
 		}
-		return ""
+		return rpmVers
 	}
 	for _, line := range strings.Split(strings.TrimSpace(string(cmdOut)), "\n") {
-		// ANGI: TODO - was, wenn mehr als eine Paketversion installiert ist
-		rpmVers = line
+		if line = strings.TrimSpace(line); line != "" {
+			rpmVers = append(rpmVers, line)
+		}
 	}
 	return rpmVers
 }
 
+// GetRunningKernelVers returns the release of the running kernel
+// like '4.12.14-197.29-default'
+func GetRunningKernelVers() string {
+	kvers, err := GetSysctlString("kernel.osrelease")
+	if err != nil {
+		return ""
+	}
+	return kvers
+}
+
+// SelectRpmVers selects the version of a package, which should be used for
+// the comparison with the expected version, from the list of installed
+// versions.
+// For kernel packages the version matching the running kernel is used,
+// for all other packages the highest installed version.
+// The second return value is true, if more than one version is installed
+func SelectRpmVers(rpm string, versions []string, kernel string) (string, bool) {
+	if len(versions) == 0 {
+		return "", false
+	}
+	multi := len(versions) > 1
+	if strings.HasPrefix(rpm, "kernel-") && kernel != "" {
+		// 4.12.14-197.29-default (uname -r) vs. 4.12.14-197.29.1 (rpm)
+		kvers := kernel
+		if idx := strings.LastIndex(kernel, "-"); idx > strings.Index(kernel, "-") {
+			// strip kernel flavor
+			kvers = kernel[:idx]
+		}
+		for _, vers := range versions {
+			if vers == kvers || strings.HasPrefix(vers, kvers+".") {
+				return vers, multi
+			}
+		}
+	}
+	rpmVers := versions[0]
+	for _, vers := range versions[1:] {
+		if CompareRpmVers(vers, rpmVers) > 0 {
+			rpmVers = vers
+		}
+	}
+	return rpmVers, multi
+}
+
 /* compare rpm versions
 func CmpRpmVers, func CheckRpmVers
 build on base of information from http://rpm.org/user_doc/dependencies.html
@@ -50,32 +108,121 @@ func CmpRpmVers(vers1, vers2 string) bool {
 		// package not installed
 		return false
 	}
+	return CompareRpmVers(vers1, vers2) >= 0
+}
+
+// CompareRpmVers compare 'version-release' of 2 RPMs
+// If vers2 does not contain a release, only the versions are compared
+// Return 0 (Equal), 1 (GreaterThan) or -1 (LessThan)
+func CompareRpmVers(vers1, vers2 string) int {
 	if vers1 == vers2 {
 		// rpm version and release are equal
-		return true
+		return 0
 	}
 	// actV is 228-150.22.1, expV is 228-142.1
-	actV := strings.Split(vers1, "-")
-	expV := strings.Split(vers2, "-")
+	actV := strings.SplitN(vers1, "-", 2)
+	expV := strings.SplitN(vers2, "-", 2)
 	// check rpm version
-	ret := CheckRpmVers(actV[0], expV[0])
-	if ret > 0 {
-		// installed package version is higher than expected
-		return true
-	} else if ret < 0 {
-		// installed package version is less than expected
-		return false
+	if ret := CheckRpmVers(actV[0], expV[0]); ret != 0 {
+		return ret
+	}
+	if len(expV) < 2 {
+		// no release expected, version is equal
+		return 0
+	}
+	if len(actV) < 2 {
+		return -1
 	}
 	// rpm version is equal, so check rpm release
-	ret = CheckRpmVers(actV[1], expV[1])
-	if ret < 0 {
-		// installed package release is less than expected
+	return CheckRpmVers(actV[1], expV[1])
+}
+
+// MatchRpmVersSpec checks, if the installed package version 'vers' fulfils
+// the version specification 'spec' from the [rpm] section of a Note
+// definition file.
+// 'spec' is a comma separated list of requirements, which all need to
+// match. A requirement is a version with an optional leading operator
+// (<, <=, =, ==, >=, >, !=) or a range 'min..max' (both including).
+// Without an operator '>=' is used.
+// The keyword 'not-installed' requires that the package is not installed.
+func MatchRpmVersSpec(vers, spec string) bool {
+	spec = strings.TrimSpace(spec)
+	if strings.ToLower(spec) == RpmNotInstalled {
+		return vers == ""
+	}
+	if vers == "" {
+		// package not installed
 		return false
 	}
-	// installed package release is equal or higher than expected
+	for _, req := range strings.Split(spec, ",") {
+		req = strings.TrimSpace(req)
+		if req == "" {
+			continue
+		}
+		if rng := strings.SplitN(req, "..", 2); len(rng) == 2 {
+			low := strings.TrimSpace(rng[0])
+			high := strings.TrimSpace(rng[1])
+			if (low != "" && CompareRpmVers(vers, low) < 0) || (high != "" && CompareRpmVers(vers, high) > 0) {
+				return false
+			}
+			continue
+		}
+		opval := rpmVersOperator.FindStringSubmatch(req)
+		ret := CompareRpmVers(vers, opval[2])
+		match := false
+		switch opval[1] {
+		case "<":
+			match = ret < 0
+		case "<=":
+			match = ret <= 0
+		case "=", "==":
+			match = ret == 0
+		case "!=":
+			match = ret != 0
+		case ">":
+			match = ret > 0
+		default:
+			match = ret >= 0
+		}
+		if !match {
+			return false
+		}
+	}
 	return true
 }
 
+// MatchOsVers checks, if the OS version selector from the [rpm] section of
+// a Note definition file matches the given OS version (see GetOsVers).
+// The selector can be 'all', an exact version like '12-SP2', a glob like
+// '15*' or '12-SP*', a range like '12-SP2..12-SP4' (both including) or a
+// comma separated list of them.
+func MatchOsVers(selector, osVers string) bool {
+	for _, sel := range strings.Split(selector, ",") {
+		sel = strings.TrimSpace(sel)
+		switch {
+		case sel == "":
+			continue
+		case sel == "all":
+			return true
+		case strings.Contains(sel, ".."):
+			rng := strings.SplitN(sel, "..", 2)
+			if osVers == "" {
+				continue
+			}
+			if (rng[0] == "" || CheckRpmVers(osVers, rng[0]) >= 0) && (rng[1] == "" || CheckRpmVers(osVers, rng[1]) <= 0) {
+				return true
+			}
+		case strings.ContainsAny(sel, "*?["):
+			if match, err := path.Match(sel, osVers); err == nil && match {
+				return true
+			}
+		case sel == osVers:
+			return true
+		}
+	}
+	return false
+}
+
 // CheckRpmVers compare versions of 2 RPMs (installed version, expected version)
 // Return 0 (Equal), 1 (GreaterThan) or -1 (LessThan)
 func CheckRpmVers(vers1, vers2 string) int {
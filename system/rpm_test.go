@@ -173,3 +173,119 @@ func TestCheckRpmVers(t *testing.T) {
 		t.Fatal("higher - ~")
 	}
 }
+
+func TestCompareRpmVers(t *testing.T) {
+	if CompareRpmVers(vers1, vers1) != 0 {
+		t.Fatalf("'%s' reported as != '%s'\n", vers1, vers1)
+	}
+	if CompareRpmVers(vers1, vers2) != 1 {
+		t.Fatalf("'%s' reported as <= '%s'\n", vers1, vers2)
+	}
+	if CompareRpmVers(vers2, vers1) != -1 {
+		t.Fatalf("'%s' reported as >= '%s'\n", vers2, vers1)
+	}
+	// no release expected, only versions are compared
+	if CompareRpmVers(vers1, "228") != 0 {
+		t.Fatalf("'%s' reported as != '228'\n", vers1)
+	}
+	if CompareRpmVers(vers1, "229") != -1 {
+		t.Fatalf("'%s' reported as >= '229'\n", vers1)
+	}
+	if CompareRpmVers("228", vers1) != -1 {
+		t.Fatalf("'228' reported as >= '%s'\n", vers1)
+	}
+}
+
+func TestMatchRpmVersSpec(t *testing.T) {
+	specs := map[string]bool{
+		"228-142.1":                   true,
+		">=228-142.1":                 true,
+		">228-150.22.1":               false,
+		"> 228-142.1":                 true,
+		"<228-150.22.1":               false,
+		"<=228-150.22.1":              true,
+		"=228-150.22.1":               true,
+		"==228-150.22.1":              true,
+		"==228":                       true,
+		"!=228-150.22.1":              false,
+		"!=228-142.1":                 true,
+		"228-142.1..228-150.22.1":     true,
+		"228-142.1..228-150.19.1":     false,
+		"228-150.22.4..":              false,
+		"..229":                       true,
+		">=228-142.1,<229":            true,
+		">=228-142.1, !=228-150.22.1": false,
+		"not-installed":               false,
+	}
+	for spec, exp := range specs {
+		if MatchRpmVersSpec(vers1, spec) != exp {
+			t.Fatalf("version '%s', spec '%s' - expected '%v'\n", vers1, spec, exp)
+		}
+	}
+	if !MatchRpmVersSpec("", "not-installed") {
+		t.Fatal("not installed package reported as installed")
+	}
+	if MatchRpmVersSpec("", "228-142.1") {
+		t.Fatal("not installed package reported as matching")
+	}
+	if MatchRpmVersSpec("", "!=228-142.1") {
+		t.Fatal("not installed package reported as matching")
+	}
+}
+
+func TestMatchOsVers(t *testing.T) {
+	selectors := map[string]bool{
+		"all":            true,
+		"12-SP4":         true,
+		"12-SP3":         false,
+		"15":             false,
+		"12-SP*":         true,
+		"15*":            false,
+		"12-SP2..12-SP4": true,
+		"12-SP2..12-SP3": false,
+		"12-SP3..":       true,
+		"15,12-SP4":      true,
+		"15, 12-SP3":     false,
+	}
+	for sel, exp := range selectors {
+		if MatchOsVers(sel, "12-SP4") != exp {
+			t.Fatalf("selector '%s', os version '12-SP4' - expected '%v'\n", sel, exp)
+		}
+	}
+	if !MatchOsVers("12..15-SP2", "15-SP1") {
+		t.Fatal("'15-SP1' not in range '12..15-SP2'")
+	}
+	if MatchOsVers("12-SP1..12-SP5", "") {
+		t.Fatal("empty os version reported as matching")
+	}
+}
+
+func TestSelectRpmVers(t *testing.T) {
+	vers, multi := SelectRpmVers("glibc", []string{}, "")
+	if vers != "" || multi {
+		t.Fatal(vers, multi)
+	}
+	vers, multi = SelectRpmVers("glibc", []string{vers1}, "")
+	if vers != vers1 || multi {
+		t.Fatal(vers, multi)
+	}
+	vers, multi = SelectRpmVers("glibc", []string{vers2, vers3, vers1}, "")
+	if vers != vers3 || !multi {
+		t.Fatal(vers, multi)
+	}
+	kvers := []string{"4.12.14-197.26.1", "4.12.14-197.29.1", "4.12.14-197.4.1"}
+	vers, multi = SelectRpmVers("kernel-default", kvers, "4.12.14-197.26-default")
+	if vers != "4.12.14-197.26.1" || !multi {
+		t.Fatal(vers, multi)
+	}
+	// running kernel not found in the list, use highest version
+	vers, multi = SelectRpmVers("kernel-default", kvers, "4.12.14-197.30-default")
+	if vers != "4.12.14-197.29.1" || !multi {
+		t.Fatal(vers, multi)
+	}
+	// kernel version only considered for kernel packages
+	vers, _ = SelectRpmVers("glibc", kvers, "4.12.14-197.4-default")
+	if vers != "4.12.14-197.29.1" {
+		t.Fatal(vers)
+	}
+}
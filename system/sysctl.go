@@ -7,10 +7,16 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// SysctlDropInFile is the sysctl.d drop-in file managed by saptune to
+// persist the [sysctl] values of the applied Notes. The name is chosen to
+// be read as last file by systemd-sysctl
+const SysctlDropInFile = "/etc/sysctl.d/zz-saptune.conf"
+
 // mapping of system parameter names to configuration names
 const (
 	SysctlPagecacheLimitMB          = "vm.pagecache_limit_mb"
@@ -66,7 +72,7 @@ const (
 
 // GetSysctlString read a sysctl key and return the string value.
 func GetSysctlString(parameter string) (string, error) {
-	val, err := ioutil.ReadFile(path.Join("/proc/sys", strings.Replace(parameter, ".", "/", -1)))
+	val, err := ReadFile(path.Join("/proc/sys", strings.Replace(parameter, ".", "/", -1)))
 	if err != nil {
 		WarningLog("Failed to read sysctl key '%s': %v", parameter, err)
 		return "", err
@@ -110,7 +116,7 @@ func GetSysctlUint64Field(param string, field int) (uint64, error) {
 
 // SetSysctlString write a string sysctl value.
 func SetSysctlString(parameter, value string) error {
-	err := ioutil.WriteFile(path.Join("/proc/sys", strings.Replace(parameter, ".", "/", -1)), []byte(value), 0644)
+	err := WriteFile(path.Join("/proc/sys", strings.Replace(parameter, ".", "/", -1)), []byte(value), 0644)
 	if os.IsNotExist(err) {
 		WarningLog("sysctl key '%s' is not supported by os, skipping.", parameter)
 	} else if err != nil {
@@ -156,3 +162,36 @@ func IsPagecacheAvailable() bool {
 	}
 	return false
 }
+
+// WriteSysctlDropIn writes the sysctl parameters to the sysctl.d drop-in
+// file. The file is only written, if the content changed. Without
+// parameters the file is removed
+func WriteSysctlDropIn(file string, params map[string]string) error {
+	if len(params) == 0 {
+		return RemoveSysctlDropIn(file)
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	content := "# sysctl values of the Notes applied by saptune\n# generated by saptune - do not edit, will be removed during revert\n"
+	for _, key := range keys {
+		content = content + fmt.Sprintf("%s = %s\n", key, params[key])
+	}
+	if cur, err := ReadFile(file); err == nil && string(cur) == content {
+		return nil
+	}
+	if err := MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+	return WriteFile(file, []byte(content), 0644)
+}
+
+// RemoveSysctlDropIn removes the sysctl.d drop-in file
+func RemoveSysctlDropIn(file string) error {
+	if !FileExists(file) {
+		return nil
+	}
+	return RemoveFile(file)
+}
@@ -1,6 +1,11 @@
 package system
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestReadSysctl(t *testing.T) {
 	if value, err := GetSysctlInt("vm.max_map_count"); err != nil {
@@ -85,3 +90,29 @@ func TestIsPagecacheAvailable(t *testing.T) {
 		t.Log("pagecache setting NOT available")
 	}
 }
+
+func TestWriteSysctlDropIn(t *testing.T) {
+	dropIn := "/tmp/saptune_test/sysctl.d/zz-saptune.conf"
+	defer os.RemoveAll("/tmp/saptune_test")
+	params := map[string]string{"vm.swappiness": "10", "kernel.sem": "1250 256000 100 8192"}
+	if err := WriteSysctlDropIn(dropIn, params); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(dropIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(content), "\nkernel.sem = 1250 256000 100 8192\nvm.swappiness = 10\n") {
+		t.Fatal(string(content))
+	}
+	// no parameters left - remove file
+	if err := WriteSysctlDropIn(dropIn, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dropIn); !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if err := RemoveSysctlDropIn(dropIn); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -101,12 +101,12 @@ func GetServiceName(service string) string {
 
 // ReadConfigFile read content of config file
 func ReadConfigFile(fileName string, autoCreate bool) ([]byte, error) {
-	content, err := ioutil.ReadFile(fileName)
+	content, err := ReadFile(fileName)
 	if os.IsNotExist(err) && autoCreate {
 		content = []byte{}
-		err = os.MkdirAll(path.Dir(fileName), 0755)
+		err = MkdirAll(path.Dir(fileName), 0755)
 		if err == nil {
-			err = ioutil.WriteFile(fileName, []byte{}, 0644)
+			err = WriteFile(fileName, []byte{}, 0644)
 		}
 	}
 	return content, err
@@ -128,3 +128,13 @@ func CopyFile(srcFile, destFile string) error {
 	}
 	return err
 }
+
+// StringInList checks, if a string is part of a list of strings
+func StringInList(str string, list []string) bool {
+	for _, entry := range list {
+		if entry == str {
+			return true
+		}
+	}
+	return false
+}
@@ -103,3 +103,13 @@ func TestCopyFile(t *testing.T) {
 		t.Fatalf("copied from non existing file")
 	}
 }
+
+func TestStringInList(t *testing.T) {
+	list := []string{"sda", "vdb"}
+	if !StringInList("vdb", list) {
+		t.Fatal("'vdb' not found")
+	}
+	if StringInList("vd", list) || StringInList("sda", []string{}) {
+		t.Fatal("wrong match")
+	}
+}
@@ -0,0 +1,118 @@
+package system
+
+// Parse numeric parameter values and memory sizes with an optional unit
+// suffix
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern describes an integer with an optional unit suffix like
+// '4096', '-1', '16M', '16MiB' or '2 GB'
+var numberPattern = regexp.MustCompile(`^([+-]?\d+)\s*([KMGTP]?)(I?B)?$`)
+
+// unitExponent contains the power of 1024 of the unit suffixes
+var unitExponent = map[string]uint{"": 0, "K": 1, "M": 2, "G": 3, "T": 4, "P": 5}
+
+// percentPattern describes a percentage of the main memory like '75%'
+var percentPattern = regexp.MustCompile(`^(\d+(\.\d+)?)\s*%$`)
+
+// memUnitNames are the names of the units used for human-readable memory
+// sizes
+var memUnitNames = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+var minInt64 = big.NewInt(math.MinInt64)
+var maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+
+// ParseNumber parses an integer value with an optional unit suffix K, M,
+// G, T or P (also written as KB or KiB, ..., all with the factor 1024) like
+// '16M' or '16MiB' (16777216). Values of 64-bit signed and unsigned
+// parameters are supported, so the result needs to be in the range of
+// -9223372036854775808 to 18446744073709551615
+func ParseNumber(value string) (*big.Int, error) {
+	matches := numberPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if matches == nil {
+		return nil, fmt.Errorf("value '%s' is not numeric", value)
+	}
+	num, ok := new(big.Int).SetString(strings.TrimPrefix(matches[1], "+"), 10)
+	if !ok {
+		return nil, fmt.Errorf("value '%s' is not numeric", value)
+	}
+	num.Lsh(num, 10*unitExponent[matches[2]])
+	if num.Cmp(minInt64) < 0 || num.Cmp(maxUint64) > 0 {
+		return nil, fmt.Errorf("value '%s' is out of the 64-bit range", value)
+	}
+	return num, nil
+}
+
+// IsNumeric checks, if a value is an integer with an optional unit suffix
+func IsNumeric(value string) bool {
+	_, err := ParseNumber(value)
+	return err == nil
+}
+
+// ParseMemSize converts a memory size with a unit suffix like '2G',
+// '512MiB' or a percentage of the main memory like '75%' into the given
+// unit (empty for bytes, 'K', 'M', 'G', ...). The result is rounded down.
+// A value without unit suffix is already in the given unit and is returned
+// unchanged
+func ParseMemSize(value, unit string) (uint64, error) {
+	val := strings.ToUpper(strings.TrimSpace(value))
+	bytes := new(big.Int)
+	if matches := percentPattern.FindStringSubmatch(val); matches != nil {
+		percent, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil || percent > 100 {
+			return 0, fmt.Errorf("wrong percentage of the main memory '%s'", value)
+		}
+		memBytes := new(big.Float).SetUint64(ParseMeminfo()[MemMainTotalKey] * 1024)
+		memBytes.Mul(memBytes, big.NewFloat(percent/100))
+		memBytes.Int(bytes)
+	} else {
+		matches := numberPattern.FindStringSubmatch(val)
+		if matches == nil {
+			return 0, fmt.Errorf("memory size '%s' is not numeric", value)
+		}
+		num, err := ParseNumber(val)
+		if err != nil {
+			return 0, err
+		}
+		if num.Sign() < 0 {
+			return 0, fmt.Errorf("memory size '%s' is negative", value)
+		}
+		if matches[2] == "" && matches[3] == "" {
+			// no unit suffix
+			return num.Uint64(), nil
+		}
+		bytes = num
+	}
+	bytes.Rsh(bytes, 10*unitExponent[unit])
+	return bytes.Uint64(), nil
+}
+
+// HasMemUnit checks, if a value is a memory size with a unit suffix or a
+// percentage of the main memory
+func HasMemUnit(value string) bool {
+	val := strings.ToUpper(strings.TrimSpace(value))
+	if percentPattern.MatchString(val) {
+		return true
+	}
+	matches := numberPattern.FindStringSubmatch(val)
+	return matches != nil && (matches[2] != "" || matches[3] != "")
+}
+
+// FormatMemSize returns a memory size given in the unit (empty for bytes,
+// 'K', 'M', ...) as human-readable string like '16 GiB' or '1.5 MiB'
+func FormatMemSize(size uint64, unit string) string {
+	fsize := float64(size)
+	idx := int(unitExponent[unit])
+	for fsize >= 1024 && idx < len(memUnitNames)-1 {
+		fsize = fsize / 1024
+		idx++
+	}
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(math.Floor(fsize*100)/100, 'f', -1, 64), memUnitNames[idx])
+}
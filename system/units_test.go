@@ -0,0 +1,125 @@
+package system
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	values := map[string]string{
+		"4096":                 "4096",
+		" 4096 ":               "4096",
+		"-1":                   "-1",
+		"+10":                  "10",
+		"16M":                  "16777216",
+		"16m":                  "16777216",
+		"16MiB":                "16777216",
+		"16MB":                 "16777216",
+		"2 GB":                 "2147483648",
+		"1K":                   "1024",
+		"1T":                   "1099511627776",
+		"1P":                   "1125899906842624",
+		"18446744073709551615": "18446744073709551615",
+		"-9223372036854775808": "-9223372036854775808",
+		"15P":                  "16888498602639360",
+	}
+	for value, exp := range values {
+		num, err := ParseNumber(value)
+		if err != nil || num.String() != exp {
+			t.Fatalf("'%s': got '%v', expected '%s' - %v\n", value, num, exp, err)
+		}
+	}
+	wrongValues := map[string]string{
+		"":                     "is not numeric",
+		"abc":                  "is not numeric",
+		"16X":                  "is not numeric",
+		"1.5G":                 "is not numeric",
+		"never":                "is not numeric",
+		"18446744073709551616": "is out of the 64-bit range",
+		"-9223372036854775809": "is out of the 64-bit range",
+		"16384P":               "is out of the 64-bit range",
+	}
+	for value, msg := range wrongValues {
+		if _, err := ParseNumber(value); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("'%s': expected error '%s', got '%v'\n", value, msg, err)
+		}
+	}
+	if !IsNumeric("16M") || IsNumeric("madvise") {
+		t.Fatal("IsNumeric")
+	}
+}
+
+func TestParseMemSize(t *testing.T) {
+	type sizeTest struct {
+		value string
+		unit  string
+		size  uint64
+	}
+	tests := []sizeTest{
+		{"4096", "", 4096},
+		{"4096", "M", 4096},
+		{"512MiB", "", 536870912},
+		{"2G", "M", 2048},
+		{"2 GB", "K", 2097152},
+		{"1536K", "M", 1},
+		{"16b", "", 16},
+		{"16", "K", 16},
+		{"0%", "M", 0},
+	}
+	for _, tst := range tests {
+		size, err := ParseMemSize(tst.value, tst.unit)
+		if err != nil || size != tst.size {
+			t.Fatalf("'%s' in unit '%s': got '%d', expected '%d' - %v\n", tst.value, tst.unit, size, tst.size, err)
+		}
+	}
+	memKB := ParseMeminfo()[MemMainTotalKey]
+	size, err := ParseMemSize("100%", "K")
+	if err != nil || size != memKB {
+		t.Fatalf("100%%: got '%d', expected '%d' - %v\n", size, memKB, err)
+	}
+	size, err = ParseMemSize("50 %", "K")
+	if err != nil || size != memKB/2 {
+		t.Fatalf("50%%: got '%d', expected '%d' - %v\n", size, memKB/2, err)
+	}
+	wrongValues := map[string]string{
+		"":       "is not numeric",
+		"16X":    "is not numeric",
+		"-1":     "is negative",
+		"-16M":   "is negative",
+		"101%":   "wrong percentage",
+		"16384P": "is out of the 64-bit range",
+	}
+	for value, msg := range wrongValues {
+		if _, err := ParseMemSize(value, "M"); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("'%s': expected error '%s', got '%v'\n", value, msg, err)
+		}
+	}
+	for value, exp := range map[string]bool{"16G": true, "512MiB": true, "16b": true, "75%": true, "4096": false, "unlimited": false, "": false} {
+		if HasMemUnit(value) != exp {
+			t.Fatalf("HasMemUnit '%s': expected '%v'\n", value, exp)
+		}
+	}
+}
+
+func TestFormatMemSize(t *testing.T) {
+	type formatTest struct {
+		size uint64
+		unit string
+		exp  string
+	}
+	tests := []formatTest{
+		{0, "", "0 B"},
+		{1023, "", "1023 B"},
+		{16777216, "", "16 MiB"},
+		{1536, "K", "1.5 MiB"},
+		{16384, "M", "16 GiB"},
+		{1000, "M", "1000 MiB"},
+		{1025, "M", "1 GiB"},
+		{1099511627776, "K", "1 PiB"},
+	}
+	for _, tst := range tests {
+		if val := FormatMemSize(tst.size, tst.unit); val != tst.exp {
+			t.Fatalf("'%d' in unit '%s': got '%s', expected '%s'\n", tst.size, tst.unit, val, tst.exp)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+[solution]
+DESCRIPTION="Customer test solution"
+NOTES=941735 CUSTNOTE
+DEPRECATED=no
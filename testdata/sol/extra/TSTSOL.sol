@@ -0,0 +1,3 @@
+[solution]
+DESCRIPTION="Customer solution with the name of a shipped solution"
+NOTES=941735
@@ -0,0 +1,4 @@
+# override file of solution HANA, see saptune(8)
+[solution]
+NAME=HANA
+NOTES=941735 2382421
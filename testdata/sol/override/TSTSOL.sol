@@ -0,0 +1,3 @@
+[solution]
+DESCRIPTION="Test solution with override"
+NOTES=941735 1980196
@@ -0,0 +1,3 @@
+[solution]
+DESCRIPTION="solution without notes"
+ARCHITECTURES=s390x
@@ -0,0 +1,7 @@
+[solution]
+NAME=OLDSOL
+VERSION=1
+DESCRIPTION="Deprecated test solution"
+NOTES=941735
+DEPRECATED="replaced by TSTSOL"
+REPLACEMENT=TSTSOL
@@ -0,0 +1,7 @@
+# shipped test solution
+[solution]
+VERSION=2
+DATE=18.10.2026
+DESCRIPTION="Test solution"
+ARCHITECTURES=amd64 ppc64le
+NOTES=941735 1771258, 1980196
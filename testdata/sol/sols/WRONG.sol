@@ -0,0 +1,3 @@
+[solution]
+NAME=OTHER
+NOTES=941735
@@ -0,0 +1,108 @@
+package txtparser
+
+// Change single entries of the content of an INI file without losing the
+// comments, the empty lines and the order of the other entries
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iniEditLine contains the section and the key of a line of an INI file.
+// 'key' is empty for section headers, comments and irregular lines,
+// 'cond' is true for a conditional section
+type iniEditLine struct {
+	section string
+	cond    bool
+	header  bool
+	key     string
+	prefix  string // entry condition like '{arch=ppc64le} '
+}
+
+// scanINILines returns the section and the key of all lines of the content
+func scanINILines(lines []string) []iniEditLine {
+	scan := make([]iniEditLine, len(lines))
+	section := ""
+	cond := false
+	for idx, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			fields := strings.SplitN(line[1:len(line)-1], ":", 2)
+			section = fields[0]
+			cond = len(fields) == 2
+			scan[idx] = iniEditLine{section: section, cond: cond, header: true}
+			continue
+		}
+		scan[idx] = iniEditLine{section: section, cond: cond}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if ce := RegexEntryCondition.FindStringSubmatch(line); ce != nil {
+			scan[idx].prefix = fmt.Sprintf("{%s} ", ce[1])
+			line = ce[2]
+		}
+		kov := RegexKeyOperatorValue.FindStringSubmatch(line)
+		if kov != nil && strings.HasPrefix(line, kov[1]) {
+			// the key has to start the line, otherwise it is
+			// e.g. a block device selector like
+			// 'IO_SCHEDULER[model=LOGICAL VOLUME]=none'
+			scan[idx].key = kov[1]
+		}
+	}
+	return scan
+}
+
+// SetINIEntry sets the value of the entry 'key' in all sections named
+// 'section' of the content of an INI file to 'key = value'. If the entry is
+// not available, it is added to the end of the last unconditional section
+// 'section' or a new section is added to the end of the content
+func SetINIEntry(content, section, key, value string) string {
+	lines := strings.Split(content, "\n")
+	scan := scanINILines(lines)
+	entry := strings.TrimSpace(fmt.Sprintf("%s = %s", key, value))
+	found := false
+	last := -1
+	for idx, line := range scan {
+		if line.section != section {
+			continue
+		}
+		if !line.cond && (line.header || line.key != "") {
+			// last entry or section header of an unconditional
+			// section 'section'
+			last = idx
+		}
+		if line.key == key {
+			lines[idx] = line.prefix + entry
+			found = true
+		}
+	}
+	switch {
+	case found:
+	case last >= 0:
+		lines = append(lines[:last+1], append([]string{entry}, lines[last+1:]...)...)
+	default:
+		if len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		lines = append(lines, "", fmt.Sprintf("[%s]", section), entry, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RemoveINIEntry removes the entry 'key' from all sections named 'section'
+// of the content of an INI file. It returns the new content and if an
+// entry was removed
+func RemoveINIEntry(content, section, key string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	scan := scanINILines(lines)
+	kept := make([]string, 0, len(lines))
+	removed := false
+	for idx, line := range lines {
+		if scan[idx].section == section && scan[idx].key == key {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n"), removed
+}
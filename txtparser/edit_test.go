@@ -0,0 +1,111 @@
+package txtparser
+
+import (
+	"testing"
+)
+
+var editExample = `[version]
+# SAP-NOTE=edit CATEGORY=LINUX VERSION=1 DATE=01.10.2019 NAME="edit test"
+
+[sysctl]
+# comment = kept
+vm.swappiness = 10
+kernel.shmmni >= 4096
+{arch=ppc64le} vm.max_map_count = 2147483647
+
+[sysctl:os=15-*]
+vm.swappiness = 20
+
+[block]
+IO_SCHEDULER[model=LOGICAL VOLUME] = none
+IO_SCHEDULER = noop, none
+`
+
+func TestSetINIEntry(t *testing.T) {
+	// change existing entries in all sections of the name, keep the
+	// entry condition
+	content := SetINIEntry(editExample, "sysctl", "vm.swappiness", "60")
+	content = SetINIEntry(content, "sysctl", "vm.max_map_count", "65530")
+	content = SetINIEntry(content, "sysctl", "kernel.shmmni", "")
+	content = SetINIEntry(content, "block", "IO_SCHEDULER", "mq-deadline")
+	exp := `[version]
+# SAP-NOTE=edit CATEGORY=LINUX VERSION=1 DATE=01.10.2019 NAME="edit test"
+
+[sysctl]
+# comment = kept
+vm.swappiness = 60
+kernel.shmmni =
+{arch=ppc64le} vm.max_map_count = 65530
+
+[sysctl:os=15-*]
+vm.swappiness = 60
+
+[block]
+IO_SCHEDULER[model=LOGICAL VOLUME] = none
+IO_SCHEDULER = mq-deadline
+`
+	if content != exp {
+		t.Fatalf("got '%s', expected '%s'\n", content, exp)
+	}
+
+	// add a new entry to the unconditional section and a new section
+	content = SetINIEntry(editExample, "sysctl", "vm.dirty_bytes", "629145600")
+	content = SetINIEntry(content, "vm", "THP", "never")
+	exp = `[version]
+# SAP-NOTE=edit CATEGORY=LINUX VERSION=1 DATE=01.10.2019 NAME="edit test"
+
+[sysctl]
+# comment = kept
+vm.swappiness = 10
+kernel.shmmni >= 4096
+{arch=ppc64le} vm.max_map_count = 2147483647
+vm.dirty_bytes = 629145600
+
+[sysctl:os=15-*]
+vm.swappiness = 20
+
+[block]
+IO_SCHEDULER[model=LOGICAL VOLUME] = none
+IO_SCHEDULER = noop, none
+
+[vm]
+THP = never
+`
+	if content != exp {
+		t.Fatalf("got '%s', expected '%s'\n", content, exp)
+	}
+
+	// add an entry to an empty section
+	content = SetINIEntry("[mem]\n# no entries\n", "mem", "ShmFileSystemSizeMB", "16G")
+	if content != "[mem]\nShmFileSystemSizeMB = 16G\n# no entries\n" {
+		t.Fatalf("got '%s'\n", content)
+	}
+}
+
+func TestRemoveINIEntry(t *testing.T) {
+	content, removed := RemoveINIEntry(editExample, "sysctl", "vm.swappiness")
+	if !removed {
+		t.Fatal("entry not removed")
+	}
+	exp := `[version]
+# SAP-NOTE=edit CATEGORY=LINUX VERSION=1 DATE=01.10.2019 NAME="edit test"
+
+[sysctl]
+# comment = kept
+kernel.shmmni >= 4096
+{arch=ppc64le} vm.max_map_count = 2147483647
+
+[sysctl:os=15-*]
+
+[block]
+IO_SCHEDULER[model=LOGICAL VOLUME] = none
+IO_SCHEDULER = noop, none
+`
+	if content != exp {
+		t.Fatalf("got '%s', expected '%s'\n", content, exp)
+	}
+	content, removed = RemoveINIEntry(editExample, "vm", "vm.swappiness")
+	if removed || content != editExample {
+		t.Fatalf("entry of another section removed: '%s'\n", content)
+	}
+}
@@ -0,0 +1,290 @@
+package txtparser
+
+// Parse and evaluate arithmetic expressions over system facts used as
+// parameter values in Note definition files like
+// 'kernel.shmall = ${MEM_TOTAL_BYTES} / ${PAGE_SIZE}'
+
+import (
+	"fmt"
+	"github.com/SUSE/saptune/system"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isExpression checks for a variable reference like '${PAGE_SIZE}'
+var isExpression = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// exprVarName describes a valid variable name like 'MEM_TOTAL_BYTES' or
+// 'sysctl:kernel.shmmax'
+var exprVarName = regexp.MustCompile(`^[A-Za-z][\w]*(:[\w./-]+)?$`)
+
+// IsExpression checks, if a parameter value contains a variable reference
+// and therefore needs to be evaluated
+func IsExpression(value string) bool {
+	return isExpression.MatchString(value)
+}
+
+// exprToken is a token of an expression
+type exprToken struct {
+	kind string // "num", "var", "op", "(", ")"
+	text string
+	num  int64
+	pos  int // position in the expression, starting with 1
+}
+
+// exprNode is a node of the syntax tree of an expression
+type exprNode struct {
+	op    string // "num", "var", "neg" or one of + - * / %
+	num   int64
+	name  string
+	pos   int
+	left  *exprNode
+	right *exprNode
+}
+
+// Expression is a parsed arithmetic expression with integer numbers,
+// variables '${NAME}', the operators + - * / % and parentheses
+type Expression struct {
+	text string
+	root *exprNode
+}
+
+// ParseExpression parses an arithmetic expression and reports syntax errors
+// with the position of the error
+func ParseExpression(expr string) (*Expression, error) {
+	tokens, err := tokenizeExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	p := &exprParser{expr: expr, tokens: tokens}
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.idx < len(p.tokens) {
+		return nil, p.errorf(p.tokens[p.idx].pos, "unexpected '%s'", p.tokens[p.idx].text)
+	}
+	return &Expression{text: expr, root: root}, nil
+}
+
+// String returns the text of the expression
+func (e *Expression) String() string {
+	return e.text
+}
+
+// Variables returns the names of the variables used in the expression
+func (e *Expression) Variables() []string {
+	names := []string{}
+	var walk func(node *exprNode)
+	walk = func(node *exprNode) {
+		if node == nil {
+			return
+		}
+		if node.op == "var" && !system.StringInList(node.name, names) {
+			names = append(names, node.name)
+		}
+		walk(node.left)
+		walk(node.right)
+	}
+	walk(e.root)
+	return names
+}
+
+// Eval evaluates the expression. The values of the variables are
+// provided by the function 'lookup'
+func (e *Expression) Eval(lookup func(name string) (int64, error)) (int64, error) {
+	return e.eval(e.root, lookup)
+}
+
+// eval evaluates a node of the syntax tree
+func (e *Expression) eval(node *exprNode, lookup func(name string) (int64, error)) (int64, error) {
+	switch node.op {
+	case "num":
+		return node.num, nil
+	case "var":
+		val, err := lookup(node.name)
+		if err != nil {
+			return 0, fmt.Errorf("expression '%s': variable '${%s}' at position %d: %v", e.text, node.name, node.pos, err)
+		}
+		return val, nil
+	case "neg":
+		val, err := e.eval(node.left, lookup)
+		if err != nil {
+			return 0, err
+		}
+		return -val, nil
+	}
+	left, err := e.eval(node.left, lookup)
+	if err != nil {
+		return 0, err
+	}
+	right, err := e.eval(node.right, lookup)
+	if err != nil {
+		return 0, err
+	}
+	overflow := false
+	res := int64(0)
+	switch node.op {
+	case "+":
+		res = left + right
+		overflow = (right > 0 && res < left) || (right < 0 && res > left)
+	case "-":
+		res = left - right
+		overflow = (right < 0 && res < left) || (right > 0 && res > left)
+	case "*":
+		if left != 0 && right != 0 {
+			res = left * right
+			overflow = res/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64)
+		}
+	case "/", "%":
+		if right == 0 {
+			return 0, fmt.Errorf("expression '%s': division by zero at position %d", e.text, node.pos)
+		}
+		if node.op == "/" {
+			res = left / right
+		} else {
+			res = left % right
+		}
+	}
+	if overflow {
+		return 0, fmt.Errorf("expression '%s': integer overflow at position %d", e.text, node.pos)
+	}
+	return res, nil
+}
+
+// tokenizeExpression splits an expression into tokens
+func tokenizeExpression(expr string) ([]exprToken, error) {
+	tokens := []exprToken{}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(expr) && expr[i] >= '0' && expr[i] <= '9' {
+				i++
+			}
+			num, err := strconv.ParseInt(expr[start:i], 10, 64)
+			if err != nil {
+				return tokens, fmt.Errorf("expression '%s': number '%s' at position %d out of range", expr, expr[start:i], start+1)
+			}
+			tokens = append(tokens, exprToken{kind: "num", text: expr[start:i], num: num, pos: start + 1})
+		case c == '$':
+			end := strings.Index(expr[i:], "}")
+			if !strings.HasPrefix(expr[i:], "${") || end < 0 {
+				return tokens, fmt.Errorf("expression '%s': incomplete variable reference at position %d, use '${NAME}'", expr, i+1)
+			}
+			name := strings.TrimSpace(expr[i+2 : i+end])
+			if !exprVarName.MatchString(name) {
+				return tokens, fmt.Errorf("expression '%s': invalid variable name '%s' at position %d", expr, name, i+1)
+			}
+			tokens = append(tokens, exprToken{kind: "var", text: expr[i : i+end+1], pos: i + 1})
+			i = i + end + 1
+		case strings.IndexByte("+-*/%", c) >= 0:
+			tokens = append(tokens, exprToken{kind: "op", text: string(c), pos: i + 1})
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, exprToken{kind: string(c), text: string(c), pos: i + 1})
+			i++
+		default:
+			return tokens, fmt.Errorf("expression '%s': unexpected character '%c' at position %d", expr, c, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser for expressions
+type exprParser struct {
+	expr   string
+	tokens []exprToken
+	idx    int
+}
+
+// errorf returns an error containing the expression and the position
+func (p *exprParser) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("expression '%s': %s at position %d", p.expr, fmt.Sprintf(format, args...), pos)
+}
+
+// peek returns the next token or nil at the end of the expression
+func (p *exprParser) peek() *exprToken {
+	if p.idx < len(p.tokens) {
+		return &p.tokens[p.idx]
+	}
+	return nil
+}
+
+// parseSum parses 'product (('+'|'-') product)*'
+func (p *exprParser) parseSum() (*exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok != nil && tok.kind == "op" && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+		p.idx++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: tok.text, pos: tok.pos, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseProduct parses 'factor (('*'|'/'|'%') factor)*'
+func (p *exprParser) parseProduct() (*exprNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok != nil && tok.kind == "op" && (tok.text == "*" || tok.text == "/" || tok.text == "%"); tok = p.peek() {
+		p.idx++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: tok.text, pos: tok.pos, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFactor parses a number, a variable, a negation or an expression
+// in parentheses
+func (p *exprParser) parseFactor() (*exprNode, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, p.errorf(len(p.expr)+1, "unexpected end of expression")
+	}
+	p.idx++
+	switch tok.kind {
+	case "num":
+		return &exprNode{op: "num", num: tok.num, pos: tok.pos}, nil
+	case "var":
+		name := strings.TrimSpace(tok.text[2 : len(tok.text)-1])
+		return &exprNode{op: "var", name: name, pos: tok.pos}, nil
+	case "(":
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != ")" {
+			return nil, p.errorf(tok.pos, "missing ')' for '('")
+		}
+		p.idx++
+		return node, nil
+	case "op":
+		if tok.text == "-" {
+			node, err := p.parseFactor()
+			if err != nil {
+				return nil, err
+			}
+			return &exprNode{op: "neg", pos: tok.pos, left: node}, nil
+		}
+	}
+	return nil, p.errorf(tok.pos, "unexpected '%s'", tok.text)
+}
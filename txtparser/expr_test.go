@@ -0,0 +1,97 @@
+package txtparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var tstFacts = map[string]int64{"MEM_TOTAL_BYTES": 68719476736, "PAGE_SIZE": 4096, "CPU_COUNT": 8}
+
+func tstLookup(name string) (int64, error) {
+	if val, ok := tstFacts[name]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("unknown system fact")
+}
+
+func TestIsExpression(t *testing.T) {
+	if !IsExpression("${MEM_TOTAL_BYTES} / ${PAGE_SIZE}") || !IsExpression("@sapsys soft memlock ${MEM_TOTAL_KB}") {
+		t.Fatal("expression not detected")
+	}
+	if IsExpression("1250 256000 100 8192") || IsExpression("$PAGE_SIZE") {
+		t.Fatal("value detected as expression")
+	}
+}
+
+func TestEvalExpression(t *testing.T) {
+	exprs := map[string]int64{
+		"${MEM_TOTAL_BYTES} / ${PAGE_SIZE}":  16777216,
+		"${MEM_TOTAL_BYTES}*75/100":          51539607552,
+		"2 + 3 * 4":                          14,
+		"(2 + 3) * 4":                        20,
+		"10 - 2 - 3":                         5,
+		"-${CPU_COUNT} + 10":                 2,
+		"17 % 5":                             2,
+		"${ CPU_COUNT }":                     8,
+		"((${CPU_COUNT} - 1) * (2 + 2)) / 2": 14,
+	}
+	for expr, exp := range exprs {
+		e, err := ParseExpression(expr)
+		if err != nil {
+			t.Fatal(expr, err)
+		}
+		val, err := e.Eval(tstLookup)
+		if err != nil || val != exp {
+			t.Fatalf("'%s': got '%d', expected '%d' - %v\n", expr, val, exp, err)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	parseErrs := map[string]string{
+		"":                     "empty expression",
+		"${PAGE_SIZE} *":       "unexpected end of expression at position 15",
+		"(2 + 3":               "missing ')' for '(' at position 1",
+		"2 + 3)":               "unexpected ')' at position 6",
+		"2 3":                  "unexpected '3' at position 3",
+		"${PAGE_SIZE":          "incomplete variable reference at position 1",
+		"$PAGE_SIZE":           "incomplete variable reference at position 1",
+		"${1PAGE}":             "invalid variable name '1PAGE' at position 1",
+		"10 ^ 2":               "unexpected character '^' at position 4",
+		"* 2":                  "unexpected '*' at position 1",
+		"99999999999999999999": "out of range",
+	}
+	for expr, msg := range parseErrs {
+		if _, err := ParseExpression(expr); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("'%s': expected error '%s', got '%v'\n", expr, msg, err)
+		}
+	}
+	evalErrs := map[string]string{
+		"${MEM_TOTL_BYTES} / 2":                   "variable '${MEM_TOTL_BYTES}' at position 1: unknown system fact",
+		"${PAGE_SIZE} / (${CPU_COUNT} - 8)":       "division by zero at position 14",
+		"${MEM_TOTAL_BYTES} * ${MEM_TOTAL_BYTES}": "integer overflow at position 20",
+	}
+	for expr, msg := range evalErrs {
+		e, err := ParseExpression(expr)
+		if err != nil {
+			t.Fatal(expr, err)
+		}
+		if _, err := e.Eval(tstLookup); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("'%s': expected error '%s', got '%v'\n", expr, msg, err)
+		}
+	}
+}
+
+func TestExpressionVariables(t *testing.T) {
+	e, err := ParseExpression("${MEM_TOTAL_BYTES} / ${PAGE_SIZE} + ${PAGE_SIZE} - ${sysctl:kernel.shmmax}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(e.Variables(), " ") != "MEM_TOTAL_BYTES PAGE_SIZE sysctl:kernel.shmmax" {
+		t.Fatal(e.Variables())
+	}
+	if e.String() != "${MEM_TOTAL_BYTES} / ${PAGE_SIZE} + ${PAGE_SIZE} - ${sysctl:kernel.shmmax}" {
+		t.Fatal(e.String())
+	}
+}
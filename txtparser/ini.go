@@ -3,7 +3,6 @@ package txtparser
 import (
 	"fmt"
 	"github.com/SUSE/saptune/system"
-	"io/ioutil"
 	"regexp"
 	"strings"
 )
@@ -15,68 +14,104 @@ const (
 	OperatorMoreThan      = ">"
 	OperatorMoreThanEqual = ">="
 	OperatorEqual         = "="
+	OperatorNotEqual      = "!="
+)
+
+// keys in the [version] section to declare the base Notes of a Note
+const (
+	INIKeyInclude = "INCLUDE"
+	INIKeyExtends = "EXTENDS"
 )
 
 // Operator is the comparison or assignment operator used in an INI file entry
 type Operator string
 
 // RegexKeyOperatorValue breaks up a line into key, operator, value.
-var RegexKeyOperatorValue = regexp.MustCompile(`([\w.+_-]+)\s*([<=>]+)\s*["']*(.*?)["']*$`)
+var RegexKeyOperatorValue = regexp.MustCompile(`([\w.+_-]+)\s*(!=|[<=>]+)\s*["']*(.*?)["']*$`)
+
+// RegexBlockSelector breaks up a line of the [block] section with a block
+// device selector like 'IO_SCHEDULER[model=LOGICAL VOLUME]=none' into key,
+// selector, operator, value.
+var RegexBlockSelector = regexp.MustCompile(`^([\w.+_-]+)\[(.*)\]\s*([<=>]+)\s*["']*(.*?)["']*$`)
+
+// RegexEntryCondition breaks up a line with a condition like
+// '{arch=ppc64le} kernel.numa_balancing = 0' into condition and entry.
+var RegexEntryCondition = regexp.MustCompile(`^\{([^}]*)\}\s*(.*)$`)
 
 // counter to control the [block] section detected warning
 var blckCnt = 0
 
+// getHostFacts returns the properties of the running system used to
+// evaluate the conditions of sections and entries
+var getHostFacts = system.GetHostFacts
+
 // INIEntry contains a single key-value pair in INI file.
+// Origin is the file the entry was read from, if the INI file is based
+// on other INI files
 type INIEntry struct {
 	Section  string
 	Key      string
 	Operator Operator
 	Value    string
+	Origin   string
+}
+
+// INICondition contains a condition of a section or an entry in an INI
+// file and the result of the evaluation on the running system.
+// Entry is empty for a section condition
+type INICondition struct {
+	Section   string
+	Entry     string
+	Condition string
+	Match     bool
+	Explain   []string
 }
 
 // INIFile contains all key-value pairs of an INI file.
 type INIFile struct {
-	AllValues []INIEntry
-	KeyValue  map[string]map[string]INIEntry
+	AllValues  []INIEntry
+	KeyValue   map[string]map[string]INIEntry
+	Conditions []INICondition
+	HostFacts  system.HostFacts
+	Bases      []string
+	Unset      []INIEntry
 }
 
 // GetINIFileDescriptiveName return the descriptive name of the Note
 func GetINIFileDescriptiveName(fileName string) string {
-	var re = regexp.MustCompile(`# .*NOTE=.*VERSION=(\d*)\s*DATE=(.*)\s*NAME="([^"]*)"`)
-	rval := ""
-	content, err := ioutil.ReadFile(fileName)
-	if err != nil {
+	meta, err := GetNoteMetadata(fileName)
+	if err != nil || meta.Name == "" {
 		return ""
 	}
-	matches := re.FindStringSubmatch(string(content))
-	if len(matches) != 0 {
-		rval = fmt.Sprintf("%s\n\t\t\t%sVersion %s from %s", matches[3], "", matches[1], matches[2])
-	}
-	return rval
+	return fmt.Sprintf("%s\n\t\t\t%sVersion %s from %s", meta.Name, "", meta.Version, meta.Date)
 }
 
 // GetINIFileVersionSectionEntry returns the field 'entryName' from the version
 // section of the Note configuration file
 func GetINIFileVersionSectionEntry(fileName, entryName string) string {
-	var re = regexp.MustCompile(`# .*NOTE=.*TEST=(\d*)\s*DATE=.*"`)
-	switch entryName {
-	case "version":
-		re = regexp.MustCompile(`# .*NOTE=.*VERSION=(\d*)\s*DATE=.*"`)
-	case "category":
-		re = regexp.MustCompile(`# .*NOTE=.*CATEGORY=(\w*)\s*VERSION=.*"`)
-	default:
-		return ""
-	}
-	rval := ""
-	content, err := ioutil.ReadFile(fileName)
+	meta, err := GetNoteMetadata(fileName)
 	if err != nil {
 		return ""
 	}
-	matches := re.FindStringSubmatch(string(content))
-	if len(matches) != 0 {
-		rval = fmt.Sprintf("%s", matches[1])
+	switch entryName {
+	case "id":
+		return meta.ID
+	case "version":
+		return meta.Version
+	case "category":
+		return meta.Category
+	case "date":
+		return meta.Date
+	case "name":
+		return meta.Name
+	case "url":
+		return meta.URL
+	case "author":
+		return meta.Author
+	case "supersedes":
+		return strings.Join(meta.Supersedes, ",")
 	}
-	return rval
+	return ""
 }
 
 // ParseINIFile read the content of the configuration file
@@ -88,6 +123,155 @@ func ParseINIFile(fileName string, autoCreate bool) (*INIFile, error) {
 	return ParseINI(string(content)), nil
 }
 
+// ResolveINIFile reads the content of a Note definition file including
+// the Note definitions it is based on. The base Notes are declared by
+// 'INCLUDE=' or 'EXTENDS=' (a list of Note IDs) in the [version] section.
+// The entries of the base Notes are inherited in the order of the list,
+// the entries of the file itself add or replace inherited entries or unset
+// them by an empty value. 'lookup' returns the file name of a Note ID
+func ResolveINIFile(fileName string, lookup func(id string) (string, error)) (*INIFile, error) {
+	return resolveINIFile(fileName, lookup, []string{})
+}
+
+// resolveINIFile resolves the base Notes of a Note definition file.
+// 'chain' contains the files, which include the file, to detect cycles
+func resolveINIFile(fileName string, lookup func(id string) (string, error), chain []string) (*INIFile, error) {
+	for _, file := range chain {
+		if file == fileName {
+			return nil, fmt.Errorf("cyclic inheritance of Note definition files: %s -> %s", strings.Join(chain, " -> "), fileName)
+		}
+	}
+	ini, err := ParseINIFile(fileName, false)
+	if err != nil {
+		return nil, err
+	}
+	baseIDs := ini.baseIDs()
+	if len(baseIDs) == 0 {
+		return ini, nil
+	}
+	ini.removeBaseEntries()
+	for idx := range ini.AllValues {
+		ini.AllValues[idx].Origin = fileName
+		ini.KeyValue[ini.AllValues[idx].Section][ini.AllValues[idx].Key] = ini.AllValues[idx]
+	}
+	resolved := &INIFile{
+		AllValues: make([]INIEntry, 0, 64),
+		KeyValue:  make(map[string]map[string]INIEntry),
+	}
+	for _, id := range baseIDs {
+		baseFile, err := lookup(id)
+		if err != nil {
+			return nil, fmt.Errorf("base Note '%s' of '%s' not found: %v", id, fileName, err)
+		}
+		base, err := resolveINIFile(baseFile, lookup, append(chain, fileName))
+		if err != nil {
+			return nil, err
+		}
+		for idx, entry := range base.AllValues {
+			if entry.Origin == "" {
+				base.AllValues[idx].Origin = baseFile
+			}
+		}
+		resolved.Bases = append(resolved.Bases, baseFile)
+		resolved.Unset = append(resolved.Unset, base.Unset...)
+		resolved.merge(base, false)
+	}
+	resolved.merge(ini, true)
+	return resolved, nil
+}
+
+// baseIDs returns the IDs of the base Notes declared by 'INCLUDE=' or
+// 'EXTENDS=' in the [version] section
+func (ini *INIFile) baseIDs() []string {
+	ids := []string{}
+	for _, key := range []string{INIKeyInclude, INIKeyExtends} {
+		if entry, ok := ini.KeyValue["version"][key]; ok {
+			ids = append(ids, strings.FieldsFunc(entry.Value, func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t'
+			})...)
+		}
+	}
+	return ids
+}
+
+// removeBaseEntries removes the 'INCLUDE=' and 'EXTENDS=' entries of the
+// [version] section, as they are no tuning parameters
+func (ini *INIFile) removeBaseEntries() {
+	delete(ini.KeyValue["version"], INIKeyInclude)
+	delete(ini.KeyValue["version"], INIKeyExtends)
+	if len(ini.KeyValue["version"]) == 0 {
+		delete(ini.KeyValue, "version")
+	}
+	entries := make([]INIEntry, 0, len(ini.AllValues))
+	for _, entry := range ini.AllValues {
+		if entry.Section == "version" && (entry.Key == INIKeyInclude || entry.Key == INIKeyExtends) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	ini.AllValues = entries
+}
+
+// isUnsetEntry checks, if an entry unsets an inherited entry. That is an
+// entry with an empty value, a limit definition without value or an
+// empty LIMITS option
+func isUnsetEntry(entry INIEntry) bool {
+	if entry.Section == "limits" {
+		return entry.Value == "NA" || len(strings.Fields(entry.Value)) == 3
+	}
+	return entry.Value == ""
+}
+
+// merge adds the entries of 'src' to the INI file. Entries with the same
+// key replace the former entries. If 'unset' is true, entries with an
+// empty value remove the former entries
+func (ini *INIFile) merge(src *INIFile, unset bool) {
+	for _, entry := range src.AllValues {
+		if unset && isUnsetEntry(entry) {
+			ini.unsetEntry(entry)
+			continue
+		}
+		if _, ok := ini.KeyValue[entry.Section]; !ok {
+			ini.KeyValue[entry.Section] = make(map[string]INIEntry)
+		}
+		ini.KeyValue[entry.Section][entry.Key] = entry
+		replaced := false
+		for idx, former := range ini.AllValues {
+			if former.Section == entry.Section && former.Key == entry.Key {
+				ini.AllValues[idx] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			ini.AllValues = append(ini.AllValues, entry)
+		}
+	}
+	ini.Conditions = append(ini.Conditions, src.Conditions...)
+	if src.HostFacts.Arch != "" {
+		ini.HostFacts = src.HostFacts
+	}
+}
+
+// unsetEntry removes an inherited entry. An empty LIMITS option removes
+// all inherited limits
+func (ini *INIFile) unsetEntry(entry INIEntry) {
+	matchKey := func(key string) bool { return key == entry.Key }
+	if entry.Section == "limits" && entry.Value == "NA" {
+		matchKey = func(key string) bool { return strings.HasPrefix(key, "LIMIT_") }
+	}
+	entries := make([]INIEntry, 0, len(ini.AllValues))
+	for _, former := range ini.AllValues {
+		if former.Section == entry.Section && matchKey(former.Key) {
+			delete(ini.KeyValue[entry.Section], former.Key)
+			continue
+		}
+		entries = append(entries, former)
+	}
+	ini.AllValues = entries
+	ini.Unset = append(ini.Unset, entry)
+}
+
 // ParseINI parse the content of the configuration file
 func ParseINI(input string) *INIFile {
 	ret := &INIFile{
@@ -97,6 +281,14 @@ func ParseINI(input string) *INIFile {
 
 	reminder := ""
 	currentSection := ""
+	// section condition does not match, skip all entries of the section
+	skipSection := false
+	factsAvail := false
+	// index of the expanded block device entries in currentEntriesArray
+	// and the information, if the entry was set by a block device selector
+	// (kept for all [block] sections of the file)
+	blckIdx := make(map[string]int)
+	blckSel := make(map[string]bool)
 	currentEntriesArray := make([]INIEntry, 0, 8)
 	currentEntriesMap := make(map[string]INIEntry)
 	for _, line := range strings.Split(input, "\n") {
@@ -108,12 +300,35 @@ func ParseINI(input string) *INIFile {
 			// Save previous section
 			if currentSection != "" {
 				ret.KeyValue[currentSection] = currentEntriesMap
-				ret.AllValues = append(ret.AllValues, currentEntriesArray...)
+				ret.AllValues = storeSectionEntries(ret.AllValues, currentSection, currentEntriesArray)
 			}
 			// Start a new section
+			// '[section:condition]' starts a conditional section
 			currentSection = line[1 : len(line)-1]
+			skipSection = false
+			if fields := strings.SplitN(currentSection, ":", 2); len(fields) == 2 {
+				currentSection = fields[0]
+				if !factsAvail {
+					ret.HostFacts = getHostFacts()
+					factsAvail = true
+				}
+				skipSection = !ret.evalCondition(currentSection, "", fields[1])
+			}
+			blckIdx = make(map[string]int)
 			currentEntriesArray = make([]INIEntry, 0, 8)
 			currentEntriesMap = make(map[string]INIEntry)
+			if _, ok := ret.KeyValue[currentSection]; ok {
+				// section already used before, continue with
+				// the entries of the former section
+				currentEntriesArray = sectionEntries(ret.AllValues, currentSection)
+				currentEntriesMap = ret.KeyValue[currentSection]
+				for idx, entry := range currentEntriesArray {
+					blckIdx[entry.Key] = idx
+				}
+			}
+			continue
+		}
+		if skipSection {
 			continue
 		}
 		if strings.HasPrefix(line, "#") {
@@ -126,12 +341,33 @@ func ParseINI(input string) *INIFile {
 			}
 			continue
 		}
+		if RegexEntryCondition.MatchString(line) {
+			// '{condition} entry' is a conditional entry
+			ce := RegexEntryCondition.FindStringSubmatch(line)
+			if !factsAvail {
+				ret.HostFacts = getHostFacts()
+				factsAvail = true
+			}
+			if !ret.evalCondition(currentSection, ce[2], ce[1]) {
+				continue
+			}
+			line = ce[2]
+		}
 		// Break apart a line into key, operator, value.
 		kov := make([]string, 0)
-		if currentSection == "rpm" {
+		selector := ""
+		if currentSection == "block" && RegexBlockSelector.MatchString(line) {
+			ksov := RegexBlockSelector.FindStringSubmatch(line)
+			selector = ksov[2]
+			kov = []string{ksov[0], ksov[1], ksov[3], ksov[4]}
+		} else if currentSection == "rpm" {
+			// <package> <os version selector> <version specification>
 			fields := strings.Fields(line)
-			if fields[1] == "all" || fields[1] == system.GetOsVers() {
-				kov = []string{"rpm", "rpm:" + fields[0], fields[1], fields[2]}
+			if len(fields) < 3 {
+				system.WarningLog("skipping irregular rpm entry '%s'", line)
+				kov = nil
+			} else if system.MatchOsVers(fields[1], system.GetOsVers()) {
+				kov = []string{"rpm", "rpm:" + fields[0], fields[1], strings.Join(fields[2:], "")}
 			} else {
 				kov = nil
 			}
@@ -151,6 +387,11 @@ func ParseINI(input string) *INIFile {
 			// Skip comments, empty, and irregular lines.
 			continue
 		}
+		if currentSection == "version" && kov[1] != INIKeyInclude && kov[1] != INIKeyExtends {
+			// metadata of the Note, no tuning parameter
+			// see ParseNoteMetadata
+			continue
+		}
 		if currentSection == "limits" {
 			for _, limits := range strings.Split(kov[3], ",") {
 				limits = strings.TrimSpace(limits)
@@ -171,39 +412,33 @@ func ParseINI(input string) *INIFile {
 					Operator: Operator(kov[2]),
 					Value:    limits,
 				}
-				currentEntriesArray = append(currentEntriesArray, entry)
-				currentEntriesMap[entry.Key] = entry
+				currentEntriesArray = addEntry(currentEntriesArray, currentEntriesMap, entry)
 			}
 		} else if currentSection == "block" {
 			if blckCnt == 0 {
 				system.WarningLog("[block] section detected: Traversing all block devices can take a considerable amount of time.")
 				blckCnt = blckCnt + 1
 			}
-			// identify virtio block devices
-			isVD := regexp.MustCompile(`^vd\w+$`)
-			_, sysDevs := system.ListDir("/sys/block", "the available block devices of the system")
-			for _, bdev := range sysDevs {
-				// /sys/block/*/device/type (TYPE_DISK / 0x00)
-				// does not work for virtio block devices
-				fname := fmt.Sprintf("/sys/block/%s/device/type", bdev)
-				dtype, err := ioutil.ReadFile(fname)
-				if err != nil || strings.TrimSpace(string(dtype)) != "0" {
-					if strings.Join(isVD.FindStringSubmatch(bdev), "") == "" {
-						// skip unsupported devices
-						continue
-					}
-				}
-				//if strings.Contains(bdev, "dm-") {
-				// skip unsupported devices
-				//	continue
-				//}
+			for _, bdev := range blockDevsOfEntry(kov[1], selector) {
 				entry := INIEntry{
 					Section:  currentSection,
 					Key:      fmt.Sprintf("%s_%s", kov[1], bdev),
 					Operator: Operator(kov[2]),
 					Value:    kov[3],
 				}
-				currentEntriesArray = append(currentEntriesArray, entry)
+				if idx, ok := blckIdx[entry.Key]; ok {
+					// device already set by a former entry.
+					// an entry with a block device selector
+					// wins against an entry without selector
+					if blckSel[entry.Key] && selector == "" {
+						continue
+					}
+					currentEntriesArray[idx] = entry
+				} else {
+					blckIdx[entry.Key] = len(currentEntriesArray)
+					currentEntriesArray = append(currentEntriesArray, entry)
+				}
+				blckSel[entry.Key] = blckSel[entry.Key] || selector != ""
 				currentEntriesMap[entry.Key] = entry
 			}
 		} else {
@@ -215,8 +450,7 @@ func ParseINI(input string) *INIFile {
 				Operator: Operator(kov[2]),
 				Value:    value,
 			}
-			currentEntriesArray = append(currentEntriesArray, entry)
-			currentEntriesMap[entry.Key] = entry
+			currentEntriesArray = addEntry(currentEntriesArray, currentEntriesMap, entry)
 		}
 	}
 	if reminder != "" {
@@ -224,7 +458,7 @@ func ParseINI(input string) *INIFile {
 		// Save previous section
 		if currentSection != "" {
 			ret.KeyValue[currentSection] = currentEntriesMap
-			ret.AllValues = append(ret.AllValues, currentEntriesArray...)
+			ret.AllValues = storeSectionEntries(ret.AllValues, currentSection, currentEntriesArray)
 		}
 		// Start the reminder section
 		currentEntriesArray = make([]INIEntry, 0, 8)
@@ -244,7 +478,106 @@ func ParseINI(input string) *INIFile {
 	// Save last section
 	if currentSection != "" {
 		ret.KeyValue[currentSection] = currentEntriesMap
-		ret.AllValues = append(ret.AllValues, currentEntriesArray...)
+		ret.AllValues = storeSectionEntries(ret.AllValues, currentSection, currentEntriesArray)
 	}
 	return ret
 }
+
+// evalCondition evaluates the condition of a section or an entry and
+// records the result. A wrong condition does not match
+func (ini *INIFile) evalCondition(section, entry, condition string) bool {
+	iniCond := INICondition{
+		Section:   section,
+		Entry:     entry,
+		Condition: condition,
+	}
+	cond, err := system.ParseCondition(condition)
+	if err != nil {
+		if entry == "" {
+			system.WarningLog("skipping section [%s] - %v", section, err)
+		} else {
+			system.WarningLog("skipping entry '%s' of section [%s] - %v", entry, section, err)
+		}
+		iniCond.Explain = []string{err.Error()}
+	} else {
+		iniCond.Match, iniCond.Explain = cond.Match(ini.HostFacts)
+	}
+	ini.Conditions = append(ini.Conditions, iniCond)
+	return iniCond.Match
+}
+
+// addEntry adds an entry to the entries of the current section. An entry
+// with the same key, e.g. from a conditional section, replaces the former
+// entry
+func addEntry(entries []INIEntry, entryMap map[string]INIEntry, entry INIEntry) []INIEntry {
+	if _, ok := entryMap[entry.Key]; ok {
+		for idx, former := range entries {
+			if former.Key == entry.Key {
+				entries[idx] = entry
+				entryMap[entry.Key] = entry
+				return entries
+			}
+		}
+	}
+	entryMap[entry.Key] = entry
+	return append(entries, entry)
+}
+
+// sectionEntries returns a copy of the entries of a section
+func sectionEntries(allValues []INIEntry, section string) []INIEntry {
+	sectEntries := make([]INIEntry, 0, 8)
+	for _, entry := range allValues {
+		if entry.Section == section {
+			sectEntries = append(sectEntries, entry)
+		}
+	}
+	return sectEntries
+}
+
+// storeSectionEntries stores the entries of a section in the list of all
+// entries. If the section was used before, the entries replace the former
+// entries of the section at their position, so the order of the sections
+// is kept. The entries of a new section are appended
+func storeSectionEntries(allValues []INIEntry, section string, entries []INIEntry) []INIEntry {
+	ret := make([]INIEntry, 0, len(allValues)+len(entries))
+	stored := false
+	for _, entry := range allValues {
+		if entry.Section != section {
+			ret = append(ret, entry)
+			continue
+		}
+		if !stored {
+			ret = append(ret, entries...)
+			stored = true
+		}
+	}
+	if !stored {
+		ret = append(ret, entries...)
+	}
+	return ret
+}
+
+// blockDevsOfEntry returns the block devices an entry of the [block] section
+// applies to. Without a block device selector all supported disks are used,
+// with a selector the matching disks (including NVMe devices)
+func blockDevsOfEntry(key, selector string) []string {
+	bdevs := make([]string, 0)
+	_, sysDevs := system.ListDir("/sys/block", "the available block devices of the system")
+	for _, bdev := range sysDevs {
+		if system.IsSupportedBlockDevice(bdev, selector != "") {
+			bdevs = append(bdevs, bdev)
+		}
+	}
+	if selector == "" {
+		return bdevs
+	}
+	selected, err := system.SelectBlockDevices(selector, bdevs)
+	if err != nil {
+		system.WarningLog("skipping entry '%s[%s]' of section [block]: %v", key, selector, err)
+		return make([]string, 0)
+	}
+	if len(selected) == 0 {
+		system.InfoLog("no block device matches the selector '%s' of entry '%s'", selector, key)
+	}
+	return selected
+}
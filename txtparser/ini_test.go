@@ -3,10 +3,12 @@ package txtparser
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/SUSE/saptune/system"
 	"io/ioutil"
 	"os"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -14,7 +16,7 @@ var fileNotExist = "/file_does_not_exist"
 var tstFile = path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/ini_all_test.ini")
 var tst2File = path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/wrong_limit_test.ini")
 var fileName = path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/ospackage/usr/share/saptune/notes/1410736")
-var descName = fmt.Sprintf("%s\n\t\t\t%sVersion %s from %s", "TCP/IP: setting keepalive interval", "", "4", "14.12.2017 ")
+var descName = fmt.Sprintf("%s\n\t\t\t%sVersion %s from %s", "TCP/IP: setting keepalive interval", "", "4", "14.12.2017")
 var category = "NET"
 var fileVersion = "4"
 
@@ -209,6 +211,269 @@ func TestParseINI(t *testing.T) {
 	}
 }
 
+var iniBlockSelector = `
+[block]
+IO_SCHEDULER=noop
+IO_SCHEDULER[name=^vdb$]=none
+IO_SCHEDULER[name=^not_avail$]=kyber
+IO_SCHEDULER[wrong=selector]=bfq
+NRREQ[name=^vdb$, rotational=0, rotational=1]=64
+NRREQ=1024
+`
+
+func TestParseINIBlockSelector(t *testing.T) {
+	blockINI := ParseINI(iniBlockSelector)
+	keys := make(map[string]bool)
+	for _, entry := range blockINI.AllValues {
+		if keys[entry.Key] {
+			t.Fatalf("duplicate entry for key '%s'\n", entry.Key)
+		}
+		keys[entry.Key] = true
+		if entry.Value == "kyber" || entry.Value == "bfq" {
+			t.Fatal(entry)
+		}
+	}
+	for _, bdev := range blockDevsOfEntry("IO_SCHEDULER", "") {
+		sched := blockINI.KeyValue["block"]["IO_SCHEDULER_"+bdev].Value
+		nrreq := blockINI.KeyValue["block"]["NRREQ_"+bdev].Value
+		if bdev == "vdb" {
+			// selector wins against entry without selector
+			if sched != "none" || nrreq != "64" {
+				t.Fatal(bdev, sched, nrreq)
+			}
+		} else if sched != "noop" || nrreq != "1024" {
+			t.Fatal(bdev, sched, nrreq)
+		}
+	}
+	if devs := blockDevsOfEntry("IO_SCHEDULER", "name=^not_avail$"); len(devs) != 0 {
+		t.Fatal(devs)
+	}
+	if devs := blockDevsOfEntry("IO_SCHEDULER", "name=("); len(devs) != 0 {
+		t.Fatal(devs)
+	}
+}
+
+var iniConditions = `
+[sysctl]
+vm.swappiness = 10
+kernel.numa_balancing = 0
+{virt=none} vm.dirty_bytes = 629145600
+{virt=kvm,mem=32G..} vm.dirty_bytes = 314572800
+{colour=blue} vm.max_map_count = 1
+
+[sysctl:arch=ppc64le]
+kernel.numa_balancing = 1
+vm.nr_hugepages = 128
+
+[limits]
+LIMITS = @sapsys soft nofile 65536
+
+[reminder:os=15..]
+# reminder for SLE15
+
+[sysctl:arch=amd64,os=15-SP1..]
+vm.swappiness = 60
+[reminder:os=12*]
+# reminder for SLE12
+`
+
+func TestParseINIConditions(t *testing.T) {
+	getHostFacts = func() system.HostFacts {
+		return system.HostFacts{Arch: "amd64", OsVers: "15-SP1", Virt: "kvm", MemTotalKB: 64 * 1024 * 1024, CPUVendor: "intel"}
+	}
+	defer func() { getHostFacts = system.GetHostFacts }()
+
+	condINI := ParseINI(iniConditions)
+	expected := map[string]string{
+		"vm.swappiness":         "60",
+		"kernel.numa_balancing": "0",
+		"vm.dirty_bytes":        "314572800",
+	}
+	if len(condINI.KeyValue["sysctl"]) != len(expected) {
+		t.Fatal(condINI.KeyValue["sysctl"])
+	}
+	for key, val := range expected {
+		if condINI.KeyValue["sysctl"][key].Value != val {
+			t.Fatal(key, condINI.KeyValue["sysctl"][key])
+		}
+	}
+	sysctlCnt := 0
+	for _, entry := range condINI.AllValues {
+		if entry.Section == "sysctl" {
+			sysctlCnt++
+			if entry.Value != expected[entry.Key] {
+				t.Fatal(entry)
+			}
+		}
+	}
+	if sysctlCnt != len(expected) {
+		t.Fatal(condINI.AllValues)
+	}
+	// a section used again keeps its position
+	sections := make([]string, 0)
+	for _, entry := range condINI.AllValues {
+		if len(sections) == 0 || sections[len(sections)-1] != entry.Section {
+			sections = append(sections, entry.Section)
+		}
+	}
+	if strings.Join(sections, " ") != "sysctl limits reminder" {
+		t.Fatal(sections)
+	}
+	if condINI.AllValues[0].Key != "vm.swappiness" || condINI.AllValues[1].Key != "kernel.numa_balancing" {
+		t.Fatal(condINI.AllValues)
+	}
+	if condINI.KeyValue["limits"]["LIMIT_@sapsys_soft_nofile"].Value != "@sapsys soft nofile 65536" {
+		t.Fatal(condINI.KeyValue["limits"])
+	}
+	if condINI.KeyValue["reminder"]["reminder"].Value != "# reminder for SLE15\n" {
+		t.Fatal(condINI.KeyValue["reminder"])
+	}
+
+	if len(condINI.Conditions) != 7 {
+		t.Fatal(condINI.Conditions)
+	}
+	cond := condINI.Conditions[1]
+	if cond.Section != "sysctl" || cond.Entry != "vm.dirty_bytes = 314572800" || cond.Condition != "virt=kvm,mem=32G.." || !cond.Match {
+		t.Fatal(cond)
+	}
+	if len(cond.Explain) != 2 || cond.Explain[1] != "mem=32G..: matched (system: 65536M)" {
+		t.Fatal(cond.Explain)
+	}
+	cond = condINI.Conditions[2]
+	if cond.Match || cond.Explain[0] != "unknown condition 'colour', supported are arch, os, kernel, virt, mem, cpu, pagecache" {
+		t.Fatal(cond)
+	}
+	cond = condINI.Conditions[3]
+	if cond.Section != "sysctl" || cond.Entry != "" || cond.Condition != "arch=ppc64le" || cond.Match {
+		t.Fatal(cond)
+	}
+	if cond.Explain[0] != "arch=ppc64le: not matched (system: amd64)" {
+		t.Fatal(cond.Explain)
+	}
+	if condINI.HostFacts.Virt != "kvm" {
+		t.Fatal(condINI.HostFacts)
+	}
+
+	// no host facts needed without conditions
+	if plainINI := ParseINI(iniExample); plainINI.Conditions != nil || plainINI.HostFacts.Arch != "" {
+		t.Fatal(plainINI)
+	}
+}
+
+func TestResolveINIFile(t *testing.T) {
+	inheritDir := path.Join(os.Getenv("GOPATH"), "/src/github.com/SUSE/saptune/testdata/inherit")
+	lookup := func(id string) (string, error) {
+		fileName := path.Join(inheritDir, id)
+		if _, err := os.Stat(fileName); err != nil {
+			return "", err
+		}
+		return fileName, nil
+	}
+	checkEntries := func(ini *INIFile, section string, expected map[string]string) {
+		t.Helper()
+		if len(ini.KeyValue[section]) != len(expected) {
+			t.Fatal(section, ini.KeyValue[section])
+		}
+		cnt := 0
+		for _, entry := range ini.AllValues {
+			if entry.Section != section {
+				continue
+			}
+			cnt++
+			if ini.KeyValue[section][entry.Key] != entry {
+				t.Fatal(entry, ini.KeyValue[section][entry.Key])
+			}
+			if entry.Value+" "+path.Base(entry.Origin) != expected[entry.Key] {
+				t.Fatal(entry, expected[entry.Key])
+			}
+		}
+		if cnt != len(expected) {
+			t.Fatal(section, ini.AllValues)
+		}
+	}
+
+	// note without base notes
+	ini, err := ResolveINIFile(path.Join(inheritDir, "4711"), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ini.Bases != nil || ini.Unset != nil || ini.AllValues[0].Origin != "" {
+		t.Fatal(ini)
+	}
+
+	ini, err = ResolveINIFile(path.Join(inheritDir, "4712"), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ini.Bases) != 1 || ini.Bases[0] != path.Join(inheritDir, "4711") {
+		t.Fatal(ini.Bases)
+	}
+	if _, ok := ini.KeyValue["version"]; ok {
+		t.Fatal(ini.KeyValue["version"])
+	}
+	checkEntries(ini, "sysctl", map[string]string{
+		"vm.swappiness":         "10 4712",
+		"kernel.numa_balancing": "0 4711",
+		"kernel.shmmni":         "32768 4712",
+	})
+	checkEntries(ini, "limits", map[string]string{
+		"LIMIT_@sapsys_soft_nofile": "@sapsys soft nofile 65536 4711",
+		"LIMIT_@dba_hard_nofile":    "@dba hard nofile 1048576 4712",
+	})
+	checkEntries(ini, "reminder", map[string]string{
+		"reminder": "# reminder of the base note\n 4711",
+	})
+	if len(ini.Unset) != 2 || ini.Unset[0].Key != "vm.dirty_bytes" || ini.Unset[1].Key != "LIMIT_@sapsys_hard_nofile" || path.Base(ini.Unset[1].Origin) != "4712" {
+		t.Fatal(ini.Unset)
+	}
+
+	ini, err = ResolveINIFile(path.Join(inheritDir, "4713"), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ini.Bases) != 2 || path.Base(ini.Bases[0]) != "4712" || path.Base(ini.Bases[1]) != "4714" {
+		t.Fatal(ini.Bases)
+	}
+	checkEntries(ini, "sysctl", map[string]string{
+		"vm.swappiness":         "10 4712",
+		"kernel.numa_balancing": "1 4714",
+		"kernel.shmmni":         "32768 4712",
+		"vm.max_map_count":      "2147483647 4714",
+	})
+	checkEntries(ini, "limits", map[string]string{})
+	if len(ini.Unset) != 3 || ini.Unset[2].Key != "LIMITS_NA" || path.Base(ini.Unset[2].Origin) != "4713" {
+		t.Fatal(ini.Unset)
+	}
+
+	_, err = ResolveINIFile(path.Join(inheritDir, "4715"), lookup)
+	if err == nil || err.Error() != fmt.Sprintf("cyclic inheritance of Note definition files: %s -> %s -> %s", path.Join(inheritDir, "4715"), path.Join(inheritDir, "4716"), path.Join(inheritDir, "4715")) {
+		t.Fatal(err)
+	}
+	_, err = ResolveINIFile(path.Join(inheritDir, "4712"), func(id string) (string, error) { return "", fmt.Errorf("unknown Note ID") })
+	if err == nil || err.Error() != fmt.Sprintf("base Note '4711' of '%s' not found: unknown Note ID", path.Join(inheritDir, "4712")) {
+		t.Fatal(err)
+	}
+	if _, err = ResolveINIFile(path.Join(inheritDir, "not_avail"), lookup); err == nil {
+		t.Fatal("not existing file resolved")
+	}
+}
+
+func TestParseINIOperators(t *testing.T) {
+	opINI := ParseINI("[sysctl]\nvm.max_map_count != 0\nvm.swappiness=10..60\nkernel.sem >= 1250 256000 100 8192\nnet.ipv4.tcp_rmem = >=4096 * >=16M\n")
+	expected := map[string]string{
+		"vm.max_map_count":  "!= 0",
+		"vm.swappiness":     "= 10..60",
+		"kernel.sem":        ">= 1250\t256000\t100\t8192",
+		"net.ipv4.tcp_rmem": "= >=4096\t*\t>=16M",
+	}
+	for key, exp := range expected {
+		entry := opINI.KeyValue["sysctl"][key]
+		if string(entry.Operator)+" "+entry.Value != exp {
+			t.Fatal(key, entry)
+		}
+	}
+}
+
 func TestGetINIFileDescriptiveName(t *testing.T) {
 	str := GetINIFileDescriptiveName(fileName)
 	if str != descName {
@@ -0,0 +1,194 @@
+package txtparser
+
+// Read the metadata of a Note definition file from the key-value entries
+// of the [version] section like 'VERSION=11' or from the legacy header
+// comment line
+// '# SAP-NOTE=941735 CATEGORY=LINUX VERSION=11 DATE=04.05.2018 NAME="..."'
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// metadata keys of the [version] section. The key of the Note ID can have
+// a prefix like 'SAP-NOTE' or 'VIP-NOTE'
+const (
+	MetaKeyCategory   = "CATEGORY"
+	MetaKeyVersion    = "VERSION"
+	MetaKeyDate       = "DATE"
+	MetaKeyName       = "NAME"
+	MetaKeyURL        = "URL"
+	MetaKeyAuthor     = "AUTHOR"
+	MetaKeySupersedes = "SUPERSEDES"
+)
+
+// MetaDateFormat is the format of the DATE field (DD.MM.YYYY)
+const MetaDateFormat = "02.01.2006"
+
+// isMetaIDKey matches the key of the Note ID like 'SAP-NOTE' or 'NOTE'
+var isMetaIDKey = regexp.MustCompile(`^([\w]+-)?NOTE$`)
+
+// legacyMetaField matches the fields 'KEY=value' or 'KEY="value"' of the
+// legacy header comment line
+var legacyMetaField = regexp.MustCompile(`([\w-]+)=("[^"]*"|\S*)`)
+
+var isMetaVersion = regexp.MustCompile(`^\d+(\.\d+)*$`)
+var isMetaNoteID = regexp.MustCompile(`^[\w.+-]+$`)
+
+// NoteMetadata contains the metadata of a Note definition file
+type NoteMetadata struct {
+	ID         string
+	Category   string
+	Version    string
+	Date       string
+	Name       string
+	URL        string
+	Author     string
+	Supersedes []string
+	Legacy     bool     // read from the legacy header comment line
+	unknown    []string // unknown keys of the [version] section
+}
+
+// GetNoteMetadata reads the metadata of a Note definition file
+func GetNoteMetadata(fileName string) (NoteMetadata, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return NoteMetadata{}, err
+	}
+	return ParseNoteMetadata(string(content)), nil
+}
+
+// ParseNoteMetadata reads the metadata from the content of a Note
+// definition file. Key-value entries of the [version] section win against
+// the fields of the legacy header comment line
+func ParseNoteMetadata(content string) NoteMetadata {
+	legacy := NoteMetadata{Legacy: true}
+	meta := NoteMetadata{}
+	legacyFound := false
+	entryFound := false
+	currentSection := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "["):
+			currentSection = strings.SplitN(strings.Trim(line, "[]"), ":", 2)[0]
+		case strings.HasPrefix(line, "#"):
+			if legacyFound || !strings.Contains(line, "NOTE=") {
+				continue
+			}
+			for _, field := range legacyMetaField.FindAllStringSubmatch(line, -1) {
+				legacy.set(field[1], field[2])
+			}
+			legacyFound = legacy.ID != ""
+		case currentSection == "version":
+			kov := RegexKeyOperatorValue.FindStringSubmatch(line)
+			if kov == nil || kov[1] == INIKeyInclude || kov[1] == INIKeyExtends {
+				continue
+			}
+			meta.set(kov[1], kov[3])
+			entryFound = true
+		}
+	}
+	if !entryFound {
+		if legacyFound {
+			return legacy
+		}
+		return meta
+	}
+	if legacyFound {
+		// fields only available in the legacy header comment line
+		meta.fillFrom(legacy)
+	}
+	return meta
+}
+
+// set stores the value of a metadata field
+func (meta *NoteMetadata) set(key, value string) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	switch {
+	case isMetaIDKey.MatchString(key):
+		meta.ID = value
+	case key == MetaKeyCategory:
+		meta.Category = value
+	case key == MetaKeyVersion:
+		meta.Version = value
+	case key == MetaKeyDate:
+		meta.Date = value
+	case key == MetaKeyName:
+		meta.Name = value
+	case key == MetaKeyURL:
+		meta.URL = value
+	case key == MetaKeyAuthor:
+		meta.Author = value
+	case key == MetaKeySupersedes:
+		meta.Supersedes = strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	default:
+		meta.unknown = append(meta.unknown, key)
+	}
+}
+
+// fillFrom sets the empty fields from other metadata
+func (meta *NoteMetadata) fillFrom(other NoteMetadata) {
+	fillField := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fillField(&meta.ID, other.ID)
+	fillField(&meta.Category, other.Category)
+	fillField(&meta.Version, other.Version)
+	fillField(&meta.Date, other.Date)
+	fillField(&meta.Name, other.Name)
+	fillField(&meta.URL, other.URL)
+	fillField(&meta.Author, other.Author)
+	if len(meta.Supersedes) == 0 {
+		meta.Supersedes = other.Supersedes
+	}
+}
+
+// Validate checks the metadata and returns all problems found as one error
+func (meta NoteMetadata) Validate() error {
+	errs := []string{}
+	for _, field := range []struct{ key, value string }{
+		{"SAP-NOTE", meta.ID}, {MetaKeyVersion, meta.Version},
+		{MetaKeyDate, meta.Date}, {MetaKeyName, meta.Name},
+	} {
+		if field.value == "" {
+			errs = append(errs, fmt.Sprintf("missing %s", field.key))
+		}
+	}
+	if meta.ID != "" && !isMetaNoteID.MatchString(meta.ID) {
+		errs = append(errs, fmt.Sprintf("wrong Note ID '%s'", meta.ID))
+	}
+	if meta.Version != "" && !isMetaVersion.MatchString(meta.Version) {
+		errs = append(errs, fmt.Sprintf("wrong %s '%s', only digits and dots are allowed", MetaKeyVersion, meta.Version))
+	}
+	if meta.Date != "" {
+		if _, err := time.Parse(MetaDateFormat, meta.Date); err != nil {
+			errs = append(errs, fmt.Sprintf("wrong %s '%s', expected format is DD.MM.YYYY", MetaKeyDate, meta.Date))
+		}
+	}
+	if meta.URL != "" {
+		if u, err := url.Parse(meta.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("wrong %s '%s'", MetaKeyURL, meta.URL))
+		}
+	}
+	for _, id := range meta.Supersedes {
+		if !isMetaNoteID.MatchString(id) {
+			errs = append(errs, fmt.Sprintf("wrong Note ID '%s' in %s", id, MetaKeySupersedes))
+		}
+	}
+	for _, key := range meta.unknown {
+		errs = append(errs, fmt.Sprintf("unknown key '%s' in section [version]", key))
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package txtparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var legacyHeader = `# 941735 - SAP memory management system for 64-bit Linux systems
+
+[version]
+# SAP-NOTE=941735 CATEGORY=LINUX VERSION=11 DATE=04.05.2018 NAME="SAP memory management system for 64-bit Linux systems"
+
+[mem]
+ShmFileSystemSizeMB=0
+`
+
+var structuredHeader = `[version]
+SAP-NOTE = 1680803_site
+CATEGORY = SITE
+VERSION = 2.1
+DATE = 02.10.2019
+NAME = "1680803 with site specific changes"
+URL = https://launchpad.support.sap.com/#/notes/1680803
+AUTHOR = "Site Admin"
+SUPERSEDES = 1680803_old, 1680803_older
+EXTENDS = 1680803
+
+[sysctl]
+vm.dirty_bytes = 0
+`
+
+func TestParseNoteMetadata(t *testing.T) {
+	meta := ParseNoteMetadata(legacyHeader)
+	exp := NoteMetadata{ID: "941735", Category: "LINUX", Version: "11", Date: "04.05.2018", Name: "SAP memory management system for 64-bit Linux systems", Legacy: true}
+	if !reflect.DeepEqual(meta, exp) {
+		t.Fatalf("got '%+v', expected '%+v'\n", meta, exp)
+	}
+	if err := meta.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// field order of the legacy header does not matter
+	meta = ParseNoteMetadata(`[version]
+# VIP-NOTE=vip1 NAME="VIP Note 1" DATE=16.04.2019 VERSION=5`)
+	if meta.ID != "vip1" || meta.Name != "VIP Note 1" || meta.Version != "5" || meta.Date != "16.04.2019" || meta.Validate() != nil {
+		t.Fatalf("got '%+v'\n", meta)
+	}
+
+	meta = ParseNoteMetadata(structuredHeader)
+	exp = NoteMetadata{ID: "1680803_site", Category: "SITE", Version: "2.1", Date: "02.10.2019", Name: "1680803 with site specific changes", URL: "https://launchpad.support.sap.com/#/notes/1680803", Author: "Site Admin", Supersedes: []string{"1680803_old", "1680803_older"}}
+	if !reflect.DeepEqual(meta, exp) {
+		t.Fatalf("got '%+v', expected '%+v'\n", meta, exp)
+	}
+	if err := meta.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// entries win against the legacy header, missing entries are
+	// taken from the legacy header
+	meta = ParseNoteMetadata(`[version]
+# SAP-NOTE=941735 CATEGORY=LINUX VERSION=11 DATE=04.05.2018 NAME="SAP memory management"
+VERSION=12
+`)
+	if meta.Version != "12" || meta.ID != "941735" || meta.Name != "SAP memory management" || meta.Legacy {
+		t.Fatalf("got '%+v'\n", meta)
+	}
+
+	if meta := ParseNoteMetadata("[sysctl]\nvm.swappiness = 10\n"); !reflect.DeepEqual(meta, NoteMetadata{}) {
+		t.Fatalf("got '%+v'\n", meta)
+	}
+}
+
+func TestValidateNoteMetadata(t *testing.T) {
+	meta := ParseNoteMetadata(`[version]
+NOTE = 4711 4712
+VERSION = 1a
+DATE = 2019-10-02
+URL = launchpad.support.sap.com
+SUPERSEDES = 4710,47/09
+VERSON = 2
+`)
+	err := meta.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, msg := range []string{"missing NAME", "wrong Note ID '4711 4712'", "wrong VERSION '1a'", "wrong DATE '2019-10-02'", "wrong URL 'launchpad.support.sap.com'", "wrong Note ID '47/09' in SUPERSEDES", "unknown key 'VERSON' in section [version]"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("'%s' not found in '%v'\n", msg, err)
+		}
+	}
+	if err := (NoteMetadata{}).Validate(); err == nil || err.Error() != "missing SAP-NOTE, missing VERSION, missing DATE, missing NAME" {
+		t.Fatal(err)
+	}
+}
+
+func TestParseINIMetadata(t *testing.T) {
+	ini := ParseINI(structuredHeader)
+	if len(ini.KeyValue["version"]) != 1 || ini.KeyValue["version"][INIKeyExtends].Value != "1680803" {
+		t.Fatal(ini.KeyValue["version"])
+	}
+	for _, entry := range ini.AllValues {
+		if entry.Section == "version" && entry.Key != INIKeyExtends {
+			t.Fatal(entry)
+		}
+	}
+	if ini.KeyValue["sysctl"]["vm.dirty_bytes"].Value != "0" {
+		t.Fatal(ini.KeyValue["sysctl"])
+	}
+}